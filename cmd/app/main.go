@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/cache"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db/cached"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db/memory"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/seed"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/server"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
 )
 
@@ -32,35 +40,69 @@ import (
 
 func main() {
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize database connection pool
-	pool, err := config.NewDatabasePool(cfg.DatabaseURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
 	}
-	defer pool.Close()
 
-	fmt.Println("Connected to PostgreSQL successfully")
+	// Choose the store: PostgreSQL, or a seeded in-memory store in demo mode
+	var store db.Store
+	if cfg.Server.DemoMode {
+		memStore := memory.New()
+		if err := seed.SeedAll(memStore, cfg.Auth.BcryptCost); err != nil {
+			log.Fatalf("Failed to seed in-memory store: %v", err)
+		}
+		store = memStore
+
+		fmt.Println("Running in demo mode with an in-memory store (data is not persisted)")
+	} else {
+		// Initialize database connection pool
+		pool, err := config.NewDatabasePool(cfg.Database)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer pool.Close()
 
-	// Wrap pool in DB struct
-	database := db.NewDB(pool)
+		fmt.Println("Connected to PostgreSQL successfully")
+
+		// Wrap pool in DB struct
+		store = db.NewDB(pool)
+	}
 
 	// Initialize JWT service
-	jwtService := auth.NewJWTService(cfg.JWTSecret)
+	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.TokenTTL))
 
 	// Initialize WebSocket hub
-	hub := websockets.NewHub()
+	hub := websockets.NewHub(cfg.WebSocket, cfg.Pricing.JSONFormat)
 	go hub.Run() // Start hub in a goroutine
 
+	// Cache hot catalog reads; catalog events invalidate them before reaching the hub
+	var events service.Publisher = hub
+	if readCache := cache.New(cfg.Cache); readCache != nil {
+		cachedStore := cached.New(store, readCache, hub, cfg.Cache)
+		store, events = cachedStore, cachedStore
+	}
+
+	// Business logic shared by HTTP handlers and future transports
+	services := service.New(store, jwtService, events, cfg)
+
+	// Purge products and categories whose trash retention expired
+	if cfg.Trash.PurgeInterval > 0 {
+		go services.Trash.RunPurger(context.Background(), time.Duration(cfg.Trash.PurgeInterval))
+	}
+
 	// Setup router with all routes and middleware
-	router := server.SetupRouter(database, jwtService, hub)
+	router := server.SetupRouter(cfg, services, jwtService, hub)
 
 	// Start server
-	addr := ":" + cfg.Port
+	addr := ":" + cfg.Server.Port
 	fmt.Printf("Server starting on %s\n", addr)
 	if err := router.Run(addr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
@@ -16,13 +17,13 @@ func main() {
 	fmt.Println()
 
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
 	// Initialize database connection pool
-	pool, err := config.NewDatabasePool(cfg.DatabaseURL)
+	pool, err := config.NewDatabasePool(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -35,7 +36,7 @@ func main() {
 	database := db.NewDB(pool)
 
 	// Run seeders
-	if err := seed.SeedAll(database); err != nil {
+	if err := seed.SeedAll(database, cfg.Auth.BcryptCost); err != nil {
 		log.Fatalf("Seeding failed: %v", err)
 	}
 
@@ -223,6 +223,82 @@ const docTemplate = `{
                 }
             }
         },
+        "/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Devuelve aciertos, fallos y entradas del cache de productos y categorías, en total y por tipo de lectura. Requiere rol de administrador.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cache"
+                ],
+                "summary": "Estadísticas del cache de lecturas",
+                "responses": {
+                    "200": {
+                        "description": "Estadísticas del cache",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CacheStats"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
         "/categories": {
             "get": {
                 "security": [
@@ -230,7 +306,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Obtiene la lista completa de categorías con búsqueda opcional por nombre",
+                "description": "Obtiene la lista completa de categorías con búsqueda opcional por nombre. Con cursor devuelve páginas ordenadas por nombre.",
                 "consumes": [
                     "application/json"
                 ],
@@ -247,11 +323,43 @@ const docTemplate = `{
                         "description": "Término de búsqueda en nombre de categoría",
                         "name": "search",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor opaco para paginar; vacío pide la primera página",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Categorías por página con cursor (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Con cursor, incluir el total de categorías",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a devolver separados por coma, ej. id,name",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de una respuesta anterior; si no cambió responde 304",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Lista de categorías",
+                        "description": "Lista de categorías (con cursor: models.CategoryCursorResponse)",
                         "schema": {
                             "allOf": [
                                 {
@@ -266,6 +374,37 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=\u003chttp_cache.categories_max_age\u003e"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifica esta versión de la lista"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Ninguna categoría cambió desde el ETag enviado"
+                    },
+                    "400": {
+                        "description": "Cursor o campos inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -351,6 +490,12 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión de la categoría"
+                            }
                         }
                     },
                     "400": {
@@ -407,6 +552,24 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "409": {
+                        "description": "Ya existe una categoría con ese nombre",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "500": {
                         "description": "Error interno del servidor",
                         "schema": {
@@ -428,54 +591,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}": {
+        "/categories/export": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Obtiene la información completa de una categoría específica",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Descarga todas las categorías, ordenadas por nombre, en CSV, NDJSON o XLSX",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
                     "categorías"
                 ],
-                "summary": "Obtener detalle de categoría",
+                "summary": "Exportar categorías",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID de la categoría",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato del archivo",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Término de búsqueda en nombre de categoría",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columnas a exportar separadas por coma, ej. id,name",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Detalle de la categoría",
+                        "description": "Archivo con una fila por categoría",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ApiResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Category"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=categories.\u003cformato\u003e"
+                            }
                         }
                     },
                     "400": {
-                        "description": "ID inválido",
+                        "description": "Formato o campos inválidos",
                         "schema": {
                             "allOf": [
                                 {
@@ -510,24 +683,6 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Categoría no encontrada",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ApiResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "$ref": "#/definitions/models.ApiError"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
                     "500": {
                         "description": "Error interno del servidor",
                         "schema": {
@@ -547,14 +702,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/categories/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Actualiza una categoría existente. Requiere rol de administrador. Los campos no enviados no se modifican. Emite evento WebSocket 'category:updated'.",
+                "description": "Obtiene la información completa de una categoría específica",
                 "consumes": [
                     "application/json"
                 ],
@@ -564,7 +721,7 @@ const docTemplate = `{
                 "tags": [
                     "categorías"
                 ],
-                "summary": "Actualizar categoría",
+                "summary": "Obtener detalle de categoría",
                 "parameters": [
                     {
                         "type": "integer",
@@ -574,18 +731,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Datos a actualizar (campos opcionales)",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CategoryUpdateRequest"
-                        }
+                        "type": "string",
+                        "description": "ETag de una respuesta anterior; si no cambió responde 304",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha HTTP; si la categoría no cambió desde entonces responde 304",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Categoría actualizada exitosamente",
+                        "description": "Detalle de la categoría",
                         "schema": {
                             "allOf": [
                                 {
@@ -600,10 +760,23 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión de la categoría, para enviar en If-Match"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Última modificación de la categoría"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "La categoría no cambió"
+                    },
                     "400": {
-                        "description": "ID inválido o datos de entrada inválidos",
+                        "description": "ID inválido",
                         "schema": {
                             "allOf": [
                                 {
@@ -638,24 +811,6 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "403": {
-                        "description": "Requiere rol de administrador",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ApiResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "$ref": "#/definitions/models.ApiError"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
                     "404": {
                         "description": "Categoría no encontrada",
                         "schema": {
@@ -694,13 +849,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Elimina una categoría del sistema. Requiere rol de administrador. Emite evento WebSocket 'category:deleted'.",
+                "description": "Actualiza una categoría existente. Requiere rol de administrador. Los campos no enviados no se modifican. Con If-Match solo se aplica si la categoría sigue en esa versión; si no, responde 412 con la versión actual. Emite evento WebSocket 'category:updated'.",
                 "consumes": [
                     "application/json"
                 ],
@@ -710,7 +865,7 @@ const docTemplate = `{
                 "tags": [
                     "categorías"
                 ],
-                "summary": "Eliminar categoría",
+                "summary": "Actualizar categoría",
                 "parameters": [
                     {
                         "type": "integer",
@@ -718,11 +873,26 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Datos a actualizar (campos opcionales)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CategoryUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer la categoría (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Categoría eliminada exitosamente",
+                        "description": "Categoría actualizada exitosamente",
                         "schema": {
                             "allOf": [
                                 {
@@ -732,15 +902,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object"
+                                            "$ref": "#/definitions/models.Category"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión de la categoría"
+                            }
                         }
                     },
                     "400": {
-                        "description": "ID inválido",
+                        "description": "ID inválido o datos de entrada inválidos",
                         "schema": {
                             "allOf": [
                                 {
@@ -811,8 +987,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Error interno del servidor",
+                    "409": {
+                        "description": "Ya existe una categoría con ese nombre",
                         "schema": {
                             "allOf": [
                                 {
@@ -828,77 +1004,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            }
-        },
-        "/products": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Obtiene una lista paginada de productos con opciones de filtrado, ordenamiento y búsqueda full-text",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "productos"
-                ],
-                "summary": "Listar productos con paginación",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Número de página",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Productos por página (máximo 100)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "name",
-                            "price",
-                            "stock",
-                            "created_at"
-                        ],
-                        "type": "string",
-                        "default": "created_at",
-                        "description": "Campo para ordenar",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Dirección de ordenamiento",
-                        "name": "sort_order",
-                        "in": "query"
                     },
-                    {
-                        "type": "string",
-                        "description": "Término de búsqueda full-text en nombres de productos",
-                        "name": "search",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Lista de productos",
+                    "412": {
+                        "description": "La categoría cambió; incluye la versión actual",
                         "schema": {
                             "allOf": [
                                 {
@@ -908,15 +1016,18 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.ProductListResponse"
+                                            "$ref": "#/definitions/models.Category"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "401": {
-                        "description": "No autenticado",
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
                         "schema": {
                             "allOf": [
                                 {
@@ -953,13 +1064,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Crea un nuevo producto en el sistema. Requiere rol de administrador. Emite evento WebSocket 'product:created'.",
+                "description": "Mueve una categoría a la papelera; deja de mostrarse en los productos hasta que se restaure. Requiere rol de administrador. Emite evento WebSocket 'category:deleted'.",
                 "consumes": [
                     "application/json"
                 ],
@@ -967,23 +1078,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "productos"
+                    "categorías"
                 ],
-                "summary": "Crear nuevo producto",
+                "summary": "Eliminar categoría",
                 "parameters": [
                     {
-                        "description": "Datos del producto",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ProductCreateRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer la categoría (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Producto creado exitosamente",
+                    "200": {
+                        "description": "Categoría eliminada exitosamente",
                         "schema": {
                             "allOf": [
                                 {
@@ -993,7 +1108,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Product"
+                                            "type": "object"
                                         }
                                     }
                                 }
@@ -1001,7 +1116,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Datos de entrada inválidos",
+                        "description": "ID inválido",
                         "schema": {
                             "allOf": [
                                 {
@@ -1054,75 +1169,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Error interno del servidor",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ApiResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "error": {
-                                            "$ref": "#/definitions/models.ApiError"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/products/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Obtiene la información completa de un producto específico incluyendo sus categorías",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "productos"
-                ],
-                "summary": "Obtener detalle de producto",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID del producto",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Detalle del producto",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/models.ApiResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Product"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "ID inválido",
+                    "404": {
+                        "description": "Categoría no encontrada",
                         "schema": {
                             "allOf": [
                                 {
@@ -1139,8 +1187,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "401": {
-                        "description": "No autenticado",
+                    "412": {
+                        "description": "La categoría cambió; incluye la versión actual",
                         "schema": {
                             "allOf": [
                                 {
@@ -1149,6 +1197,9 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Category"
+                                        },
                                         "error": {
                                             "$ref": "#/definitions/models.ApiError"
                                         }
@@ -1157,8 +1208,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Producto no encontrado",
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
                         "schema": {
                             "allOf": [
                                 {
@@ -1195,44 +1246,51 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Actualiza un producto existente. Requiere rol de administrador. Los campos no enviados no se modifican. Emite evento WebSocket 'product:updated'.",
+                "description": "Aplica un JSON Merge Patch (application/merge-patch+json) o un JSON Patch (application/json-patch+json) sobre name y description de la categoría; null en un merge patch borra la descripción. El patch se aplica entero o no se aplica. Con If-Match solo se aplica si la categoría sigue en esa versión. Requiere rol de administrador. Emite evento WebSocket 'category:updated' si algo cambió.",
                 "consumes": [
-                    "application/json"
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "productos"
+                    "categorías"
                 ],
-                "summary": "Actualizar producto",
+                "summary": "Modificar categoría con un patch",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID del producto",
+                        "description": "ID de la categoría",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Datos a actualizar (campos opcionales)",
-                        "name": "request",
+                        "description": "Merge patch (campos a cambiar) o JSON Patch (array de operaciones)",
+                        "name": "patch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ProductUpdateRequest"
+                            "$ref": "#/definitions/models.CategoryPatchDocument"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer la categoría (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Producto actualizado exitosamente",
+                        "description": "Categoría modificada",
                         "schema": {
                             "allOf": [
                                 {
@@ -1242,15 +1300,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Product"
+                                            "$ref": "#/definitions/models.Category"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión de la categoría"
+                            }
                         }
                     },
                     "400": {
-                        "description": "ID inválido o datos de entrada inválidos",
+                        "description": "ID o patch inválidos, o el resultado no es una categoría válida",
                         "schema": {
                             "allOf": [
                                 {
@@ -1304,7 +1368,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Producto no encontrado",
+                        "description": "Categoría no encontrada",
                         "schema": {
                             "allOf": [
                                 {
@@ -1321,8 +1385,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Error interno del servidor",
+                    "409": {
+                        "description": "Falló una operación test del JSON Patch, o ya existe una categoría con ese nombre",
                         "schema": {
                             "allOf": [
                                 {
@@ -1338,38 +1402,9 @@ const docTemplate = `{
                                 }
                             ]
                         }
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Elimina un producto del sistema. Requiere rol de administrador. Emite evento WebSocket 'product:deleted'.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "productos"
-                ],
-                "summary": "Eliminar producto",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID del producto",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Producto eliminado exitosamente",
+                    },
+                    "412": {
+                        "description": "La categoría cambió; incluye la versión actual",
                         "schema": {
                             "allOf": [
                                 {
@@ -1379,15 +1414,18 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object"
+                                            "$ref": "#/definitions/models.Category"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "ID inválido",
+                    "413": {
+                        "description": "El patch supera el tamaño máximo",
                         "schema": {
                             "allOf": [
                                 {
@@ -1404,8 +1442,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "401": {
-                        "description": "No autenticado",
+                    "415": {
+                        "description": "Content-Type no soportado",
                         "schema": {
                             "allOf": [
                                 {
@@ -1422,8 +1460,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "403": {
-                        "description": "Requiere rol de administrador",
+                    "422": {
+                        "description": "Una operación no se puede aplicar (ej. path inexistente)",
                         "schema": {
                             "allOf": [
                                 {
@@ -1440,8 +1478,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "Producto no encontrado",
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
                         "schema": {
                             "allOf": [
                                 {
@@ -1479,14 +1517,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/{id}/history": {
+        "/exchange-rates": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Obtiene el historial de cambios de precio y stock de un producto. Opcionalmente filtra por rango de fechas (formato RFC3339).",
+                "description": "Obtiene los tipos de cambio cargados, agrupados por par de monedas y del más reciente al más antiguo. Opcionalmente filtra por moneda base y/o cotizada.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1494,21 +1532,2495 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "productos"
+                    "tipos de cambio"
                 ],
-                "summary": "Obtener historial de producto",
+                "summary": "Listar tipos de cambio",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID del producto",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "Fecha de inicio (RFC3339: 2024-01-01T00:00:00Z)",
-                        "name": "start",
+                        "description": "Moneda base (ISO 4217, ej. USD)",
+                        "name": "base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Moneda cotizada (ISO 4217, ej. ARS)",
+                        "name": "quote",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lista de tipos de cambio",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ExchangeRateListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registra que 1 unidad de base_currency equivale a rate unidades de quote_currency desde effective_at (por defecto, ahora). Requiere rol de administrador. Emite evento WebSocket 'exchange_rate:created'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tipos de cambio"
+                ],
+                "summary": "Cargar tipo de cambio",
+                "parameters": [
+                    {
+                        "description": "Datos del tipo de cambio",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExchangeRateCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Tipo de cambio creado exitosamente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ExchangeRate"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Datos de entrada inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe un tipo de cambio para ese par y fecha",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rates/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Elimina un tipo de cambio. Requiere rol de administrador. Emite evento WebSocket 'exchange_rate:deleted'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tipos de cambio"
+                ],
+                "summary": "Eliminar tipo de cambio",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del tipo de cambio",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tipo de cambio eliminado exitosamente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Tipo de cambio no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene una lista paginada de productos con opciones de filtrado, ordenamiento y búsqueda full-text. Con ?ids= obtiene varios productos puntuales (con sus categorías) en una sola query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Listar productos con paginación",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Número de página (paginación por offset)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor opaco (next_cursor/prev_cursor) para paginación por keyset; vacío pide la primera página",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Con cursor, incluir el total de productos (cuesta un COUNT)",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Productos por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "price",
+                            "stock",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Campo para ordenar",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Dirección de ordenamiento",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orden por varios campos, ej. price:desc,name:asc (reemplaza sort_by/sort_order; siempre desempata por id)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs separados por coma (máximo 100): devuelve esos productos en ese orden y los IDs inexistentes en not_found, sin paginar ni filtrar",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Término de búsqueda full-text en nombres de productos",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filtrar por ID de categoría",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expresión de filtro, ej. price \u003e= 100 AND stock \u003c 5 AND category IN (1,3). Campos: id, name, price, currency, stock, created_at, updated_at, category. Operadores: = != \u003c \u003c= \u003e \u003e= IN, NOT IN, CONTAINS, AND, OR, NOT y paréntesis",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs de categorías separados por coma (ej. 1,3)",
+                        "name": "category_ids",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Con varias categorías: 'any' (alguna) o 'all' (todas)",
+                        "name": "category_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio mínimo (inclusive, en la moneda de cada producto)",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio máximo (inclusive)",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true: solo con stock; false: solo sin stock",
+                        "name": "in_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock mínimo (inclusive)",
+                        "name": "min_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock máximo (inclusive)",
+                        "name": "max_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados desde esta fecha (RFC3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modificados desde esta fecha (RFC3339)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convertir precios a esta moneda (ISO 4217, ej. USD)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a devolver separados por coma, ej. id,name,price (se leen solo esas columnas)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "categories"
+                        ],
+                        "type": "string",
+                        "description": "Datos embebidos: categories. Con fields, las categorías solo se devuelven si se incluyen",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de una respuesta anterior; si no cambió responde 304",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lista de productos (con cursor: models.ProductCursorResponse; con ids: models.ProductBatchResponse)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=\u003chttp_cache.products_max_age\u003e (no-cache si es 0)"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Identifica esta versión de la página"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Ningún producto ni categoría cambió desde el ETag enviado (sin currency se responde sin leer la página)"
+                    },
+                    "400": {
+                        "description": "Moneda, filtro, campos o cursor inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Crea un nuevo producto en el sistema. Requiere rol de administrador. Emite evento WebSocket 'product:created'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Crear nuevo producto",
+                "parameters": [
+                    {
+                        "description": "Datos del producto",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Producto creado exitosamente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del producto"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Datos de entrada inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "category_ids contiene categorías inexistentes",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/bulk": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mueve a la papelera varios productos, elegidos por ids o por filter, en una sola transacción. Requiere rol de administrador. Emite un único evento WebSocket 'product:bulk_deleted'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Eliminar productos en lote",
+                "parameters": [
+                    {
+                        "description": "Selección: ids o filter",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductBulkDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resumen: productos eliminados e IDs pedidos que no existen",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductBulkResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Selección o filtro inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Aplica los mismos cambios a varios productos, elegidos por ids o por filter (una expresión como la de ?filter= en GET /products, ej. category IN (1)). Cambios posibles: set_price o adjust_price_percent (redondeado a centavos), set_stock o adjust_stock, add_category_ids y remove_category_ids. Todo ocurre en una transacción: si un producto no admite el cambio (ej. el stock quedaría negativo) no se modifica ninguno. El historial de precios y stock se registra por producto, y cada cambio de stock agrega un movimiento 'adjustment' al libro del producto. Requiere rol de administrador. Emite un único evento WebSocket 'product:bulk_updated'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Actualizar productos en lote",
+                "parameters": [
+                    {
+                        "description": "Selección (ids o filter) y cambios a aplicar",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductBulkUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resumen: productos modificados e IDs pedidos que no existen",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductBulkResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Selección, filtro o cambios inválidos, o el resultado viola una regla (ej. stock negativo)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "add_category_ids contiene categorías inexistentes",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Descarga todos los productos que cumplen los filtros (los mismos que GET /products) en CSV, NDJSON o XLSX, con sus categorías por nombre separadas por \"|\". El archivo se genera fila por fila mientras se lee la base, sin paginar. Los precios se exportan en la moneda de cada producto. Con fields=sku,name,description,price,currency,stock\u0026include=categories el CSV se puede volver a importar con POST /products/import.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Exportar productos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato del archivo",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "price",
+                            "stock",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Campo para ordenar",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Dirección de ordenamiento",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orden por varios campos, ej. price:desc,name:asc",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Término de búsqueda full-text en nombres de productos",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expresión de filtro, como en GET /products",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs de categorías separados por coma (ej. 1,3)",
+                        "name": "category_ids",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Con varias categorías: 'any' (alguna) o 'all' (todas)",
+                        "name": "category_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio mínimo (inclusive)",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio máximo (inclusive)",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true: solo con stock; false: solo sin stock",
+                        "name": "in_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock mínimo (inclusive)",
+                        "name": "min_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock máximo (inclusive)",
+                        "name": "max_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados desde esta fecha (RFC3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modificados desde esta fecha (RFC3339)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columnas a exportar separadas por coma, ej. sku,name,price",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "categories"
+                        ],
+                        "type": "string",
+                        "description": "Con fields, agrega la columna de categorías",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Archivo con una fila por producto",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=products.\u003cformato\u003e"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Formato, filtro u orden inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Crea o actualiza productos desde un archivo CSV (con encabezado: sku, name, description, price, currency, stock, categories; varias categorías separadas por \"|\") o NDJSON (un producto por línea, categories como array). Las categorías se indican por nombre o por ID (si un valor coincide con un nombre, se usa ese nombre). Las filas cuyo sku pertenece a un producto existente lo actualizan (un cambio de stock se registra como movimiento 'adjustment'); el resto crea productos. Con mode=atomic no se escribe nada si alguna fila tiene errores (responde 422 con el reporte); con mode=best_effort se escriben las filas válidas y se informan las demás. Con dry_run=true se valida todo sin guardar cambios y se responde con el mismo status que la importación real (422 si mode=atomic tiene filas inválidas). Requiere rol de administrador. Emite un único evento WebSocket 'product:imported'.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Importar productos en lote",
+                "parameters": [
+                    {
+                        "description": "Contenido del archivo CSV o NDJSON (máximo 10 MB y 10000 filas)",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "atomic",
+                            "best_effort"
+                        ],
+                        "type": "string",
+                        "default": "atomic",
+                        "description": "Qué hacer con las filas inválidas",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Validar y reportar sin guardar cambios",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reporte por fila de la importación",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductImportReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Archivo o modo inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "413": {
+                        "description": "El archivo supera el tamaño máximo",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type no soportado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Modo atomic con filas inválidas (también con dry_run); no se importó nada",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductImportReport"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene la información completa de un producto específico incluyendo sus categorías",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Obtener detalle de producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convertir el precio a esta moneda (ISO 4217, ej. USD)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag de una respuesta anterior; si no cambió responde 304",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha HTTP; si el producto no cambió desde entonces responde 304",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detalle del producto",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del producto y de sus categorías, para enviar en If-Match o If-None-Match (débil si se convirtió el precio)"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Última modificación del producto o sus categorías"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "El producto no cambió"
+                    },
+                    "400": {
+                        "description": "ID o moneda inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "No hay tipo de cambio para la moneda pedida",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Actualiza un producto existente. Requiere rol de administrador. Los campos no enviados no se modifican. Un cambio de stock se registra como movimiento 'adjustment' en el libro del producto. Con If-Match solo se aplica si el producto sigue en esa versión; si no, responde 412 con la versión actual. Emite evento WebSocket 'product:updated'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Actualizar producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos a actualizar (campos opcionales)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer el producto (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Producto actualizado exitosamente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión del producto"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido o datos de entrada inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "El producto cambió; incluye la versión actual",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "category_ids contiene categorías inexistentes",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mueve un producto a la papelera; se puede restaurar hasta que se purgue. Requiere rol de administrador. Emite evento WebSocket 'product:deleted'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Eliminar producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer el producto (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Producto eliminado exitosamente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "El producto cambió; incluye la versión actual",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Aplica un JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json) o un JSON Patch (RFC 6902, Content-Type application/json-patch+json) sobre el documento editable del producto: sku, name, description, price, currency, stock y category_ids. Con merge patch, null borra sku o description; con JSON Patch se puede, por ejemplo, agregar una categoría con {\"op\":\"add\",\"path\":\"/category_ids/-\",\"value\":3}. El patch se aplica entero o no se aplica, en una transacción, y el resultado se valida como un producto nuevo. Un cambio de stock se registra como movimiento 'adjustment'. Con If-Match solo se aplica si el producto sigue en esa versión. Requiere rol de administrador. Emite evento WebSocket 'product:updated' si algo cambió.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Modificar producto con un patch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch (campos a cambiar) o JSON Patch (array de operaciones)",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductPatchDocument"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer el producto (ej. \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Producto modificado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión del producto"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ID o patch inválidos, o el resultado no es un producto válido",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Falló una operación test del JSON Patch, o el sku ya está en uso",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "El producto cambió; incluye la versión actual",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Product"
+                                        },
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "413": {
+                        "description": "El patch supera el tamaño máximo",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type no soportado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "422": {
+                        "description": "Una operación no se puede aplicar (ej. path inexistente) o category_ids contiene categorías inexistentes",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "428": {
+                        "description": "Falta If-Match (si concurrency.require_if_match está activo)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene el historial de cambios de precio y stock de un producto. Opcionalmente filtra por rango de fechas (formato RFC3339).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Obtener historial de producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha de inicio (RFC3339: 2024-01-01T00:00:00Z)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha de fin (RFC3339: 2024-12-31T23:59:59Z)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor opaco para paginar el historial; vacío pide la primera página",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Registros por página con cursor (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Con cursor, incluir el total de registros",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a devolver separados por coma, ej. changed_at,price",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Historial del producto (con cursor: models.ProductHistoryCursorResponse)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductHistoryResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido, formato de fecha incorrecto, campos o cursor inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado o en la papelera",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/history/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Descarga todos los cambios de precio y stock de un producto, del más reciente al más antiguo, en CSV, NDJSON o XLSX",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Exportar historial de producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato del archivo",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha de inicio (RFC3339: 2024-01-01T00:00:00Z)",
+                        "name": "start",
                         "in": "query"
                     },
                     {
@@ -1516,11 +4028,1054 @@ const docTemplate = `{
                         "description": "Fecha de fin (RFC3339: 2024-12-31T23:59:59Z)",
                         "name": "end",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columnas a exportar separadas por coma, ej. changed_at,price",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Archivo con una fila por cambio",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=product-\u003cid\u003e-history.\u003cformato\u003e"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ID, formato, fechas o campos inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado o en la papelera",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/stock-movements": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene el libro de movimientos de stock de un producto, del más reciente al más antiguo. Pagina por offset (page/limit) o con cursor, y opcionalmente filtra por motivo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Listar movimientos de stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Motivo (sale, restock, adjustment, return, damage)",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Número de página (paginación por offset)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Movimientos por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor opaco para paginación por cursor; vacío pide la primera página",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Con cursor, incluir el total de movimientos",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Movimientos del producto (con cursor: models.StockMovementCursorResponse)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.StockMovementListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID, motivo o cursor inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Suma al stock del producto una cantidad con signo y lo registra en el libro de movimientos con su motivo, una referencia opcional (ej. número de pedido) y el usuario que lo cargó. Motivos: sale y damage restan (cantidad negativa), restock y return suman (positiva), adjustment admite ambos signos. El producto se bloquea mientras se aplica, así que movimientos simultáneos se aplican de a uno; si el stock quedara negativo se rechaza sin cambiar nada. También queda en el historial del producto. Requiere rol de administrador. Emite evento WebSocket 'product:stock_moved'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "productos"
+                ],
+                "summary": "Registrar movimiento de stock",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Movimiento a registrar",
+                        "name": "movement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StockMovementCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Movimiento registrado, con el stock resultante",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.StockMovement"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID o datos inválidos, o el signo de la cantidad no corresponde al motivo",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Producto no encontrado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Stock insuficiente",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Busca productos o categorías según el parámetro 'type'. Soporta paginación, ordenamiento y filtros. Para productos, permite filtrar por categorías, rango de precio y stock, y fechas de alta o modificación.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "búsqueda"
+                ],
+                "summary": "Búsqueda universal",
+                "parameters": [
+                    {
+                        "enum": [
+                            "product",
+                            "category"
+                        ],
+                        "type": "string",
+                        "default": "product",
+                        "description": "Tipo de búsqueda",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Término de búsqueda",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Número de página",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items por página",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "name",
+                        "description": "Campo para ordenar (name, price, stock, created_at para products)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Dirección de ordenamiento",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Orden por varios campos, ej. price:desc,name:asc (name y created_at para categorías); siempre desempata por id",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filtrar productos por ID de categoría (solo para type=product)",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expresión de filtro, ej. price \u003e= 100 AND stock \u003c 5 AND category IN (1,3). Campos: id, name, price, currency, stock, created_at, updated_at, category. Operadores: = != \u003c \u003c= \u003e \u003e= IN, NOT IN, CONTAINS, AND, OR, NOT y paréntesis",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IDs de categorías separados por coma (ej. 1,3)",
+                        "name": "category_ids",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Con varias categorías: 'any' (alguna) o 'all' (todas)",
+                        "name": "category_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio mínimo (inclusive, en la moneda de cada producto)",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Precio máximo (inclusive)",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true: solo con stock; false: solo sin stock",
+                        "name": "in_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock mínimo (inclusive)",
+                        "name": "min_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Stock máximo (inclusive)",
+                        "name": "max_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados desde esta fecha (RFC3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modificados desde esta fecha (RFC3339)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convertir precios a esta moneda (solo para type=product)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a devolver separados por coma, ej. id,name,price",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "categories"
+                        ],
+                        "type": "string",
+                        "description": "Datos embebidos (solo para type=product): categories",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resultados de búsqueda (ProductListResponse o CategoryListResponse según type)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Parámetros inválidos",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/categories": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene las categorías eliminadas que todavía pueden restaurarse, de la más reciente a la más antigua. Requiere rol de administrador.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "papelera"
+                ],
+                "summary": "Listar categorías en la papelera",
+                "responses": {
+                    "200": {
+                        "description": "Categorías eliminadas",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.CategoryListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/categories/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saca una categoría de la papelera; los productos que la tenían la recuperan. Requiere rol de administrador. Emite evento WebSocket 'category:restored'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "papelera"
+                ],
+                "summary": "Restaurar categoría",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la categoría",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Categoría restaurada",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Category"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID inválido",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "La categoría no está en la papelera",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "Ya existe otra categoría con ese nombre",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/products": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Obtiene los productos eliminados que todavía pueden restaurarse, del más reciente al más antiguo. Requiere rol de administrador.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "papelera"
+                ],
+                "summary": "Listar productos en la papelera",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Número de página",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Productos por página (máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Productos eliminados",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.ProductListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "No autenticado",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Error interno del servidor",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/products/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Saca un producto de la papelera junto con sus categorías e historial. Requiere rol de administrador. Emite evento WebSocket 'product:restored'.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "papelera"
+                ],
+                "summary": "Restaurar producto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del producto",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Historial del producto",
+                        "description": "Producto restaurado",
                         "schema": {
                             "allOf": [
                                 {
@@ -1530,7 +5085,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.ProductHistoryResponse"
+                                            "$ref": "#/definitions/models.Product"
                                         }
                                     }
                                 }
@@ -1538,7 +5093,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "ID inválido o formato de fecha incorrecto",
+                        "description": "ID inválido",
                         "schema": {
                             "allOf": [
                                 {
@@ -1573,6 +5128,42 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "403": {
+                        "description": "Requiere rol de administrador",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "El producto no está en la papelera",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.ApiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "error": {
+                                            "$ref": "#/definitions/models.ApiError"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "500": {
                         "description": "Error interno del servidor",
                         "schema": {
@@ -1594,14 +5185,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/search": {
-            "get": {
+        "/trash/purge": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Busca productos o categorías según el parámetro 'type'. Soporta paginación, ordenamiento y filtros. Para productos, permite filtrar por category_id.",
+                "description": "Elimina definitivamente los productos y categorías que llevan en la papelera más que el período de retención (trash.retention), junto con su historial y relaciones. Requiere rol de administrador. Emite evento WebSocket 'trash:purged'.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1609,69 +5200,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "búsqueda"
-                ],
-                "summary": "Búsqueda universal",
-                "parameters": [
-                    {
-                        "enum": [
-                            "product",
-                            "category"
-                        ],
-                        "type": "string",
-                        "default": "product",
-                        "description": "Tipo de búsqueda",
-                        "name": "type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Término de búsqueda",
-                        "name": "q",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Número de página",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items por página",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "name",
-                        "description": "Campo para ordenar (name, price, stock, created_at para products)",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
-                        "type": "string",
-                        "default": "asc",
-                        "description": "Dirección de ordenamiento",
-                        "name": "sort_order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Filtrar productos por ID de categoría (solo para type=product)",
-                        "name": "category_id",
-                        "in": "query"
-                    }
+                    "papelera"
                 ],
+                "summary": "Purgar papelera",
                 "responses": {
                     "200": {
-                        "description": "Resultados de búsqueda (ProductListResponse o CategoryListResponse según type)",
+                        "description": "Resultado de la purga",
                         "schema": {
                             "allOf": [
                                 {
@@ -1681,15 +5215,15 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.ProductListResponse"
+                                            "$ref": "#/definitions/models.TrashPurgeResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Parámetros inválidos",
+                    "401": {
+                        "description": "No autenticado",
                         "schema": {
                             "allOf": [
                                 {
@@ -1706,8 +5240,8 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "401": {
-                        "description": "No autenticado",
+                    "403": {
+                        "description": "Requiere rol de administrador",
                         "schema": {
                             "allOf": [
                                 {
@@ -1756,6 +5290,13 @@ const docTemplate = `{
                 "details": {
                     "type": "string"
                 },
+                "fields": {
+                    "description": "Per-field validation errors",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
                 "message": {
                     "type": "string"
                 }
@@ -1773,6 +5314,53 @@ const docTemplate = `{
                 }
             }
         },
+        "models.CacheResourceStats": {
+            "type": "object",
+            "properties": {
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CacheStats": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string",
+                    "example": "memory"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "description": "hits / (hits + misses)",
+                    "type": "number",
+                    "example": 0.93
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "max_entries": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "resources": {
+                    "description": "Keyed by product, category and category_list",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.CacheResourceStats"
+                    }
+                }
+            }
+        },
         "models.Category": {
             "type": "object",
             "required": [
@@ -1782,6 +5370,10 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "Set while the category is in the trash",
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1793,6 +5385,11 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Incremented on every write; exposed as the ETag",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
@@ -1810,37 +5407,162 @@ const docTemplate = `{
                 }
             }
         },
-        "models.CategoryListResponse": {
+        "models.CategoryListResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Category"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CategoryPatchDocument": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CategoryUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "base_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "effective_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quote_currency": {
+                    "type": "string",
+                    "example": "ARS"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1050.5
+                }
+            }
+        },
+        "models.ExchangeRateCreateRequest": {
+            "type": "object",
+            "required": [
+                "base_currency",
+                "quote_currency",
+                "rate"
+            ],
+            "properties": {
+                "base_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "effective_at": {
+                    "description": "Defaults to now",
+                    "type": "string"
+                },
+                "quote_currency": {
+                    "type": "string",
+                    "example": "ARS"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1050.5
+                }
+            }
+        },
+        "models.ExchangeRateListResponse": {
             "type": "object",
             "properties": {
-                "categories": {
+                "rates": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Category"
+                        "$ref": "#/definitions/models.ExchangeRate"
                     }
                 },
-                "limit": {
+                "total": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
                 },
-                "page": {
-                    "type": "integer"
+                "message": {
+                    "type": "string"
                 },
-                "total": {
-                    "type": "integer"
+                "param": {
+                    "type": "string"
                 },
-                "total_pages": {
-                    "type": "integer"
+                "rule": {
+                    "type": "string"
                 }
             }
         },
-        "models.CategoryUpdateRequest": {
+        "models.PriceConversion": {
             "type": "object",
             "properties": {
-                "description": {
+                "effective_at": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "inverted": {
+                    "description": "Rate derived from the opposite pair (1 / rate)",
+                    "type": "boolean"
+                },
+                "original_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "original_price": {
+                    "type": "number",
+                    "example": 19.99
+                },
+                "rate": {
+                    "description": "Original currency → response currency",
+                    "type": "number",
+                    "example": 1050.5
+                },
+                "rate_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -1859,9 +5581,25 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Category"
                     }
                 },
+                "conversion": {
+                    "description": "Set when prices are requested in another currency",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PriceConversion"
+                        }
+                    ]
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "ARS"
+                },
+                "deleted_at": {
+                    "description": "Set while the product is in the trash",
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1873,7 +5611,13 @@ const docTemplate = `{
                 },
                 "price": {
                     "type": "number",
-                    "minimum": 0
+                    "minimum": 0,
+                    "example": 19.99
+                },
+                "sku": {
+                    "description": "External code, e.g. the supplier's; unique among live products",
+                    "type": "string",
+                    "example": "ACME-1042"
                 },
                 "stock": {
                     "type": "integer",
@@ -1881,6 +5625,105 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Incremented on every write; exposed as the ETag",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.ProductBulkDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "type": "string",
+                    "example": "stock = 0"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "models.ProductBulkResult": {
+            "type": "object",
+            "properties": {
+                "affected": {
+                    "type": "integer"
+                },
+                "not_found": {
+                    "description": "Requested IDs with no live product",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_ids": {
+                    "description": "Products changed, by ID",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.ProductBulkUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "add_category_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "adjust_price_percent": {
+                    "description": "e.g. 5 raises prices 5%, rounded to cents",
+                    "type": "number",
+                    "minimum": -100,
+                    "example": -10
+                },
+                "adjust_stock": {
+                    "description": "Added to the current stock, which must stay \u003e= 0",
+                    "type": "integer",
+                    "example": -2
+                },
+                "filter": {
+                    "type": "string",
+                    "example": "category IN (1) AND stock \u003e 0"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "remove_category_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "set_price": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 19.99
+                },
+                "set_stock": {
+                    "type": "integer",
+                    "minimum": 0
                 }
             }
         },
@@ -1901,6 +5744,11 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
+                "currency": {
+                    "description": "Defaults to ARS",
+                    "type": "string",
+                    "example": "ARS"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1909,7 +5757,12 @@ const docTemplate = `{
                 },
                 "price": {
                     "type": "number",
-                    "minimum": 0
+                    "minimum": 0,
+                    "example": 19.99
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "ACME-1042"
                 },
                 "stock": {
                     "type": "integer",
@@ -1923,11 +5776,16 @@ const docTemplate = `{
                 "changed_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "ARS"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "price": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 19.99
                 },
                 "product_id": {
                     "type": "integer"
@@ -1951,6 +5809,72 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ProductImportReport": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Whether any change was written",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ]
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImportResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ProductImportResult": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "error"
+                    ]
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "description": "Not set for rows created in a dry run",
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                }
+            }
+        },
         "models.ProductListResponse": {
             "type": "object",
             "properties": {
@@ -1974,8 +5898,15 @@ const docTemplate = `{
                 }
             }
         },
-        "models.ProductUpdateRequest": {
+        "models.ProductPatchDocument": {
             "type": "object",
+            "required": [
+                "category_ids",
+                "currency",
+                "name",
+                "price",
+                "stock"
+            ],
             "properties": {
                 "category_ids": {
                     "type": "array",
@@ -1984,6 +5915,10 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "ARS"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1992,7 +5927,47 @@ const docTemplate = `{
                 },
                 "price": {
                     "type": "number",
+                    "minimum": 0,
+                    "example": 19.99
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "ACME-1042"
+                },
+                "stock": {
+                    "type": "integer",
                     "minimum": 0
+                }
+            }
+        },
+        "models.ProductUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "category_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 19.99
+                },
+                "sku": {
+                    "type": "string",
+                    "example": "ACME-1042"
                 },
                 "stock": {
                     "type": "integer",
@@ -2014,6 +5989,110 @@ const docTemplate = `{
                 }
             }
         },
+        "models.StockMovement": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "description": "Signed: negative takes units out",
+                    "type": "integer",
+                    "example": -2
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "sale"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "ORDER-1042"
+                },
+                "stock_after": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "user_id": {
+                    "description": "Who recorded it; empty once the user is deleted",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.StockMovementCreateRequest": {
+            "type": "object",
+            "required": [
+                "quantity",
+                "reason"
+            ],
+            "properties": {
+                "quantity": {
+                    "description": "Non-zero; the sign must suit the reason",
+                    "type": "integer",
+                    "example": -2
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "sale",
+                        "restock",
+                        "adjustment",
+                        "return",
+                        "damage"
+                    ],
+                    "example": "sale"
+                },
+                "reference": {
+                    "description": "e.g. an order or delivery note",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "ORDER-1042"
+                }
+            }
+        },
+        "models.StockMovementListResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "movements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StockMovement"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashPurgeResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "integer"
+                },
+                "deleted_before": {
+                    "description": "Items deleted before this instant were purged",
+                    "type": "string"
+                },
+                "products": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.UserLoginRequest": {
             "type": "object",
             "required": [
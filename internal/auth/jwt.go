@@ -16,17 +16,19 @@ type JWTClaims struct {
 
 type JWTService struct {
 	secret []byte
+	ttl    time.Duration
 }
 
-func NewJWTService(secret string) *JWTService {
+func NewJWTService(secret string, ttl time.Duration) *JWTService {
 	return &JWTService{
 		secret: []byte(secret),
+		ttl:    ttl,
 	}
 }
 
 func (s *JWTService) GenerateToken(userID int, email, role string) (string, error) {
-	// Set token expiration to 24 hours
-	expirationTime := time.Now().Add(24 * time.Hour)
+	// Set token expiration to the configured TTL
+	expirationTime := time.Now().Add(s.ttl)
 
 	claims := &JWTClaims{
 		UserID: userID,
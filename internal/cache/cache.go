@@ -0,0 +1,138 @@
+// Package cache provides the pluggable key/value cache used for hot catalog
+// reads. Backends only need to store values with a TTL; invalidation and
+// statistics live with the callers.
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+)
+
+// Backend names accepted in cache.backend
+const (
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// Cache stores values by key until they expire or are deleted.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration)
+	Delete(key string)
+	// DeletePrefix removes every key starting with prefix
+	DeletePrefix(prefix string)
+	// Len reports the number of stored entries, including expired ones not yet evicted
+	Len() int
+}
+
+// New builds the backend selected in the configuration, or nil when caching is disabled
+func New(cfg config.CacheConfig) Cache {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewLRU(cfg.MaxEntries)
+	default:
+		return nil
+	}
+}
+
+// LRU is an in-process Cache that evicts the least recently used entry
+// once it holds maxEntries
+type LRU struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // front is the most recently used
+	entries    map[string]*list.Element
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+var _ Cache = (*LRU)(nil)
+
+func NewLRU(maxEntries int) *LRU {
+	return &LRU{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (l *LRU) Get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if !time.Now().Before(e.expiresAt) {
+		l.remove(elem)
+		return nil, false
+	}
+
+	l.order.MoveToFront(elem)
+	return e.value, true
+}
+
+func (l *LRU) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 || l.maxEntries <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := l.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.maxEntries {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *LRU) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		l.remove(elem)
+	}
+}
+
+func (l *LRU) DeletePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, elem := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(elem)
+		}
+	}
+}
+
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*entry).key)
+}
@@ -1,41 +1,510 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
 )
 
+// Config is the typed application configuration.
+// Values are layered: defaults → config file (YAML/TOML) → environment → flags.
 type Config struct {
-	DatabaseURL string
-	Port        string
-	JWTSecret   string
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Pagination  PaginationConfig  `yaml:"pagination" toml:"pagination"`
+	WebSocket   WebSocketConfig   `yaml:"websocket" toml:"websocket"`
+	Pricing     PricingConfig     `yaml:"pricing" toml:"pricing"`
+	Trash       TrashConfig       `yaml:"trash" toml:"trash"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency" toml:"concurrency"`
+	HTTPCache   HTTPCacheConfig   `yaml:"http_cache" toml:"http_cache"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+
+	// PrintConfig is set by --print-config; it is never read from a file
+	PrintConfig bool `yaml:"-" toml:"-"`
+}
+
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port"`
+	// DemoMode runs the API on a seeded in-memory store instead of PostgreSQL
+	DemoMode bool `yaml:"demo_mode" toml:"demo_mode"`
+}
+
+type DatabaseConfig struct {
+	URL            string   `yaml:"url" toml:"url"`
+	MaxConns       int32    `yaml:"max_conns" toml:"max_conns"`
+	MinConns       int32    `yaml:"min_conns" toml:"min_conns"`
+	ConnectTimeout Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+}
+
+type AuthConfig struct {
+	JWTSecret  string   `yaml:"jwt_secret" toml:"jwt_secret"`
+	TokenTTL   Duration `yaml:"token_ttl" toml:"token_ttl"`
+	BcryptCost int      `yaml:"bcrypt_cost" toml:"bcrypt_cost"`
+}
+
+type PaginationConfig struct {
+	DefaultLimit       int `yaml:"default_limit" toml:"default_limit"`
+	SearchDefaultLimit int `yaml:"search_default_limit" toml:"search_default_limit"`
+	MaxLimit           int `yaml:"max_limit" toml:"max_limit"`
+}
+
+type WebSocketConfig struct {
+	ReadBufferSize      int   `yaml:"read_buffer_size" toml:"read_buffer_size"`
+	WriteBufferSize     int   `yaml:"write_buffer_size" toml:"write_buffer_size"`
+	SendBufferSize      int   `yaml:"send_buffer_size" toml:"send_buffer_size"`
+	BroadcastBufferSize int   `yaml:"broadcast_buffer_size" toml:"broadcast_buffer_size"`
+	MaxMessageSize      int64 `yaml:"max_message_size" toml:"max_message_size"`
+}
+
+type PricingConfig struct {
+	// JSONFormat encodes prices as fixed precision numbers ("number") or strings ("string")
+	JSONFormat string `yaml:"json_format" toml:"json_format"`
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted products and categories stay restorable
+	Retention Duration `yaml:"retention" toml:"retention"`
+	// PurgeInterval is how often expired items are purged; 0 disables the background purge
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+type ConcurrencyConfig struct {
+	// RequireIfMatch rejects updates and deletes without If-Match (428)
+	RequireIfMatch bool `yaml:"require_if_match" toml:"require_if_match"`
+}
+
+type HTTPCacheConfig struct {
+	// ProductsMaxAge is the Cache-Control max-age of product reads; 0 means revalidate every time
+	ProductsMaxAge Duration `yaml:"products_max_age" toml:"products_max_age"`
+	// CategoriesMaxAge is the Cache-Control max-age of category reads
+	CategoriesMaxAge Duration `yaml:"categories_max_age" toml:"categories_max_age"`
+}
+
+type CacheConfig struct {
+	// Backend is "memory" (in-process LRU) or "none" to read straight from the store
+	Backend    string `yaml:"backend" toml:"backend"`
+	MaxEntries int    `yaml:"max_entries" toml:"max_entries"`
+	// ProductTTL and CategoryTTL bound how stale an entry can get when an
+	// invalidation is missed (e.g. writes made by another instance)
+	ProductTTL  Duration `yaml:"product_ttl" toml:"product_ttl"`
+	CategoryTTL Duration `yaml:"category_ttl" toml:"category_ttl"`
+}
+
+// Duration is a time.Duration that reads and writes as "24h", "90s", etc.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the configuration used when nothing else is provided
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port: "8080",
+		},
+		Database: DatabaseConfig{
+			MaxConns:       10,
+			MinConns:       0,
+			ConnectTimeout: Duration(10 * time.Second),
+		},
+		Auth: AuthConfig{
+			TokenTTL:   Duration(24 * time.Hour),
+			BcryptCost: 10,
+		},
+		Pagination: PaginationConfig{
+			DefaultLimit:       10,
+			SearchDefaultLimit: 20,
+			MaxLimit:           100,
+		},
+		WebSocket: WebSocketConfig{
+			ReadBufferSize:      1024,
+			WriteBufferSize:     1024,
+			SendBufferSize:      256,
+			BroadcastBufferSize: 256,
+			MaxMessageSize:      512,
+		},
+		Pricing: PricingConfig{
+			JSONFormat: money.FormatNumber,
+		},
+		Trash: TrashConfig{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		HTTPCache: HTTPCacheConfig{
+			CategoriesMaxAge: Duration(time.Minute),
+		},
+		Cache: CacheConfig{
+			Backend:     "memory",
+			MaxEntries:  10000,
+			ProductTTL:  Duration(time.Minute),
+			CategoryTTL: Duration(10 * time.Minute),
+		},
+	}
 }
 
-// Load reads configuration from environment variables
-func Load() (*Config, error) {
+// setting describes a single configuration value and where it can be overridden
+type setting struct {
+	key   string // dotted path used in files and error messages
+	env   string
+	flag  string
+	usage string
+	ptr   interface{}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"server.port", "PORT", "port", "HTTP listen port", &c.Server.Port},
+		{"server.demo_mode", "DEMO_MODE", "demo", "use a seeded in-memory store instead of PostgreSQL", &c.Server.DemoMode},
+		{"database.url", "DATABASE_URL", "database-url", "PostgreSQL connection URL", &c.Database.URL},
+		{"database.max_conns", "DB_MAX_CONNS", "db-max-conns", "maximum connections in the pool", &c.Database.MaxConns},
+		{"database.min_conns", "DB_MIN_CONNS", "db-min-conns", "minimum idle connections in the pool", &c.Database.MinConns},
+		{"database.connect_timeout", "DB_CONNECT_TIMEOUT", "db-connect-timeout", "timeout for the initial connection", &c.Database.ConnectTimeout},
+		{"auth.jwt_secret", "JWT_SECRET", "jwt-secret", "secret used to sign JWT tokens", &c.Auth.JWTSecret},
+		{"auth.token_ttl", "JWT_TTL", "jwt-ttl", "lifetime of issued JWT tokens", &c.Auth.TokenTTL},
+		{"auth.bcrypt_cost", "BCRYPT_COST", "bcrypt-cost", "bcrypt cost factor for password hashing", &c.Auth.BcryptCost},
+		{"pagination.default_limit", "PAGINATION_DEFAULT_LIMIT", "page-default-limit", "default page size for listings", &c.Pagination.DefaultLimit},
+		{"pagination.search_default_limit", "PAGINATION_SEARCH_DEFAULT_LIMIT", "page-search-default-limit", "default page size for /search", &c.Pagination.SearchDefaultLimit},
+		{"pagination.max_limit", "PAGINATION_MAX_LIMIT", "page-max-limit", "maximum page size", &c.Pagination.MaxLimit},
+		{"websocket.read_buffer_size", "WS_READ_BUFFER_SIZE", "ws-read-buffer-size", "WebSocket upgrader read buffer in bytes", &c.WebSocket.ReadBufferSize},
+		{"websocket.write_buffer_size", "WS_WRITE_BUFFER_SIZE", "ws-write-buffer-size", "WebSocket upgrader write buffer in bytes", &c.WebSocket.WriteBufferSize},
+		{"websocket.send_buffer_size", "WS_SEND_BUFFER_SIZE", "ws-send-buffer-size", "queued outbound messages per client", &c.WebSocket.SendBufferSize},
+		{"websocket.broadcast_buffer_size", "WS_BROADCAST_BUFFER_SIZE", "ws-broadcast-buffer-size", "queued broadcast messages in the hub", &c.WebSocket.BroadcastBufferSize},
+		{"websocket.max_message_size", "WS_MAX_MESSAGE_SIZE", "ws-max-message-size", "maximum inbound message size in bytes", &c.WebSocket.MaxMessageSize},
+		{"pricing.json_format", "PRICE_JSON_FORMAT", "price-json-format", "encode prices as JSON numbers or strings (number|string)", &c.Pricing.JSONFormat},
+		{"trash.retention", "TRASH_RETENTION", "trash-retention", "how long deleted items can be restored before they are purged", &c.Trash.Retention},
+		{"trash.purge_interval", "TRASH_PURGE_INTERVAL", "trash-purge-interval", "how often expired trash is purged (0 disables)", &c.Trash.PurgeInterval},
+		{"concurrency.require_if_match", "REQUIRE_IF_MATCH", "require-if-match", "reject updates and deletes without an If-Match header", &c.Concurrency.RequireIfMatch},
+		{"http_cache.products_max_age", "HTTP_CACHE_PRODUCTS_MAX_AGE", "http-cache-products-max-age", "how long clients may reuse product reads without revalidating", &c.HTTPCache.ProductsMaxAge},
+		{"http_cache.categories_max_age", "HTTP_CACHE_CATEGORIES_MAX_AGE", "http-cache-categories-max-age", "how long clients may reuse category reads without revalidating", &c.HTTPCache.CategoriesMaxAge},
+		{"cache.backend", "CACHE_BACKEND", "cache-backend", "read cache backend: memory or none", &c.Cache.Backend},
+		{"cache.max_entries", "CACHE_MAX_ENTRIES", "cache-max-entries", "maximum number of cached reads", &c.Cache.MaxEntries},
+		{"cache.product_ttl", "CACHE_PRODUCT_TTL", "cache-product-ttl", "how long a cached product is served", &c.Cache.ProductTTL},
+		{"cache.category_ttl", "CACHE_CATEGORY_TTL", "cache-category-ttl", "how long cached categories are served", &c.Cache.CategoryTTL},
+	}
+}
+
+// Load builds the configuration from defaults, an optional config file,
+// environment variables and command line flags (in that order of precedence)
+func Load(args []string) (*Config, error) {
 	// Load .env file if it exists (for local development)
 	// Ignore error if .env doesn't exist
 	_ = godotenv.Load()
 
-	cfg := &Config{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		Port:        os.Getenv("PORT"),
-		JWTSecret:   os.Getenv("JWT_SECRET"),
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Parse flags first to find the config file, but apply them last
+	fs := flag.NewFlagSet("bsmart", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration (secrets redacted) and exit")
+
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		name := s.flag
+		record := func(v string) error {
+			flagValues[name] = v
+			return nil
+		}
+		if _, isBool := s.ptr.(*bool); isBool {
+			fs.BoolFunc(name, s.usage+" (env "+s.env+")", record)
+		} else {
+			fs.Func(name, s.usage+" (env "+s.env+")", record)
+		}
 	}
 
-	if cfg.Port == "" {
-		cfg.Port = "8080"
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
 
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
+	var problems []string
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			problems = append(problems, err.Error())
+		}
 	}
 
-	if cfg.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required")
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok && raw != "" {
+			if err := setValue(s.ptr, raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid value %q from env %s: %v", s.key, raw, s.env, err))
+			}
+		}
 	}
 
+	for _, s := range settings {
+		if raw, ok := flagValues[s.flag]; ok {
+			if err := setValue(s.ptr, raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid value %q from flag --%s: %v", s.key, raw, s.flag, err))
+			}
+		}
+	}
+
+	problems = append(problems, cfg.validate()...)
+	// Printing the configuration must work while secrets are still missing
+	if !*printConfig {
+		problems = append(problems, cfg.validateSecrets()...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	cfg.PrintConfig = *printConfig
+
 	return cfg, nil
 }
+
+// loadFile decodes a YAML or TOML file on top of the current values
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, c, yaml.Strict())
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		return fmt.Errorf("unsupported config file format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func setValue(ptr interface{}, raw string) error {
+	switch p := ptr.(type) {
+	case *string:
+		*p = raw
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		*p = v
+	case *int:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*p = v
+	case *int32:
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*p = int32(v)
+	case *int64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*p = v
+	case *Duration:
+		if err := p.UnmarshalText([]byte(raw)); err != nil {
+			return errors.New("must be a duration such as 30s or 24h")
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %T", ptr)
+	}
+	return nil
+}
+
+// validate returns every problem found in the configuration
+func (c *Config) validate() []string {
+	var problems []string
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port: %q is not a valid port", c.Server.Port))
+	}
+
+	// Both URLs (postgres://...) and key=value DSNs (host=... dbname=...) are accepted
+	if c.Database.URL != "" {
+		if _, err := pgxpool.ParseConfig(c.Database.URL); err != nil {
+			problems = append(problems, fmt.Sprintf("database.url: is not a valid connection string: %v", err))
+		}
+	}
+	if c.Database.MaxConns < 1 {
+		problems = append(problems, "database.max_conns: must be at least 1")
+	}
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		problems = append(problems, "database.min_conns: must be between 0 and database.max_conns")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		problems = append(problems, "database.connect_timeout: must be positive")
+	}
+
+	if c.Auth.TokenTTL <= 0 {
+		problems = append(problems, "auth.token_ttl: must be positive")
+	}
+	// Same bounds enforced by bcrypt (MinCost..MaxCost)
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		problems = append(problems, "auth.bcrypt_cost: must be between 4 and 31")
+	}
+
+	if c.Pagination.MaxLimit < 1 {
+		problems = append(problems, "pagination.max_limit: must be at least 1")
+	}
+	if c.Pagination.DefaultLimit < 1 || c.Pagination.DefaultLimit > c.Pagination.MaxLimit {
+		problems = append(problems, "pagination.default_limit: must be between 1 and pagination.max_limit")
+	}
+	if c.Pagination.SearchDefaultLimit < 1 || c.Pagination.SearchDefaultLimit > c.Pagination.MaxLimit {
+		problems = append(problems, "pagination.search_default_limit: must be between 1 and pagination.max_limit")
+	}
+
+	if c.WebSocket.ReadBufferSize < 1 {
+		problems = append(problems, "websocket.read_buffer_size: must be positive")
+	}
+	if c.WebSocket.WriteBufferSize < 1 {
+		problems = append(problems, "websocket.write_buffer_size: must be positive")
+	}
+	if c.WebSocket.SendBufferSize < 1 {
+		problems = append(problems, "websocket.send_buffer_size: must be positive")
+	}
+	if c.WebSocket.BroadcastBufferSize < 1 {
+		problems = append(problems, "websocket.broadcast_buffer_size: must be positive")
+	}
+	if c.WebSocket.MaxMessageSize < 1 {
+		problems = append(problems, "websocket.max_message_size: must be positive")
+	}
+
+	if c.Pricing.JSONFormat != money.FormatNumber && c.Pricing.JSONFormat != money.FormatString {
+		problems = append(problems, fmt.Sprintf("pricing.json_format: must be %q or %q", money.FormatNumber, money.FormatString))
+	}
+
+	if c.Trash.Retention < 0 {
+		problems = append(problems, "trash.retention: must not be negative")
+	}
+	if c.Trash.PurgeInterval < 0 {
+		problems = append(problems, "trash.purge_interval: must not be negative")
+	}
+
+	if c.HTTPCache.ProductsMaxAge < 0 {
+		problems = append(problems, "http_cache.products_max_age: must not be negative")
+	}
+	if c.HTTPCache.CategoriesMaxAge < 0 {
+		problems = append(problems, "http_cache.categories_max_age: must not be negative")
+	}
+
+	if c.Cache.Backend != "memory" && c.Cache.Backend != "none" {
+		problems = append(problems, fmt.Sprintf("cache.backend: must be %q or %q", "memory", "none"))
+	}
+	if c.Cache.MaxEntries < 1 {
+		problems = append(problems, "cache.max_entries: must be at least 1")
+	}
+	if c.Cache.ProductTTL < 0 {
+		problems = append(problems, "cache.product_ttl: must not be negative")
+	}
+	if c.Cache.CategoryTTL < 0 {
+		problems = append(problems, "cache.category_ttl: must not be negative")
+	}
+
+	return problems
+}
+
+// validateSecrets reports the required secrets that are missing
+func (c *Config) validateSecrets() []string {
+	var problems []string
+
+	if c.Database.URL == "" && !c.Server.DemoMode {
+		problems = append(problems, "database.url: DATABASE_URL is required (or enable server.demo_mode)")
+	}
+	if c.Auth.JWTSecret == "" {
+		problems = append(problems, "auth.jwt_secret: JWT_SECRET is required")
+	}
+
+	return problems
+}
+
+// ValidationError lists every problem found while loading the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+const redacted = "********"
+
+// Redacted returns a copy of the configuration that is safe to print
+func (c *Config) Redacted() *Config {
+	out := *c
+
+	if out.Auth.JWTSecret != "" {
+		out.Auth.JWTSecret = redacted
+	}
+
+	out.Database.URL = redactDSN(out.Database.URL)
+
+	return &out
+}
+
+// dsnPassword matches the password of a key=value DSN, quoted or not
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactDSN hides the password of a connection string, in the user info or
+// query of a URL or in a key=value DSN, keeping the rest usable for debugging
+func redactDSN(dsn string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		// Nothing can be told apart from the password
+		return redacted
+	}
+	if _, has := u.User.Password(); has {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+	// URLs escape "*"; unescape the mask so it reads like the other secrets
+	return strings.ReplaceAll(u.String(), url.QueryEscape(redacted), redacted)
+}
+
+// Print writes the redacted configuration as YAML
+func (c *Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,139 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+)
+
+// clearEnv unsets the variables the tests rely on so the host environment
+// cannot leak into them
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"CONFIG_FILE", "PORT", "DEMO_MODE", "DATABASE_URL", "JWT_SECRET", "JWT_TTL",
+		"BCRYPT_COST", "PAGINATION_DEFAULT_LIMIT", "WS_SEND_BUFFER_SIZE",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadLayering(t *testing.T) {
+	clearEnv(t)
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `
+server:
+  port: "7001"
+pagination:
+  default_limit: 20
+auth:
+  token_ttl: 1h
+websocket:
+  send_buffer_size: 5
+`
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_URL", "postgres://app@localhost:5432/bsmart")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("PORT", "7002")
+	t.Setenv("PAGINATION_DEFAULT_LIMIT", "30")
+
+	cfg, err := config.Load([]string{"--config", file, "--port", "7003"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// defaults < file < env < flags
+	if cfg.Server.Port != "7003" {
+		t.Fatalf("flag must win over env and file, got port %s", cfg.Server.Port)
+	}
+	if cfg.Pagination.DefaultLimit != 30 {
+		t.Fatalf("env must win over the file, got default_limit %d", cfg.Pagination.DefaultLimit)
+	}
+	if time.Duration(cfg.Auth.TokenTTL) != time.Hour || cfg.WebSocket.SendBufferSize != 5 {
+		t.Fatalf("file must win over defaults, got token_ttl %s and send_buffer_size %d",
+			time.Duration(cfg.Auth.TokenTTL), cfg.WebSocket.SendBufferSize)
+	}
+	if cfg.Auth.BcryptCost != config.Default().Auth.BcryptCost {
+		t.Fatalf("unset values keep their default, got bcrypt_cost %d", cfg.Auth.BcryptCost)
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	clearEnv(t)
+
+	var invalid *config.ValidationError
+	if _, err := config.Load(nil); !errors.As(err, &invalid) {
+		t.Fatalf("expected missing secrets to fail validation, got %v", err)
+	}
+
+	// Printing the configuration is how a missing secret gets diagnosed
+	cfg, err := config.Load([]string{"--print-config"})
+	if err != nil {
+		t.Fatalf("--print-config must not require secrets: %v", err)
+	}
+	if !cfg.PrintConfig {
+		t.Fatal("expected PrintConfig to be set")
+	}
+
+	// File, env and validation problems are reported together
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("server:\n  colour: red\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PAGINATION_DEFAULT_LIMIT", "many")
+	_, err = config.Load([]string{"--config", file})
+	if !errors.As(err, &invalid) || len(invalid.Problems) < 3 {
+		t.Fatalf("expected the file, env and secret problems in one error, got %v", err)
+	}
+	t.Setenv("PAGINATION_DEFAULT_LIMIT", "")
+
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("DATABASE_URL", "host=localhost port=notaport")
+	if _, err := config.Load(nil); !errors.As(err, &invalid) || !strings.Contains(err.Error(), "database.url") {
+		t.Fatalf("expected an invalid DSN to fail validation, got %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		keep string
+	}{
+		{"url user info", "postgres://app:s3cr3t@db:5432/bsmart", "postgres://app:"},
+		{"url query", "postgres://db:5432/bsmart?user=app&password=s3cr3t", "user=app"},
+		{"key value", "host=db user=app password=s3cr3t dbname=bsmart", "dbname=bsmart"},
+		{"key value quoted", "host=db password='s3cr3t with spaces' dbname=bsmart", "dbname=bsmart"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Database.URL = tt.dsn
+			cfg.Auth.JWTSecret = "jwt-s3cr3t"
+
+			out := cfg.Redacted()
+			if !strings.Contains(out.Database.URL, "********") {
+				t.Fatalf("expected the shared mask, got %s", out.Database.URL)
+			}
+			if strings.Contains(out.Database.URL, "s3cr3t") {
+				t.Fatalf("password leaked: %s", out.Database.URL)
+			}
+			if !strings.Contains(out.Database.URL, tt.keep) {
+				t.Fatalf("expected %q to survive redaction, got %s", tt.keep, out.Database.URL)
+			}
+			if out.Auth.JWTSecret == cfg.Auth.JWTSecret {
+				t.Fatal("JWT secret leaked")
+			}
+			if cfg.Database.URL != tt.dsn {
+				t.Fatal("Redacted must not modify the original")
+			}
+		})
+	}
+}
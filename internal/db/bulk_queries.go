@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *DB) LockProducts(ctx context.Context, filter *FilterParams, limit int) ([]int, error) {
+	filter.Validate()
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Locking in id order keeps concurrent bulk writes from deadlocking
+	query := fmt.Sprintf("SELECT p.id FROM products p %s ORDER BY p.id LIMIT %s FOR UPDATE", productWhere(filter, arg), arg(limit))
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock products: %w", err)
+	}
+
+	ids, err := ScanRows(rows, func(row pgx.Row) (int, error) {
+		var id int
+		err := row.Scan(&id)
+		return id, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan product ids: %w", err)
+	}
+	return ids, nil
+}
+
+// BulkUpdateProducts changes every product with one statement per table;
+// the history trigger records each product whose price or stock changed
+func (db *DB) BulkUpdateProducts(ctx context.Context, ids []int, userID *int, req *models.ProductBulkUpdateRequest) error {
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	changesStock := req.SetStock != nil || req.AdjustStock != nil
+	var stockBefore map[int]int
+	if changesStock {
+		if stockBefore, err = lockStocks(ctx, tx, ids); err != nil {
+			return err
+		}
+	}
+
+	args := []interface{}{ids}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Category changes also bump the version, since products embed their categories
+	updates := []string{"updated_at = " + arg(time.Now()), "version = version + 1"}
+	switch {
+	case req.SetPrice != nil:
+		updates = append(updates, "price = "+arg(*req.SetPrice))
+	case req.AdjustPricePercent != nil:
+		updates = append(updates, fmt.Sprintf("price = ROUND(price * (100 + %s::numeric) / 100, 2)", arg(*req.AdjustPricePercent)))
+	}
+	switch {
+	case req.SetStock != nil:
+		updates = append(updates, "stock = "+arg(*req.SetStock))
+	case req.AdjustStock != nil:
+		updates = append(updates, "stock = stock + "+arg(*req.AdjustStock))
+	}
+
+	query := fmt.Sprintf("UPDATE products SET %s WHERE id = ANY($1) AND deleted_at IS NULL", strings.Join(updates, ", "))
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return translateError(err, "product", "update")
+	}
+	if changesStock {
+		if err := recordStockAdjustments(ctx, tx, stockBefore, userID); err != nil {
+			return err
+		}
+	}
+
+	if len(req.AddCategoryIDs) > 0 {
+		var live int
+		err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM categories WHERE id = ANY($1) AND deleted_at IS NULL`, req.AddCategoryIDs).Scan(&live)
+		if err != nil {
+			return fmt.Errorf("failed to check categories: %w", err)
+		}
+		if live < len(req.AddCategoryIDs) {
+			return fmt.Errorf("%w: product category references a category that does not exist", ErrInvalidReference)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO product_category (product_id, category_id)
+			SELECT p.id, c.id FROM unnest($1::int[]) AS p(id) CROSS JOIN unnest($2::int[]) AS c(id)
+			ON CONFLICT DO NOTHING
+		`, ids, req.AddCategoryIDs)
+		if err != nil {
+			return translateError(err, "product category", "add")
+		}
+	}
+
+	if len(req.RemoveCategoryIDs) > 0 {
+		_, err := tx.Exec(ctx, `DELETE FROM product_category WHERE product_id = ANY($1) AND category_id = ANY($2)`, ids, req.RemoveCategoryIDs)
+		if err != nil {
+			return fmt.Errorf("failed to remove product categories: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) BulkDeleteProducts(ctx context.Context, ids []int) error {
+	query := `
+		UPDATE products
+		SET deleted_at = NOW(), version = version + 1
+		WHERE id = ANY($1) AND deleted_at IS NULL
+	`
+	if _, err := db.conn(ctx).Exec(ctx, query, ids); err != nil {
+		return translateError(err, "product", "delete")
+	}
+	return nil
+}
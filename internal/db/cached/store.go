@@ -0,0 +1,242 @@
+// Package cached decorates a db.Store with a read cache for products and
+// categories. The decorator also acts as the service event publisher so
+// every product:* and category:* event invalidates the affected entries
+// before it is forwarded to the next publisher.
+package cached
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/cache"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// Cache key prefixes, also used as resource names in the stats
+const (
+	resourceProduct      = "product"
+	resourceCategory     = "category"
+	resourceCategoryList = "category_list"
+)
+
+// Publisher matches service.Publisher
+type Publisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// Store caches GetProductByID, GetCategoryByID and ListCategories. Every
+// other method goes straight to the wrapped store.
+type Store struct {
+	db.Store
+	cache  cache.Cache
+	events Publisher
+	cfg    config.CacheConfig
+
+	// generation is bumped on every invalidation; a read only fills the
+	// cache if no invalidation happened while it was hitting the store
+	generation atomic.Uint64
+	counters   map[string]*counter
+}
+
+type counter struct {
+	hits, misses atomic.Int64
+}
+
+type inTxKey struct{}
+
+var _ db.Store = (*Store)(nil)
+
+func New(store db.Store, c cache.Cache, events Publisher, cfg config.CacheConfig) *Store {
+	return &Store{
+		Store:  store,
+		cache:  c,
+		events: events,
+		cfg:    cfg,
+		counters: map[string]*counter{
+			resourceProduct:      {},
+			resourceCategory:     {},
+			resourceCategoryList: {},
+		},
+	}
+}
+
+// WithinTx marks the context so reads inside the transaction bypass the
+// cache: they may see uncommitted rows that must never be cached.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.Store.WithinTx(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, inTxKey{}, true))
+	})
+}
+
+func (s *Store) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	product, err := read(s, ctx, resourceProduct, key(resourceProduct, id), time.Duration(s.cfg.ProductTTL), func() (*models.Product, error) {
+		return s.Store.GetProductByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyProduct(product), nil
+}
+
+func (s *Store) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	category, err := read(s, ctx, resourceCategory, key(resourceCategory, id), time.Duration(s.cfg.CategoryTTL), func() (*models.Category, error) {
+		return s.Store.GetCategoryByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	copied := *category
+	return &copied, nil
+}
+
+// ListCategories only caches complete listings of every field; pages and
+// sparse fieldsets are read from the store
+func (s *Store) ListCategories(ctx context.Context, search string, pagination *db.PaginationParams, fields db.Fieldset) ([]models.Category, int, error) {
+	if pagination != nil || fields != nil {
+		return s.Store.ListCategories(ctx, search, pagination, fields)
+	}
+
+	categories, err := read(s, ctx, resourceCategoryList, resourceCategoryList+":"+search, time.Duration(s.cfg.CategoryTTL), func() ([]models.Category, error) {
+		categories, _, err := s.Store.ListCategories(ctx, search, nil, nil)
+		return categories, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return slices.Clone(categories), len(categories), nil
+}
+
+// read returns the cached value for key or loads and caches it. Callers
+// must copy the result before handing it out, since services mutate what
+// they get (e.g. price conversion).
+func read[T any](s *Store, ctx context.Context, resource, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if ctx.Value(inTxKey{}) != nil {
+		return load()
+	}
+
+	if value, ok := s.cache.Get(key); ok {
+		s.counters[resource].hits.Add(1)
+		return value.(T), nil
+	}
+	s.counters[resource].misses.Add(1)
+
+	generation := s.generation.Load()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if s.generation.Load() == generation {
+		s.cache.Set(key, value, ttl)
+	}
+	return value, nil
+}
+
+// Publish invalidates the entries an event makes stale, then forwards it
+func (s *Store) Publish(eventType string, data interface{}) {
+	s.invalidate(eventType, data)
+	if s.events != nil {
+		s.events.Publish(eventType, data)
+	}
+}
+
+func (s *Store) invalidate(eventType string, data interface{}) {
+	resource, _, _ := strings.Cut(eventType, ":")
+
+	switch resource {
+	case resourceProduct:
+		if ids, ok := eventProductIDs(data); ok {
+			for _, id := range ids {
+				s.cache.Delete(key(resourceProduct, id))
+			}
+		} else if id, ok := eventID(data); ok {
+			s.cache.Delete(key(resourceProduct, id))
+		} else {
+			s.cache.DeletePrefix(resourceProduct + ":")
+		}
+	case resourceCategory:
+		if id, ok := eventID(data); ok {
+			s.cache.Delete(key(resourceCategory, id))
+		} else {
+			s.cache.DeletePrefix(resourceCategory + ":")
+		}
+		s.cache.DeletePrefix(resourceCategoryList + ":")
+		// Products embed their categories
+		s.cache.DeletePrefix(resourceProduct + ":")
+	default:
+		return
+	}
+
+	s.generation.Add(1)
+}
+
+// eventID extracts the resource ID from an event payload
+func eventID(data interface{}) (int, bool) {
+	switch d := data.(type) {
+	case *models.Product:
+		if d != nil {
+			return d.ID, true
+		}
+	case *models.Category:
+		if d != nil {
+			return d.ID, true
+		}
+	case *models.StockMovement:
+		if d != nil {
+			return d.ProductID, true
+		}
+	case map[string]int:
+		id, ok := d["id"]
+		return id, ok
+	}
+	return 0, false
+}
+
+// eventProductIDs extracts the products of an event about many of them
+func eventProductIDs(data interface{}) ([]int, bool) {
+	switch d := data.(type) {
+	case models.ProductBulkEvent:
+		return d.ProductIDs, true
+	case models.ProductImportEvent:
+		return d.ProductIDs, true
+	}
+	return nil, false
+}
+
+// Stats reports the cache counters
+func (s *Store) Stats() models.CacheStats {
+	stats := models.CacheStats{
+		Enabled:    true,
+		Backend:    s.cfg.Backend,
+		Entries:    s.cache.Len(),
+		MaxEntries: s.cfg.MaxEntries,
+		Resources:  make(map[string]models.CacheResourceStats, len(s.counters)),
+	}
+
+	for resource, c := range s.counters {
+		resourceStats := models.CacheResourceStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+		stats.Resources[resource] = resourceStats
+		stats.Hits += resourceStats.Hits
+		stats.Misses += resourceStats.Misses
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+func key(resource string, id int) string {
+	return resource + ":" + strconv.Itoa(id)
+}
+
+func copyProduct(p *models.Product) *models.Product {
+	copied := *p
+	copied.Categories = slices.Clone(p.Categories)
+	return &copied
+}
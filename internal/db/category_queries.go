@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
 	"github.com/jackc/pgx/v5"
@@ -13,20 +15,21 @@ func (db *DB) CreateCategory(ctx context.Context, req *models.CategoryCreateRequ
 	query := `
 		INSERT INTO categories (name, description, created_at, updated_at)
 		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, name, description, created_at, updated_at
+		RETURNING id, name, description, created_at, updated_at, version
 	`
 
 	var category models.Category
-	err := db.QueryRow(ctx, query, req.Name, req.Description).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, req.Name, req.Description).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Description,
 		&category.CreatedAt,
 		&category.UpdatedAt,
+		&category.Version,
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create category: %w", err)
+		return nil, translateError(err, "category", "create")
 	}
 
 	return &category, nil
@@ -34,70 +37,115 @@ func (db *DB) CreateCategory(ctx context.Context, req *models.CategoryCreateRequ
 
 func (db *DB) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
 	query := `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, created_at, updated_at, version
 		FROM categories
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var category models.Category
-	err := db.QueryRow(ctx, query, id).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Description,
 		&category.CreatedAt,
 		&category.UpdatedAt,
+		&category.Version,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("category not found")
-		}
-		return nil, fmt.Errorf("failed to get category: %w", err)
+		return nil, translateError(err, "category", "get")
 	}
 
 	return &category, nil
 }
 
-// ListCategories retrieves all categories
-func (db *DB) ListCategories(ctx context.Context, search string) ([]models.Category, error) {
-	var query string
-	var args []interface{}
+// CategoryListVersion summarizes the categories like ProductListVersion
+func (db *DB) CategoryListVersion(ctx context.Context) (string, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(version), 0), COALESCE(MAX(id), 0) FROM categories`
+
+	var categories TableVersion
+	err := db.conn(ctx).QueryRow(ctx, query).Scan(&categories.Rows, &categories.Versions, &categories.LastID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read category list version: %w", err)
+	}
+
+	return categories.String(), nil
+}
+
+// ListCategories retrieves the categories whose name contains search,
+// ordered by name. A nil pagination returns all of them; nil fields read
+// every column.
+func (db *DB) ListCategories(ctx context.Context, search string, pagination *PaginationParams, fields Fieldset) ([]models.Category, int, error) {
+	whereClause, args := categoryWhere(search)
+
+	total := 0
+	orderByClause := keysetOrderBy(CategorySortKeys, false)
+	pageClause := ""
+	if pagination != nil {
+		pagination.Validate()
+
+		if !pagination.Keyset || !pagination.SkipTotal {
+			var err error
+			total, err = db.CountRows(ctx, "SELECT COUNT(*) FROM categories "+whereClause, args...)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to count categories: %w", err)
+			}
+		}
+
+		condition, orderBy, limit, pageArgs, err := pageClauses(CategorySortKeys, pagination, len(args))
+		if err != nil {
+			return nil, 0, err
+		}
+		if condition != "" {
+			whereClause += " AND " + condition
+		}
+		orderByClause, pageClause = orderBy, limit
+		args = append(args, pageArgs...)
+	}
+
+	columns, dests := selectColumns(categoryColumns, fields.withKeys(CategorySortKeys))
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories
+		%s
+		%s
+		%s
+	`, columns, whereClause, orderByClause, pageClause)
 
-	if search != "" {
-		query = `
-			SELECT id, name, description, created_at, updated_at
-			FROM categories
-			WHERE name ILIKE $1
-			ORDER BY name
-		`
-		args = append(args, "%"+search+"%")
-	} else {
-		query = `
-			SELECT id, name, description, created_at, updated_at
-			FROM categories
-			ORDER BY name
-		`
-	}
-
-	rows, err := db.Query(ctx, query, args...)
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query categories: %w", err)
+		return nil, 0, fmt.Errorf("failed to query categories: %w", err)
 	}
 
 	categories, err := ScanRows(rows, func(row pgx.Row) (models.Category, error) {
 		var c models.Category
-		err := row.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+		err := row.Scan(dests(&c)...)
 		return c, err
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan categories: %w", err)
+		return nil, 0, fmt.Errorf("failed to scan categories: %w", err)
 	}
 
-	return categories, nil
+	if pagination == nil {
+		return categories, len(categories), nil
+	}
+	if pagination.Keyset {
+		categories = FinishKeysetPage(pagination, CategorySortKeys, categories, CategorySortValue)
+	}
+	return categories, total, nil
+}
+
+// categoryWhere builds the WHERE clause selecting the live categories whose
+// name contains search
+func categoryWhere(search string) (string, []interface{}) {
+	if search == "" {
+		return "WHERE deleted_at IS NULL", nil
+	}
+	return "WHERE deleted_at IS NULL AND name ILIKE $1", []interface{}{"%" + search + "%"}
 }
 
-func (db *DB) UpdateCategory(ctx context.Context, id int, req *models.CategoryUpdateRequest) (*models.Category, error) {
+func (db *DB) UpdateCategory(ctx context.Context, id int, req *models.CategoryUpdateRequest, ifVersions []int) (*models.Category, error) {
 	// Build dynamic UPDATE query
 	updates := []string{}
 	args := []interface{}{}
@@ -115,55 +163,64 @@ func (db *DB) UpdateCategory(ctx context.Context, id int, req *models.CategoryUp
 		args = append(args, *req.Description)
 	}
 
+	if req.ClearDescription {
+		updates = append(updates, "description = NULL")
+	}
+
 	if len(updates) == 0 {
-		return nil, fmt.Errorf("no fields to update")
+		return nil, fmt.Errorf("%w: no fields to update", ErrValidation)
 	}
 
-	// Always update updated_at
+	// Always update updated_at and bump the version
 	argCount++
-	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount))
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount), "version = version + 1")
 	args = append(args, "NOW()")
 
-	// Add category ID as last argument
-	argCount++
-	args = append(args, id)
+	// Add category ID and accepted versions as last arguments
+	argCount += 2
+	args = append(args, id, ifVersions)
 
 	query := fmt.Sprintf(`
 		UPDATE categories
 		SET %s
-		WHERE id = $%d
-		RETURNING id, name, description, created_at, updated_at
-	`, strings.Join(updates, ", "), argCount)
+		WHERE id = $%d AND deleted_at IS NULL AND ($%d::int[] IS NULL OR version = ANY($%d))
+		RETURNING id, name, description, created_at, updated_at, version
+	`, strings.Join(updates, ", "), argCount-1, argCount, argCount)
 
 	var category models.Category
-	err := db.QueryRow(ctx, query, args...).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, args...).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Description,
 		&category.CreatedAt,
 		&category.UpdatedAt,
+		&category.Version,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, db.missingOrStale(ctx, "categories", "category", id, ifVersions)
+	}
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("category not found")
-		}
-		return nil, fmt.Errorf("failed to update category: %w", err)
+		return nil, translateError(err, "category", "update")
 	}
 
 	return &category, nil
 }
 
-func (db *DB) DeleteCategory(ctx context.Context, id int) error {
-	query := `DELETE FROM categories WHERE id = $1`
+func (db *DB) DeleteCategory(ctx context.Context, id int, ifVersions []int) error {
+	query := `
+		UPDATE categories
+		SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::int[] IS NULL OR version = ANY($2))
+	`
 
-	result, err := db.Exec(ctx, query, id)
+	result, err := db.conn(ctx).Exec(ctx, query, id, ifVersions)
 	if err != nil {
-		return fmt.Errorf("failed to delete category: %w", err)
+		return translateError(err, "category", "delete")
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("category not found")
+		return db.missingOrStale(ctx, "categories", "category", id, ifVersions)
 	}
 
 	return nil
@@ -175,23 +232,16 @@ func (db *DB) SearchCategories(ctx context.Context, searchTerm string, paginatio
 	filter.Validate()
 
 	// Build WHERE clause
-	var whereClause string
+	whereClause := "WHERE deleted_at IS NULL"
 	var args []interface{}
 
 	if searchTerm != "" {
-		whereClause = "WHERE name ILIKE $1"
+		whereClause += " AND name ILIKE $1"
 		args = append(args, "%"+searchTerm+"%")
 	}
 
-	// Build ORDER BY clause
-	allowedFields := map[string]string{
-		"name":       "name",
-		"created_at": "created_at",
-	}
-	orderByClause := filter.BuildOrderByClause(allowedFields)
-	if orderByClause == "" {
-		orderByClause = "ORDER BY name ASC" // Default sorting
-	}
+	// Order by the requested fields with the id as tiebreaker
+	orderByClause := keysetOrderBy(CategorySearchSortKeys(filter), false)
 
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM categories %s", whereClause)
@@ -207,24 +257,25 @@ func (db *DB) SearchCategories(ctx context.Context, searchTerm string, paginatio
 	argCount++
 	offsetArg := argCount
 
+	columns, dests := selectColumns(categoryColumns, filter.Fields)
 	query := fmt.Sprintf(`
-		SELECT id, name, description, created_at, updated_at
+		SELECT %s
 		FROM categories
 		%s
 		%s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, orderByClause, limitArg, offsetArg)
+	`, columns, whereClause, orderByClause, limitArg, offsetArg)
 
 	args = append(args, pagination.Limit, pagination.Offset())
 
-	rows, err := db.Query(ctx, query, args...)
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search categories: %w", err)
 	}
 
 	categories, err := ScanRows(rows, func(row pgx.Row) (models.Category, error) {
 		var c models.Category
-		err := row.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
+		err := row.Scan(dests(&c)...)
 		return c, err
 	})
 
@@ -234,3 +285,69 @@ func (db *DB) SearchCategories(ctx context.Context, searchTerm string, paginatio
 
 	return categories, total, nil
 }
+
+// ListDeletedCategories returns the categories in the trash, most recently deleted first
+func (db *DB) ListDeletedCategories(ctx context.Context) ([]models.Category, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at, version, deleted_at
+		FROM categories
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+	`
+
+	rows, err := db.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted categories: %w", err)
+	}
+
+	categories, err := ScanRows(rows, func(row pgx.Row) (models.Category, error) {
+		var c models.Category
+		err := row.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt, &c.Version, &c.DeletedAt)
+		return c, err
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan deleted categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// RestoreCategory takes a category out of the trash. It fails with
+// ErrConflict when another live category took its name meanwhile.
+func (db *DB) RestoreCategory(ctx context.Context, id int) (*models.Category, error) {
+	query := `
+		UPDATE categories
+		SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, name, description, created_at, updated_at, version
+	`
+
+	var category models.Category
+	err := db.conn(ctx).QueryRow(ctx, query, id).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Description,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+		&category.Version,
+	)
+
+	if err != nil {
+		return nil, translateError(err, "deleted category", "restore")
+	}
+
+	return &category, nil
+}
+
+// PurgeCategories permanently deletes trashed categories; product links cascade
+func (db *DB) PurgeCategories(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `DELETE FROM categories WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	result, err := db.conn(ctx).Exec(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, translateError(err, "category", "purge")
+	}
+
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// Cursor is a position in a keyset-paginated listing. It is sent to clients
+// as an opaque string (see Encode) and is only valid for the sort it was
+// issued for.
+type Cursor struct {
+	Sort     string   `json:"s"`           // Canonical sort, e.g. "price:desc"
+	Values   []string `json:"v"`           // Sort key values of the boundary row, id last
+	Backward bool     `json:"b,omitempty"` // Read the rows before the boundary (prev_cursor)
+}
+
+// Encode returns the opaque form of the cursor
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort == "" || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Types of sort key values, named after the SQL type they are cast to
+const (
+	SortText      = "text"
+	SortNumeric   = "numeric"
+	SortInt       = "int"
+	SortTimestamp = "timestamptz"
+)
+
+// ProductSortValue returns the cursor value of a product sort key
+func ProductSortValue(p models.Product, field string) string {
+	switch field {
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price.String()
+	case "stock":
+		return strconv.Itoa(p.Stock)
+	case "created_at":
+		return formatCursorTime(p.CreatedAt)
+	default:
+		return strconv.Itoa(p.ID)
+	}
+}
+
+// CategorySortValue returns the cursor value of a category sort key
+func CategorySortValue(c models.Category, field string) string {
+	if field == "name" {
+		return c.Name
+	}
+	return strconv.Itoa(c.ID)
+}
+
+// HistorySortValue returns the cursor value of a history sort key
+func HistorySortValue(h models.ProductHistory, field string) string {
+	if field == "changed_at" {
+		return formatCursorTime(h.ChangedAt)
+	}
+	return strconv.Itoa(h.ID)
+}
+
+func StockMovementSortValue(m models.StockMovement, field string) string {
+	if field == "created_at" {
+		return formatCursorTime(m.CreatedAt)
+	}
+	return strconv.Itoa(m.ID)
+}
+
+func formatCursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// sortSpec is the canonical form of keys stored in cursors
+func sortSpec(keys []SortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Field == "id" {
+			continue
+		}
+		order := "asc"
+		if key.Desc {
+			order = "desc"
+		}
+		parts = append(parts, key.Field+":"+order)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Check verifies that the cursor was issued for keys and holds valid values
+func (c *Cursor) Check(keys []SortKey) error {
+	if c.Sort != sortSpec(keys) || len(c.Values) != len(keys) {
+		return fmt.Errorf("%w: it was issued for a different sort, start again without it", ErrInvalidCursor)
+	}
+	for i, key := range keys {
+		if _, err := parseSortValue(key.Type, c.Values[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// parseSortValue converts a cursor value to a comparable Go value
+func parseSortValue(typ, s string) (interface{}, error) {
+	switch typ {
+	case SortNumeric:
+		return money.Parse(s)
+	case SortInt:
+		return strconv.Atoi(s)
+	case SortTimestamp:
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		return s, nil
+	}
+}
+
+// CompareSortValues compares two valid cursor values of the given type.
+// Text is compared bytewise, as the in-memory store sorts it.
+func CompareSortValues(typ, a, b string) int {
+	x, _ := parseSortValue(typ, a)
+	y, _ := parseSortValue(typ, b)
+
+	switch x := x.(type) {
+	case money.Amount:
+		return x.Cmp(y.(money.Amount))
+	case int:
+		return cmp.Compare(x, y.(int))
+	case time.Time:
+		return x.Compare(y.(time.Time))
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// keysetCondition builds the WHERE condition selecting the rows past the
+// cursor, expanded as (k1 > v1) OR (k1 = v1 AND k2 > v2) ... so that keys
+// may have different directions. Placeholders start at $argStart.
+func keysetCondition(keys []SortKey, cursor *Cursor, argStart int) (string, []interface{}, error) {
+	if err := cursor.Check(keys); err != nil {
+		return "", nil, err
+	}
+
+	args := make([]interface{}, len(keys))
+	placeholders := make([]string, len(keys))
+	for i, key := range keys {
+		args[i] = cursor.Values[i]
+		// Values travel as text and are cast in SQL to the column type
+		placeholders[i] = fmt.Sprintf("$%d::text::%s", argStart+i, key.Type)
+	}
+
+	alternatives := make([]string, len(keys))
+	for i, key := range keys {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, fmt.Sprintf("%s = %s", keys[j].Column, placeholders[j]))
+		}
+		op := ">"
+		if key.Desc != cursor.Backward {
+			op = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", key.Column, op, placeholders[i]))
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+// FinishKeysetPage trims the extra row fetched to detect more results,
+// restores the order of backward pages and sets the page cursors.
+// rows must hold up to Limit+1 items in read order.
+func FinishKeysetPage[T any](p *PaginationParams, keys []SortKey, rows []T, value func(T, string) string) []T {
+	hasMore := len(rows) > p.Limit
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+	backward := p.Cursor != nil && p.Cursor.Backward
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	cursorAt := func(row T, backward bool) string {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = value(row, key.Field)
+		}
+		return (&Cursor{Sort: sortSpec(keys), Values: values, Backward: backward}).Encode()
+	}
+
+	p.NextCursor, p.PrevCursor = "", ""
+	if len(rows) == 0 {
+		// Past either end: offer a way back from the requested position
+		if p.Cursor != nil {
+			back := *p.Cursor
+			back.Backward = !back.Backward
+			if backward {
+				p.NextCursor = back.Encode()
+			} else {
+				p.PrevCursor = back.Encode()
+			}
+		}
+		return rows
+	}
+
+	if hasMore || backward {
+		p.NextCursor = cursorAt(rows[len(rows)-1], false)
+	}
+	if (hasMore && backward) || (!backward && p.Cursor != nil) {
+		p.PrevCursor = cursorAt(rows[0], true)
+	}
+	return rows
+}
+
+// pageClauses builds the SQL that selects the requested page of a listing
+// ordered by keys: an extra WHERE condition (empty unless reading after a
+// cursor), the ORDER BY clause and the LIMIT/OFFSET clause. Placeholders
+// continue after argCount and args holds their values.
+func pageClauses(keys []SortKey, p *PaginationParams, argCount int) (condition, orderBy, limit string, args []interface{}, err error) {
+	if !p.Keyset {
+		limit = fmt.Sprintf("LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+		return "", keysetOrderBy(keys, false), limit, []interface{}{p.Limit, p.Offset()}, nil
+	}
+
+	backward := false
+	if p.Cursor != nil {
+		condition, args, err = keysetCondition(keys, p.Cursor, argCount+1)
+		if err != nil {
+			return "", "", "", nil, err
+		}
+		backward = p.Cursor.Backward
+	}
+
+	// One extra row tells whether there is another page
+	limit = fmt.Sprintf("LIMIT $%d", argCount+len(args)+1)
+	args = append(args, p.Limit+1)
+	return condition, keysetOrderBy(keys, backward), limit, args, nil
+}
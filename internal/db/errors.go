@@ -0,0 +1,163 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Domain errors returned by the data layer. Callers should match them with
+// errors.Is; the wrapped message describes the specific resource or field.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	// ErrEmailExists is the ErrConflict of registering an email that is taken
+	ErrEmailExists      = fmt.Errorf("%w: email already registered", ErrConflict)
+	ErrInvalidReference = errors.New("invalid reference")
+	ErrValidation       = errors.New("validation failed")
+	// ErrPreconditionFailed means a conditional write found a different version
+	ErrPreconditionFailed = errors.New("was modified since it was read")
+	// ErrInvalidCursor means a pagination cursor is malformed or was issued for another sort
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// PostgreSQL error codes (https://www.postgresql.org/docs/current/errcodes-appendix.html)
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+	pgDataException       = "22" // class prefix: invalid numeric values, overflows, etc.
+)
+
+// NotFound builds an ErrNotFound for the given resource, e.g. "product not found"
+func NotFound(resource string) error {
+	return fmt.Errorf("%s %w", resource, ErrNotFound)
+}
+
+// InsufficientStock builds the ErrConflict of a stock movement that would
+// leave the stock below zero
+func InsufficientStock(stock, quantity int) error {
+	return fmt.Errorf("%w: insufficient stock: %d units available, the movement takes %d", ErrConflict, stock, -quantity)
+}
+
+// constraint is the domain error a schema constraint maps to. Messages are
+// fixed: PostgreSQL's Detail quotes the offending values (e.g. another
+// user's email), so it stays in the error chain for the logs only.
+type constraint struct {
+	kind    error
+	message string
+}
+
+// constraints holds the named constraints of the schema. Unnamed CHECKs use
+// PostgreSQL's <table>_<column>_check naming.
+var constraints = map[string]constraint{
+	"roles_name_key":                                               {ErrConflict, "role already exists"},
+	"users_email_key":                                              {ErrEmailExists, ""},
+	"users_role_id_fkey":                                           {ErrInvalidReference, "role does not exist"},
+	"idx_categories_name_active":                                   {ErrConflict, "a category with this name already exists"},
+	"idx_products_sku_active":                                      {ErrConflict, "a product with this SKU already exists"},
+	"products_price_check":                                         {ErrValidation, "price must not be negative"},
+	"products_stock_check":                                         {ErrValidation, "stock must not be negative"},
+	"products_currency_check":                                      {ErrValidation, "currency must be a 3-letter ISO 4217 code"},
+	"product_category_pkey":                                        {ErrConflict, "a category is listed more than once"},
+	"product_category_product_id_fkey":                             {ErrInvalidReference, "product does not exist"},
+	"product_category_category_id_fkey":                            {ErrInvalidReference, "category does not exist"},
+	"exchange_rates_base_currency_check":                           {ErrValidation, "currencies must be 3-letter ISO 4217 codes"},
+	"exchange_rates_quote_currency_check":                          {ErrValidation, "currencies must be 3-letter ISO 4217 codes"},
+	"exchange_rates_check":                                         {ErrValidation, "base and quote currencies must differ"},
+	"exchange_rates_rate_check":                                    {ErrValidation, "rate must be positive"},
+	"exchange_rates_base_currency_quote_currency_effective_at_key": {ErrConflict, "an exchange rate for this pair and effective date already exists"},
+	"stock_movements_product_id_fkey":                              {ErrInvalidReference, "product does not exist"},
+	"stock_movements_quantity_check":                               {ErrValidation, "quantity must not be zero"},
+	"stock_movements_reason_check":                                 {ErrValidation, "reason is not a known movement reason"},
+	"stock_movements_stock_after_check":                            {ErrConflict, "insufficient stock"},
+}
+
+// dbError is a domain error with a client-safe message. The PostgreSQL
+// error, if any, is kept in the chain so logs can still read its Detail.
+type dbError struct {
+	kind    error
+	message string
+	cause   error
+}
+
+func (e *dbError) Error() string {
+	if e.message == "" {
+		return e.kind.Error()
+	}
+	return e.kind.Error() + ": " + e.message
+}
+
+func (e *dbError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.cause}
+}
+
+// Violation builds the error of a named schema constraint, e.g.
+// "products_price_check"; the in-memory store uses it to mirror PostgreSQL
+func Violation(name string) error {
+	return violation(name, nil)
+}
+
+func violation(name string, cause error) error {
+	c, ok := constraints[name]
+	if !ok {
+		return nil
+	}
+	return &dbError{kind: c.kind, message: c.message, cause: cause}
+}
+
+// MissingCategory builds the ErrInvalidReference of a product pointing at a
+// category that does not exist or is in the trash
+func MissingCategory(id int) error {
+	return fmt.Errorf("%w: category %d does not exist", ErrInvalidReference, id)
+}
+
+// Detail returns the PostgreSQL detail of a translated error, for logging;
+// it is empty for errors that did not come from the database
+func Detail(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Detail
+	}
+	return ""
+}
+
+// translateError maps pgx/PostgreSQL errors to domain errors,
+// keeping the original error in the chain
+func translateError(err error, resource, action string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotFound(resource)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if known := violation(pgErr.ConstraintName, err); known != nil {
+			return known
+		}
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return &dbError{kind: ErrConflict, message: resource + " already exists", cause: err}
+		case pgErr.Code == pgForeignKeyViolation:
+			return &dbError{kind: ErrInvalidReference, message: resource + " references a record that does not exist", cause: err}
+		case pgErr.Code == pgNotNullViolation && pgErr.ColumnName != "":
+			return &dbError{kind: ErrValidation, message: resource + " " + pgErr.ColumnName + " is required", cause: err}
+		case pgErr.Code == pgCheckViolation, pgErr.Code == pgNotNullViolation:
+			return &dbError{kind: ErrValidation, message: resource + " is not valid", cause: err}
+		case len(pgErr.Code) == 5 && pgErr.Code[:2] == pgDataException:
+			// The message names the problem (e.g. "numeric field overflow"), not stored data
+			return &dbError{kind: ErrValidation, message: pgErr.Message, cause: err}
+		}
+	}
+
+	return fmt.Errorf("failed to %s %s: %w", action, resource, err)
+}
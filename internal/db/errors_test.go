@@ -0,0 +1,122 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslateErrorMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		is      error
+		status  int
+		code    string
+		message string
+	}{
+		{
+			name:    "no rows",
+			err:     pgx.ErrNoRows,
+			is:      db.ErrNotFound,
+			status:  http.StatusNotFound,
+			code:    "NOT_FOUND",
+			message: "Product not found",
+		},
+		{
+			name:    "duplicate email",
+			err:     &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key", Detail: "Key (email)=(someone@example.com) already exists."},
+			is:      db.ErrEmailExists,
+			status:  http.StatusConflict,
+			code:    "EMAIL_EXISTS",
+			message: "Email already registered",
+		},
+		{
+			name:    "duplicate sku",
+			err:     &pgconn.PgError{Code: "23505", ConstraintName: "idx_products_sku_active", Detail: "Key (sku)=(SKU-1) already exists."},
+			is:      db.ErrConflict,
+			status:  http.StatusConflict,
+			code:    "CONFLICT",
+			message: "A product with this SKU already exists",
+		},
+		{
+			name:    "unknown unique constraint",
+			err:     &pgconn.PgError{Code: "23505", ConstraintName: "products_other_key", Detail: "Key (other)=(x) already exists."},
+			is:      db.ErrConflict,
+			status:  http.StatusConflict,
+			code:    "CONFLICT",
+			message: "Product already exists",
+		},
+		{
+			name:    "missing category",
+			err:     &pgconn.PgError{Code: "23503", ConstraintName: "product_category_category_id_fkey", Detail: `Key (category_id)=(99) is not present in table "categories".`},
+			is:      db.ErrInvalidReference,
+			status:  http.StatusUnprocessableEntity,
+			code:    "INVALID_REFERENCE",
+			message: "Category does not exist",
+		},
+		{
+			name:    "negative price",
+			err:     &pgconn.PgError{Code: "23514", ConstraintName: "products_price_check", Detail: "Failing row contains (1, Widget, null, -1.00, ...)."},
+			is:      db.ErrValidation,
+			status:  http.StatusBadRequest,
+			code:    "VALIDATION_ERROR",
+			message: "Price must not be negative",
+		},
+		{
+			name:    "not null",
+			err:     &pgconn.PgError{Code: "23502", ColumnName: "price", Detail: "Failing row contains (1, Widget, null, null, ...)."},
+			is:      db.ErrValidation,
+			status:  http.StatusBadRequest,
+			code:    "VALIDATION_ERROR",
+			message: "Product price is required",
+		},
+		{
+			name:    "numeric overflow",
+			err:     &pgconn.PgError{Code: "22003", Message: "numeric field overflow", Detail: "A field with precision 12, scale 2 must round to an absolute value less than 10^10."},
+			is:      db.ErrValidation,
+			status:  http.StatusBadRequest,
+			code:    "VALIDATION_ERROR",
+			message: "Numeric field overflow",
+		},
+		{
+			name:    "connection failure",
+			err:     &pgconn.PgError{Code: "08006", Message: "connection failure"},
+			status:  http.StatusInternalServerError,
+			code:    "INTERNAL_ERROR",
+			message: "An unexpected error occurred",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.TranslateError(fmt.Errorf("query: %w", tt.err), "product", "create")
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Fatalf("expected %v, got %v", tt.is, err)
+			}
+
+			status, code, message := middleware.MapError(err)
+			if status != tt.status || code != tt.code || message != tt.message {
+				t.Fatalf("expected %d %s %q, got %d %s %q", tt.status, tt.code, tt.message, status, code, message)
+			}
+
+			// The detail names stored values: it is kept for the logs only
+			var pgErr *pgconn.PgError
+			if errors.As(tt.err, &pgErr) && pgErr.Detail != "" {
+				if strings.Contains(message, pgErr.Detail) || strings.Contains(err.Error(), pgErr.Detail) {
+					t.Fatalf("detail leaked into %q", message)
+				}
+				if db.Detail(err) != pgErr.Detail {
+					t.Fatalf("expected the detail to stay in the chain, got %q", db.Detail(err))
+				}
+			}
+		})
+	}
+}
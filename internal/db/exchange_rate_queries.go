@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (db *DB) CreateExchangeRate(ctx context.Context, req *models.ExchangeRateCreateRequest) (*models.ExchangeRate, error) {
+	query := `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate, effective_at, created_at)
+		VALUES ($1, $2, $3, COALESCE($4, NOW()), NOW())
+		RETURNING id, base_currency, quote_currency, rate, effective_at, created_at
+	`
+
+	var rate models.ExchangeRate
+	err := db.conn(ctx).QueryRow(ctx, query, req.BaseCurrency, req.QuoteCurrency, req.Rate, req.EffectiveAt).Scan(
+		&rate.ID,
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.EffectiveAt,
+		&rate.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, translateError(err, "exchange rate", "create")
+	}
+
+	return &rate, nil
+}
+
+// ListExchangeRates returns rates optionally filtered by currency pair, newest first
+func (db *DB) ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT id, base_currency, quote_currency, rate, effective_at, created_at
+		FROM exchange_rates
+		WHERE ($1 = '' OR base_currency = $1)
+		  AND ($2 = '' OR quote_currency = $2)
+		ORDER BY base_currency, quote_currency, effective_at DESC
+	`
+
+	rows, err := db.conn(ctx).Query(ctx, query, base, quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+
+	rates, err := ScanRows(rows, scanExchangeRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+func (db *DB) DeleteExchangeRate(ctx context.Context, id int) error {
+	result, err := db.conn(ctx).Exec(ctx, `DELETE FROM exchange_rates WHERE id = $1`, id)
+	if err != nil {
+		return translateError(err, "exchange rate", "delete")
+	}
+
+	if result.RowsAffected() == 0 {
+		return NotFound("exchange rate")
+	}
+
+	return nil
+}
+
+func (db *DB) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	query := `
+		SELECT id, base_currency, quote_currency, rate, effective_at, created_at
+		FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND effective_at <= $3
+		ORDER BY effective_at DESC
+		LIMIT 1
+	`
+
+	rate, err := scanExchangeRate(db.conn(ctx).QueryRow(ctx, query, base, quote, at))
+	if err != nil {
+		return nil, translateError(err, "exchange rate", "get")
+	}
+
+	return &rate, nil
+}
+
+func scanExchangeRate(row pgx.Row) (models.ExchangeRate, error) {
+	var r models.ExchangeRate
+	err := row.Scan(&r.ID, &r.BaseCurrency, &r.QuoteCurrency, &r.Rate, &r.EffectiveAt, &r.CreatedAt)
+	return r, err
+}
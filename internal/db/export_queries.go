@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ExportProducts streams the live products matching filter in the order of
+// the listing. Categories are aggregated in the same query, since a second
+// query cannot run on the connection while the rows are being read.
+func (db *DB) ExportProducts(ctx context.Context, filter *FilterParams, fn func(*models.Product) error) error {
+	filter.Validate()
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	whereClause := productWhere(filter, arg)
+
+	columns, dests := selectColumns(productColumns, nil)
+	withCategories := filter.Fields.Has(FieldCategories)
+	if withCategories {
+		columns += `, COALESCE((
+			SELECT json_agg(json_build_object('id', c.id, 'name', c.name) ORDER BY c.name)
+			FROM product_category pc
+			INNER JOIN categories c ON c.id = pc.category_id AND c.deleted_at IS NULL
+			WHERE pc.product_id = p.id
+		), '[]')`
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM products p
+		%s
+		%s
+	`, columns, whereClause, keysetOrderBy(ProductSortKeys(filter), false))
+
+	err := streamRows(ctx, db, query, args, func(row pgx.Row, product *models.Product) error {
+		targets := dests(product)
+		if withCategories {
+			targets = append(targets, &product.Categories)
+		}
+		return row.Scan(targets...)
+	}, fn)
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	return nil
+}
+
+// ExportCategories streams the live categories whose name contains search, ordered by name
+func (db *DB) ExportCategories(ctx context.Context, search string, fn func(*models.Category) error) error {
+	whereClause, args := categoryWhere(search)
+	columns, dests := selectColumns(categoryColumns, nil)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM categories
+		%s
+		%s
+	`, columns, whereClause, keysetOrderBy(CategorySortKeys, false))
+
+	err := streamRows(ctx, db, query, args, func(row pgx.Row, c *models.Category) error {
+		return row.Scan(dests(c)...)
+	}, fn)
+	if err != nil {
+		return fmt.Errorf("failed to export categories: %w", err)
+	}
+	return nil
+}
+
+// ExportProductHistory streams the changes of a product newest first
+func (db *DB) ExportProductHistory(ctx context.Context, productID int, start, end *time.Time, fn func(*models.ProductHistory) error) error {
+	whereClause, args := historyWhere(productID, start, end)
+	columns, dests := selectColumns(historyColumns, nil)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_history
+		%s
+		%s
+	`, columns, whereClause, keysetOrderBy(HistorySortKeys, false))
+
+	err := streamRows(ctx, db, query, args, func(row pgx.Row, h *models.ProductHistory) error {
+		return row.Scan(dests(h)...)
+	}, fn)
+	if err != nil {
+		return fmt.Errorf("failed to export product history: %w", err)
+	}
+	return nil
+}
+
+// streamRows runs query and hands each row to fn as pgx reads it from the
+// connection, so only one row is held in memory at a time. An error from fn
+// stops the query.
+func streamRows[T any](ctx context.Context, db *DB, query string, args []interface{}, scan func(pgx.Row, *T) error, fn func(*T) error) error {
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err := scan(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"context"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// TranslateError exposes translateError to the db_test package
+var TranslateError = translateError
+
+// LoadProductCategories exposes the batched category loader to benchmarks
+func (db *DB) LoadProductCategories(ctx context.Context, products []models.Product) error {
+	return db.loadProductCategories(ctx, products)
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// Fieldset lists the fields a listing reads. A nil Fieldset reads every
+// field; for products that includes embedding their categories.
+type Fieldset []string
+
+// FieldCategories is the product field holding the embedded categories
+const FieldCategories = "categories"
+
+// Has reports whether field is read
+func (f Fieldset) Has(field string) bool {
+	return f == nil || slices.Contains(f, field)
+}
+
+// With returns f plus fields. A nil Fieldset already has every field.
+func (f Fieldset) With(fields ...string) Fieldset {
+	if f == nil {
+		return nil
+	}
+	out := slices.Clone(f)
+	for _, field := range fields {
+		if !slices.Contains(out, field) {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// withKeys adds the fields of sort keys, which cursors are built from
+func (f Fieldset) withKeys(keys []SortKey) Fieldset {
+	for _, key := range keys {
+		f = f.With(key.Field)
+	}
+	return f
+}
+
+// column maps a field of T to its SQL expression and scan destination
+type column[T any] struct {
+	field string
+	sql   string
+	dest  func(*T) interface{}
+}
+
+var productColumns = []column[models.Product]{
+	{"id", "p.id", func(p *models.Product) interface{} { return &p.ID }},
+	{"sku", "p.sku", func(p *models.Product) interface{} { return &p.SKU }},
+	{"name", "p.name", func(p *models.Product) interface{} { return &p.Name }},
+	{"description", "p.description", func(p *models.Product) interface{} { return &p.Description }},
+	{"price", "p.price", func(p *models.Product) interface{} { return &p.Price }},
+	{"currency", "p.currency", func(p *models.Product) interface{} { return &p.Currency }},
+	{"stock", "p.stock", func(p *models.Product) interface{} { return &p.Stock }},
+	{"created_at", "p.created_at", func(p *models.Product) interface{} { return &p.CreatedAt }},
+	{"updated_at", "p.updated_at", func(p *models.Product) interface{} { return &p.UpdatedAt }},
+	{"version", "p.version", func(p *models.Product) interface{} { return &p.Version }},
+}
+
+var categoryColumns = []column[models.Category]{
+	{"id", "id", func(c *models.Category) interface{} { return &c.ID }},
+	{"name", "name", func(c *models.Category) interface{} { return &c.Name }},
+	{"description", "description", func(c *models.Category) interface{} { return &c.Description }},
+	{"created_at", "created_at", func(c *models.Category) interface{} { return &c.CreatedAt }},
+	{"updated_at", "updated_at", func(c *models.Category) interface{} { return &c.UpdatedAt }},
+	{"version", "version", func(c *models.Category) interface{} { return &c.Version }},
+}
+
+var historyColumns = []column[models.ProductHistory]{
+	{"id", "id", func(h *models.ProductHistory) interface{} { return &h.ID }},
+	{"product_id", "product_id", func(h *models.ProductHistory) interface{} { return &h.ProductID }},
+	{"price", "price", func(h *models.ProductHistory) interface{} { return &h.Price }},
+	{"currency", "currency", func(h *models.ProductHistory) interface{} { return &h.Currency }},
+	{"stock", "stock", func(h *models.ProductHistory) interface{} { return &h.Stock }},
+	{"changed_at", "changed_at", func(h *models.ProductHistory) interface{} { return &h.ChangedAt }},
+}
+
+// Fields that can be requested with ?fields=
+var (
+	ProductFields  = append(fieldNames(productColumns), FieldCategories)
+	CategoryFields = fieldNames(categoryColumns)
+	HistoryFields  = fieldNames(historyColumns)
+)
+
+func fieldNames[T any](columns []column[T]) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.field
+	}
+	return names
+}
+
+// selectColumns returns the SELECT list reading fields and the matching
+// scan destinations of a row
+func selectColumns[T any](columns []column[T], fields Fieldset) (string, func(*T) []interface{}) {
+	var exprs []string
+	var picked []column[T]
+	for _, c := range columns {
+		if fields.Has(c.field) {
+			exprs = append(exprs, c.sql)
+			picked = append(picked, c)
+		}
+	}
+	return strings.Join(exprs, ", "), func(v *T) []interface{} {
+		dests := make([]interface{}, len(picked))
+		for i, c := range picked {
+			dests[i] = c.dest(v)
+		}
+		return dests
+	}
+}
+
+// trim zeroes the fields of v that are not read, as if v had been loaded
+// with selectColumns
+func trim[T any](columns []column[T], v *T, fields Fieldset) {
+	for _, c := range columns {
+		if !fields.Has(c.field) {
+			reflect.ValueOf(c.dest(v)).Elem().SetZero()
+		}
+	}
+}
+
+// TrimProduct, TrimCategory and TrimHistory let stores without SQL mirror
+// the columns read for a Fieldset
+func TrimProduct(p *models.Product, fields Fieldset) {
+	trim(productColumns, p, fields)
+	if !fields.Has(FieldCategories) {
+		p.Categories = nil
+	}
+}
+
+func TrimCategory(c *models.Category, fields Fieldset) {
+	trim(categoryColumns, c, fields)
+}
+
+func TrimHistory(h *models.ProductHistory, fields Fieldset) {
+	trim(historyColumns, h, fields)
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// LockProducts needs no row locks: WithinTx already serializes transactions
+func (s *Store) LockProducts(ctx context.Context, filter *db.FilterParams, limit int) ([]int, error) {
+	filter.Validate()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []int{}
+	for _, p := range s.products {
+		if p.DeletedAt == nil && s.matchesFilter(p, filter) {
+			ids = append(ids, p.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids[:min(len(ids), limit)], nil
+}
+
+// BulkUpdateProducts checks every product before changing any, as the
+// single UPDATE of the PostgreSQL store fails as a whole
+func (s *Store) BulkUpdateProducts(ctx context.Context, ids []int, userID *int, req *models.ProductBulkUpdateRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkCategoriesExist(req.AddCategoryIDs); err != nil {
+		return err
+	}
+
+	hundred := money.New(100, 0)
+	var factor money.Amount
+	if req.AdjustPricePercent != nil {
+		factor = hundred.Add(*req.AdjustPricePercent).Quo(hundred, req.AdjustPricePercent.Scale()+2)
+	}
+
+	updated := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		product, ok := s.liveProduct(id)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case req.SetPrice != nil:
+			price, err := columnPrice(*req.SetPrice)
+			if err != nil {
+				return err
+			}
+			product.Price = price
+		case req.AdjustPricePercent != nil:
+			price, err := columnPrice(product.Price.Mul(factor, 2))
+			if err != nil {
+				return err
+			}
+			product.Price = price
+		}
+		switch {
+		case req.SetStock != nil:
+			product.Stock = *req.SetStock
+		case req.AdjustStock != nil:
+			product.Stock += *req.AdjustStock
+		}
+		if err := s.checkProduct(product.Price, product.Stock); err != nil {
+			return err
+		}
+
+		product.UpdatedAt = s.now()
+		product.Version++
+		updated = append(updated, product)
+	}
+
+	for _, product := range updated {
+		s.recordHistory(s.products[product.ID], product)
+		s.recordStockAdjustment(s.products[product.ID], product, userID)
+		s.products[product.ID] = product
+
+		links := s.productCategory[product.ID]
+		if links == nil {
+			links = make(map[int]bool)
+			s.productCategory[product.ID] = links
+		}
+		for _, categoryID := range req.AddCategoryIDs {
+			links[categoryID] = true
+		}
+		for _, categoryID := range req.RemoveCategoryIDs {
+			delete(links, categoryID)
+		}
+	}
+	return nil
+}
+
+func (s *Store) BulkDeleteProducts(ctx context.Context, ids []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, id := range ids {
+		product, ok := s.liveProduct(id)
+		if !ok {
+			continue
+		}
+		product.DeletedAt = &now
+		product.Version++
+		s.products[id] = product
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+var categoryComparators = map[string]func(a, b models.Category) int{
+	"name":       func(a, b models.Category) int { return compareStrings(a.Name, b.Name) },
+	"created_at": func(a, b models.Category) int { return compareTimes(a.CreatedAt, b.CreatedAt) },
+}
+
+func categoryByID(a, b models.Category) int { return compareInts(a.ID, b.ID) }
+
+func (s *Store) CreateCategory(ctx context.Context, req *models.CategoryCreateRequest) (*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.categoryNameTaken(req.Name, 0) {
+		return nil, categoryConflict(req.Name)
+	}
+
+	s.nextCategoryID++
+	now := s.now()
+	category := models.Category{
+		ID:          s.nextCategoryID,
+		Name:        req.Name,
+		Description: cloneString(req.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	s.categories[category.ID] = category
+
+	return s.copyCategory(category), nil
+}
+
+func (s *Store) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	category, ok := s.liveCategory(id)
+	if !ok {
+		return nil, db.NotFound("category")
+	}
+
+	return s.copyCategory(category), nil
+}
+
+func (s *Store) CategoryListVersion(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.categoryListVersion().String(), nil
+}
+
+func (s *Store) categoryListVersion() db.TableVersion {
+	categories := db.TableVersion{Rows: int64(len(s.categories)), LastID: int64(s.nextCategoryID)}
+	for _, c := range s.categories {
+		categories.Versions += int64(c.Version)
+	}
+	return categories
+}
+
+func (s *Store) ListCategories(ctx context.Context, search string, pagination *db.PaginationParams, fields db.Fieldset) ([]models.Category, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := s.filterCategories(search)
+	sortBy(categories, &db.FilterParams{}, categoryComparators, categoryComparators["name"], categoryByID)
+
+	if pagination != nil {
+		pagination.Validate()
+	}
+	page, total, err := listPage(categories, db.CategorySortKeys, db.CategorySortValue, pagination)
+	for i := range page {
+		db.TrimCategory(&page[i], fields)
+	}
+	return page, total, err
+}
+
+func (s *Store) ExportCategories(ctx context.Context, search string, fn func(*models.Category) error) error {
+	categories, _, err := s.ListCategories(ctx, search, nil, nil)
+	if err != nil {
+		return err
+	}
+	for i := range categories {
+		if err := fn(&categories[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, id int, req *models.CategoryUpdateRequest, ifVersions []int) (*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Name == nil && req.Description == nil && !req.ClearDescription {
+		return nil, fmt.Errorf("%w: no fields to update", db.ErrValidation)
+	}
+
+	category, ok := s.liveCategory(id)
+	if !ok {
+		return nil, db.NotFound("category")
+	}
+	if !versionMatches(category.Version, ifVersions) {
+		return nil, fmt.Errorf("category %w", db.ErrPreconditionFailed)
+	}
+
+	if req.Name != nil {
+		if s.categoryNameTaken(*req.Name, id) {
+			return nil, categoryConflict(*req.Name)
+		}
+		category.Name = *req.Name
+	}
+	if req.Description != nil {
+		category.Description = cloneString(req.Description)
+	}
+	if req.ClearDescription {
+		category.Description = nil
+	}
+	category.UpdatedAt = s.now()
+	category.Version++
+	s.categories[id] = category
+
+	return s.copyCategory(category), nil
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, id int, ifVersions []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.liveCategory(id)
+	if !ok {
+		return db.NotFound("category")
+	}
+	if !versionMatches(category.Version, ifVersions) {
+		return fmt.Errorf("category %w", db.ErrPreconditionFailed)
+	}
+
+	// Links stay in place so a restore brings them back
+	now := s.now()
+	category.DeletedAt = &now
+	category.Version++
+	s.categories[id] = category
+
+	return nil
+}
+
+func (s *Store) ListDeletedCategories(ctx context.Context) ([]models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []models.Category{}
+	for _, c := range s.categories {
+		if c.DeletedAt != nil {
+			categories = append(categories, *s.copyCategory(c))
+		}
+	}
+
+	// ORDER BY deleted_at DESC, id
+	sortBy(categories, &db.FilterParams{}, nil, func(a, b models.Category) int {
+		return compareTimes(*b.DeletedAt, *a.DeletedAt)
+	}, categoryByID)
+
+	return categories, nil
+}
+
+func (s *Store) RestoreCategory(ctx context.Context, id int) (*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok || category.DeletedAt == nil {
+		return nil, db.NotFound("deleted category")
+	}
+	if s.categoryNameTaken(category.Name, id) {
+		return nil, categoryConflict(category.Name)
+	}
+
+	category.DeletedAt = nil
+	category.UpdatedAt = s.now()
+	category.Version++
+	s.categories[id] = category
+
+	return s.copyCategory(category), nil
+}
+
+func (s *Store) PurgeCategories(ctx context.Context, deletedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, c := range s.categories {
+		if c.DeletedAt == nil || !c.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		delete(s.categories, id)
+
+		// ON DELETE CASCADE on product_category
+		for _, links := range s.productCategory {
+			delete(links, id)
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *Store) SearchCategories(ctx context.Context, searchTerm string, pagination *db.PaginationParams, filter *db.FilterParams) ([]models.Category, int, error) {
+	pagination.Validate()
+	filter.Validate()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := s.filterCategories(searchTerm)
+	sortBy(categories, filter, categoryComparators, categoryComparators["name"], categoryByID)
+
+	page := paginate(categories, pagination)
+	for i := range page {
+		db.TrimCategory(&page[i], filter.Fields)
+	}
+	return page, len(categories), nil
+}
+
+// filterCategories returns copies of the categories whose name contains search (ILIKE)
+func (s *Store) filterCategories(search string) []models.Category {
+	needle := strings.ToLower(search)
+
+	categories := []models.Category{}
+	for _, c := range s.categories {
+		if c.DeletedAt != nil {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(c.Name), needle) {
+			continue
+		}
+		categories = append(categories, *s.copyCategory(c))
+	}
+	return categories
+}
+
+// liveCategory returns a category that is not in the trash
+func (s *Store) liveCategory(id int) (models.Category, bool) {
+	c, ok := s.categories[id]
+	return c, ok && c.DeletedAt == nil
+}
+
+// categoryNameTaken enforces the unique index on live category names
+func (s *Store) categoryNameTaken(name string, exceptID int) bool {
+	for _, c := range s.categories {
+		if c.ID != exceptID && c.DeletedAt == nil && c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func categoryConflict(name string) error {
+	return db.Violation("idx_categories_name_active")
+}
+
+func (s *Store) copyCategory(c models.Category) *models.Category {
+	c.Description = cloneString(c.Description)
+	c.DeletedAt = cloneTime(c.DeletedAt)
+	return &c
+}
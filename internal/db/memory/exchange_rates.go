@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+var exchangeRateOrder = func(a, b models.ExchangeRate) int {
+	if c := compareStrings(a.BaseCurrency, b.BaseCurrency); c != 0 {
+		return c
+	}
+	if c := compareStrings(a.QuoteCurrency, b.QuoteCurrency); c != 0 {
+		return c
+	}
+	return compareTimes(b.EffectiveAt, a.EffectiveAt)
+}
+
+func (s *Store) CreateExchangeRate(ctx context.Context, req *models.ExchangeRateCreateRequest) (*models.ExchangeRate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Mirror the CHECK constraints on exchange_rates
+	if !models.IsCurrencyCode(req.BaseCurrency) || !models.IsCurrencyCode(req.QuoteCurrency) {
+		return nil, db.Violation("exchange_rates_base_currency_check")
+	}
+	if req.BaseCurrency == req.QuoteCurrency {
+		return nil, db.Violation("exchange_rates_check")
+	}
+	if req.Rate == nil || req.Rate.Sign() <= 0 {
+		return nil, db.Violation("exchange_rates_rate_check")
+	}
+
+	now := s.now()
+	effectiveAt := now
+	if req.EffectiveAt != nil {
+		effectiveAt = *req.EffectiveAt
+	}
+
+	// UNIQUE (base_currency, quote_currency, effective_at)
+	for _, r := range s.exchangeRates {
+		if r.BaseCurrency == req.BaseCurrency && r.QuoteCurrency == req.QuoteCurrency && r.EffectiveAt.Equal(effectiveAt) {
+			return nil, db.Violation("exchange_rates_base_currency_quote_currency_effective_at_key")
+		}
+	}
+
+	s.nextExchangeRateID++
+	rate := models.ExchangeRate{
+		ID:            s.nextExchangeRateID,
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Rate:          req.Rate.Round(models.RateMaxDecimals),
+		EffectiveAt:   effectiveAt,
+		CreatedAt:     now,
+	}
+	s.exchangeRates[rate.ID] = rate
+
+	return &rate, nil
+}
+
+func (s *Store) ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := []models.ExchangeRate{}
+	for _, r := range s.exchangeRates {
+		if (base == "" || r.BaseCurrency == base) && (quote == "" || r.QuoteCurrency == quote) {
+			rates = append(rates, r)
+		}
+	}
+	sortBy(rates, &db.FilterParams{}, nil, exchangeRateOrder, func(a, b models.ExchangeRate) int { return compareInts(a.ID, b.ID) })
+
+	return rates, nil
+}
+
+func (s *Store) DeleteExchangeRate(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.exchangeRates[id]; !ok {
+		return db.NotFound("exchange rate")
+	}
+	delete(s.exchangeRates, id)
+
+	return nil
+}
+
+func (s *Store) GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *models.ExchangeRate
+	for _, r := range s.exchangeRates {
+		if r.BaseCurrency != base || r.QuoteCurrency != quote || r.EffectiveAt.After(at) {
+			continue
+		}
+		if latest == nil || r.EffectiveAt.After(latest.EffectiveAt) {
+			candidate := r
+			latest = &candidate
+		}
+	}
+	if latest == nil {
+		return nil, db.NotFound("exchange rate")
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,624 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+var productComparators = map[string]func(a, b models.Product) int{
+	"name":       func(a, b models.Product) int { return compareStrings(a.Name, b.Name) },
+	"price":      func(a, b models.Product) int { return a.Price.Cmp(b.Price) },
+	"stock":      func(a, b models.Product) int { return compareInts(a.Stock, b.Stock) },
+	"created_at": func(a, b models.Product) int { return compareTimes(a.CreatedAt, b.CreatedAt) },
+}
+
+// productNewestFirst is the default ordering (created_at DESC)
+func productNewestFirst(a, b models.Product) int { return compareTimes(b.CreatedAt, a.CreatedAt) }
+
+func productByID(a, b models.Product) int { return compareInts(a.ID, b.ID) }
+
+func (s *Store) CreateProduct(ctx context.Context, req *models.ProductCreateRequest) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Price == nil {
+		return nil, fmt.Errorf("%w: product price is required", db.ErrValidation)
+	}
+	price, err := columnPrice(*req.Price)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProduct(price, req.Stock); err != nil {
+		return nil, err
+	}
+	if err := s.checkCategoriesExist(req.CategoryIDs); err != nil {
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if !models.IsCurrencyCode(currency) {
+		return nil, db.Violation("products_currency_check")
+	}
+
+	if req.SKU != nil && s.skuTaken(*req.SKU, 0) {
+		return nil, skuConflict(*req.SKU)
+	}
+
+	s.nextProductID++
+	now := s.now()
+	product := models.Product{
+		ID:          s.nextProductID,
+		SKU:         cloneString(req.SKU),
+		Name:        req.Name,
+		Description: cloneString(req.Description),
+		Price:       price,
+		Currency:    currency,
+		Stock:       req.Stock,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	s.products[product.ID] = product
+	s.setProductCategories(product.ID, req.CategoryIDs)
+
+	return s.copyProduct(product), nil
+}
+
+func (s *Store) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	product, ok := s.liveProduct(id)
+	if !ok {
+		return nil, db.NotFound("product")
+	}
+
+	return s.copyProduct(product), nil
+}
+
+func (s *Store) GetProductsByIDs(ctx context.Context, ids []int, fields db.Fieldset) ([]models.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := []models.Product{}
+	for _, id := range ids {
+		if p, ok := s.liveProduct(id); ok {
+			product := *s.copyProduct(p)
+			db.TrimProduct(&product, fields.With("id"))
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (s *Store) ProductListVersion(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := db.TableVersion{Rows: int64(len(s.products)), LastID: int64(s.nextProductID)}
+	for _, p := range s.products {
+		products.Versions += int64(p.Version)
+	}
+	return products.String() + "/" + s.categoryListVersion().String(), nil
+}
+
+func (s *Store) ListProducts(ctx context.Context, pagination *db.PaginationParams, filter *db.FilterParams) ([]models.Product, int, error) {
+	pagination.Validate()
+	filter.Validate()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := s.filterProducts(filter)
+
+	page, total, err := listPage(products, db.ProductSortKeys(filter), db.ProductSortValue, pagination)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]models.Product, len(page))
+	for i, p := range page {
+		result[i] = *s.copyProduct(p)
+		db.TrimProduct(&result[i], filter.Fields)
+	}
+
+	return result, total, nil
+}
+
+// ExportProducts copies the matching products under the lock and calls fn
+// after releasing it, so a slow consumer does not block writers
+func (s *Store) ExportProducts(ctx context.Context, filter *db.FilterParams, fn func(*models.Product) error) error {
+	filter.Validate()
+
+	s.mu.RLock()
+	products := s.filterProducts(filter)
+	for i := range products {
+		products[i] = *s.copyProduct(products[i])
+		if !filter.Fields.Has(db.FieldCategories) {
+			products[i].Categories = nil
+		}
+	}
+	s.mu.RUnlock()
+
+	for i := range products {
+		if err := fn(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterProducts returns the live products matching filter in its order
+func (s *Store) filterProducts(filter *db.FilterParams) []models.Product {
+	products := []models.Product{}
+	for _, p := range s.products {
+		if p.DeletedAt != nil {
+			continue
+		}
+		if !s.matchesFilter(p, filter) {
+			continue
+		}
+		products = append(products, p)
+	}
+
+	sortBy(products, filter, productComparators, productNewestFirst, productByID)
+	return products
+}
+
+func (s *Store) UpdateProduct(ctx context.Context, id int, userID *int, req *models.ProductUpdateRequest, ifVersions []int) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.SKU == nil && req.Name == nil && req.Description == nil && req.Price == nil && req.Currency == nil && req.Stock == nil && len(req.CategoryIDs) == 0 &&
+		!req.ClearSKU && !req.ClearDescription {
+		return nil, fmt.Errorf("%w: no fields to update", db.ErrValidation)
+	}
+
+	product, ok := s.liveProduct(id)
+	if !ok {
+		return nil, db.NotFound("product")
+	}
+	if !versionMatches(product.Version, ifVersions) {
+		return nil, fmt.Errorf("product %w", db.ErrPreconditionFailed)
+	}
+
+	previous := product
+	if req.SKU != nil {
+		if s.skuTaken(*req.SKU, id) {
+			return nil, skuConflict(*req.SKU)
+		}
+		product.SKU = cloneString(req.SKU)
+	}
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Description != nil {
+		product.Description = cloneString(req.Description)
+	}
+	if req.ClearSKU {
+		product.SKU = nil
+	}
+	if req.ClearDescription {
+		product.Description = nil
+	}
+	if req.Price != nil {
+		price, err := columnPrice(*req.Price)
+		if err != nil {
+			return nil, err
+		}
+		product.Price = price
+	}
+	if req.Currency != nil {
+		if !models.IsCurrencyCode(*req.Currency) {
+			return nil, db.Violation("products_currency_check")
+		}
+		product.Currency = *req.Currency
+	}
+	if req.Stock != nil {
+		product.Stock = *req.Stock
+	}
+	if err := s.checkProduct(product.Price, product.Stock); err != nil {
+		return nil, err
+	}
+	if len(req.CategoryIDs) > 0 {
+		if err := s.checkCategoriesExist(req.CategoryIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	product.UpdatedAt = s.now()
+	product.Version++
+	s.products[id] = product
+	s.recordHistory(previous, product)
+	s.recordStockAdjustment(previous, product, userID)
+
+	if len(req.CategoryIDs) > 0 {
+		s.replaceProductCategories(id, req.CategoryIDs)
+	}
+
+	return s.copyProduct(product), nil
+}
+
+func (s *Store) DeleteProduct(ctx context.Context, id int, ifVersions []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.liveProduct(id)
+	if !ok {
+		return db.NotFound("product")
+	}
+	if !versionMatches(product.Version, ifVersions) {
+		return fmt.Errorf("product %w", db.ErrPreconditionFailed)
+	}
+
+	now := s.now()
+	product.DeletedAt = &now
+	product.Version++
+	s.products[id] = product
+
+	return nil
+}
+
+func (s *Store) ListDeletedProducts(ctx context.Context, pagination *db.PaginationParams) ([]models.Product, int, error) {
+	pagination.Validate()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	products := []models.Product{}
+	for _, p := range s.products {
+		if p.DeletedAt != nil {
+			products = append(products, p)
+		}
+	}
+
+	// ORDER BY deleted_at DESC, id
+	sortBy(products, &db.FilterParams{}, nil, func(a, b models.Product) int {
+		return compareTimes(*b.DeletedAt, *a.DeletedAt)
+	}, productByID)
+
+	page := paginate(products, pagination)
+	result := make([]models.Product, len(page))
+	for i, p := range page {
+		result[i] = *s.copyProduct(p)
+	}
+
+	return result, len(products), nil
+}
+
+func (s *Store) RestoreProduct(ctx context.Context, id int) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[id]
+	if !ok || product.DeletedAt == nil {
+		return nil, db.NotFound("deleted product")
+	}
+	if product.SKU != nil && s.skuTaken(*product.SKU, id) {
+		return nil, skuConflict(*product.SKU)
+	}
+
+	product.DeletedAt = nil
+	product.Version++
+	s.products[id] = product
+
+	return s.copyProduct(product), nil
+}
+
+func (s *Store) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := make(map[int]bool)
+	for id, p := range s.products {
+		if p.DeletedAt != nil && p.DeletedAt.Before(deletedBefore) {
+			delete(s.products, id)
+			// ON DELETE CASCADE on product_category
+			delete(s.productCategory, id)
+			purged[id] = true
+		}
+	}
+
+	// ON DELETE CASCADE on product_history
+	history := s.productHistory[:0]
+	for _, h := range s.productHistory {
+		if !purged[h.ProductID] {
+			history = append(history, h)
+		}
+	}
+	s.productHistory = history
+
+	// ON DELETE CASCADE on stock_movements
+	movements := s.stockMovements[:0]
+	for _, m := range s.stockMovements {
+		if !purged[m.ProductID] {
+			movements = append(movements, m)
+		}
+	}
+	s.stockMovements = movements
+
+	return len(purged), nil
+}
+
+func (s *Store) GetProductHistory(ctx context.Context, productID int, start, end *time.Time, pagination *db.PaginationParams, fields db.Fieldset) ([]models.ProductHistory, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := []models.ProductHistory{}
+	for _, h := range s.productHistory {
+		if h.ProductID != productID {
+			continue
+		}
+		if start != nil && h.ChangedAt.Before(*start) {
+			continue
+		}
+		if end != nil && h.ChangedAt.After(*end) {
+			continue
+		}
+		history = append(history, h)
+	}
+
+	// ORDER BY changed_at DESC, id DESC
+	sort.SliceStable(history, func(i, j int) bool {
+		if !history[i].ChangedAt.Equal(history[j].ChangedAt) {
+			return history[i].ChangedAt.After(history[j].ChangedAt)
+		}
+		return history[i].ID > history[j].ID
+	})
+
+	if pagination != nil {
+		pagination.Validate()
+	}
+	page, total, err := listPage(history, db.HistorySortKeys, db.HistorySortValue, pagination)
+	for i := range page {
+		db.TrimHistory(&page[i], fields)
+	}
+	return page, total, err
+}
+
+func (s *Store) ExportProductHistory(ctx context.Context, productID int, start, end *time.Time, fn func(*models.ProductHistory) error) error {
+	history, _, err := s.GetProductHistory(ctx, productID, start, end, nil, nil)
+	if err != nil {
+		return err
+	}
+	for i := range history {
+		if err := fn(&history[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetProductCategories(ctx context.Context, productID int) ([]models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.productCategories(productID), nil
+}
+
+// productCategories returns the categories linked to a product ordered by name
+func (s *Store) productCategories(productID int) []models.Category {
+	categories := []models.Category{}
+	for categoryID := range s.productCategory[productID] {
+		if c, ok := s.liveCategory(categoryID); ok {
+			categories = append(categories, *s.copyCategory(c))
+		}
+	}
+	sortBy(categories, &db.FilterParams{}, categoryComparators, categoryComparators["name"], categoryByID)
+	return categories
+}
+
+// recordHistory mirrors the trg_product_history trigger
+func (s *Store) recordHistory(previous, current models.Product) {
+	if previous.Price == current.Price && previous.Stock == current.Stock && previous.Currency == current.Currency {
+		return
+	}
+
+	s.nextHistoryID++
+	s.productHistory = append(s.productHistory, models.ProductHistory{
+		ID:        s.nextHistoryID,
+		ProductID: current.ID,
+		Price:     current.Price,
+		Currency:  current.Currency,
+		Stock:     current.Stock,
+		ChangedAt: s.now(),
+	})
+}
+
+// maxPrice is the first value that does not fit in NUMERIC(12,2)
+var maxPrice = money.New(1, -10)
+
+// columnPrice mirrors how PostgreSQL stores a NUMERIC(12,2): rounded to
+// two decimals, failing when the integer part has more than 10 digits
+func columnPrice(price money.Amount) (money.Amount, error) {
+	rounded := price.Round(2)
+	if rounded.Cmp(maxPrice) >= 0 || rounded.Cmp(money.New(-1, -10)) <= 0 {
+		return money.Amount{}, fmt.Errorf("%w: numeric field overflow", db.ErrValidation)
+	}
+	return rounded, nil
+}
+
+// checkProduct mirrors the CHECK constraints on products
+func (s *Store) checkProduct(price money.Amount, stock int) error {
+	if price.Sign() < 0 {
+		return db.Violation("products_price_check")
+	}
+	if stock < 0 {
+		return db.Violation("products_stock_check")
+	}
+	return nil
+}
+
+// checkCategoriesExist mirrors the foreign key on product_category.category_id
+func (s *Store) checkCategoriesExist(categoryIDs []int) error {
+	seen := make(map[int]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if _, ok := s.liveCategory(id); !ok {
+			return db.MissingCategory(id)
+		}
+		if seen[id] {
+			return db.Violation("product_category_pkey")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func (s *Store) setProductCategories(productID int, categoryIDs []int) {
+	links := make(map[int]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		links[id] = true
+	}
+	s.productCategory[productID] = links
+}
+
+// replaceProductCategories swaps the live category links, keeping links to
+// categories in the trash so that restoring them brings the links back
+func (s *Store) replaceProductCategories(productID int, categoryIDs []int) {
+	links := make(map[int]bool, len(categoryIDs))
+	for categoryID := range s.productCategory[productID] {
+		if _, live := s.liveCategory(categoryID); !live {
+			links[categoryID] = true
+		}
+	}
+	for _, id := range categoryIDs {
+		links[id] = true
+	}
+	s.productCategory[productID] = links
+}
+
+// liveProduct returns a product that is not in the trash
+func (s *Store) liveProduct(id int) (models.Product, bool) {
+	p, ok := s.products[id]
+	return p, ok && p.DeletedAt == nil
+}
+
+// matchesFilter mirrors the WHERE clause built by the PostgreSQL ListProducts
+func (s *Store) matchesFilter(p models.Product, filter *db.FilterParams) bool {
+	if len(filter.IDs) > 0 && !slices.Contains(filter.IDs, p.ID) {
+		return false
+	}
+	if len(filter.CategoryIDs) > 0 {
+		linked := 0
+		for _, id := range filter.CategoryIDs {
+			if s.hasLiveCategory(p.ID, id) {
+				linked++
+			}
+		}
+		if linked == 0 || (filter.CategoryMatch == db.CategoryMatchAll && linked < len(filter.CategoryIDs)) {
+			return false
+		}
+	}
+	if filter.Search != "" && !matchesFullText(p.Name, filter.Search) {
+		return false
+	}
+	if filter.MinPrice != nil && p.Price.Cmp(*filter.MinPrice) < 0 {
+		return false
+	}
+	if filter.MaxPrice != nil && p.Price.Cmp(*filter.MaxPrice) > 0 {
+		return false
+	}
+	if filter.InStock != nil && *filter.InStock != (p.Stock > 0) {
+		return false
+	}
+	if filter.MinStock != nil && p.Stock < *filter.MinStock {
+		return false
+	}
+	if filter.MaxStock != nil && p.Stock > *filter.MaxStock {
+		return false
+	}
+	if filter.CreatedAfter != nil && p.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.UpdatedAfter != nil && p.UpdatedAt.Before(*filter.UpdatedAfter) {
+		return false
+	}
+	if filter.Expr != nil && !filterexpr.Eval(filter.Expr, s.productField(p)) {
+		return false
+	}
+	return true
+}
+
+// productField reads the db.ProductFilterFields of p
+func (s *Store) productField(p models.Product) filterexpr.Getter {
+	return func(field string) interface{} {
+		switch field {
+		case "id":
+			return p.ID
+		case "name":
+			return p.Name
+		case "price":
+			return p.Price
+		case "currency":
+			return p.Currency
+		case "stock":
+			return p.Stock
+		case "created_at":
+			return p.CreatedAt
+		case "updated_at":
+			return p.UpdatedAt
+		case "category":
+			var ids []int
+			for id := range s.productCategory[p.ID] {
+				if _, live := s.liveCategory(id); live {
+					ids = append(ids, id)
+				}
+			}
+			return ids
+		default:
+			return nil
+		}
+	}
+}
+
+// hasLiveCategory reports whether the product is linked to a category that is not in the trash
+func (s *Store) hasLiveCategory(productID, categoryID int) bool {
+	_, live := s.liveCategory(categoryID)
+	return live && s.productCategory[productID][categoryID]
+}
+
+func (s *Store) ProductIDsBySKU(ctx context.Context, skus []string) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string]int)
+	for _, p := range s.products {
+		if p.DeletedAt == nil && p.SKU != nil && slices.Contains(skus, *p.SKU) {
+			ids[*p.SKU] = p.ID
+		}
+	}
+	return ids, nil
+}
+
+// skuTaken enforces the unique index on live product SKUs
+func (s *Store) skuTaken(sku string, exceptID int) bool {
+	for _, p := range s.products {
+		if p.ID != exceptID && p.DeletedAt == nil && p.SKU != nil && *p.SKU == sku {
+			return true
+		}
+	}
+	return false
+}
+
+func skuConflict(sku string) error {
+	return db.Violation("idx_products_sku_active")
+}
+
+func (s *Store) copyProduct(p models.Product) *models.Product {
+	p.SKU = cloneString(p.SKU)
+	p.Description = cloneString(p.Description)
+	p.DeletedAt = cloneTime(p.DeletedAt)
+	p.Categories = s.productCategories(p.ID)
+	return &p
+}
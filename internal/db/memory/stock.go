@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+func (s *Store) CreateStockMovement(ctx context.Context, productID int, userID *int, req *models.StockMovementCreateRequest) (*models.StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.liveProduct(productID)
+	if !ok {
+		return nil, db.NotFound("product")
+	}
+	stockAfter := product.Stock + req.Quantity
+	if stockAfter < 0 {
+		return nil, db.InsufficientStock(product.Stock, req.Quantity)
+	}
+
+	previous := product
+	product.Stock = stockAfter
+	product.UpdatedAt = s.now()
+	product.Version++
+	s.products[productID] = product
+	s.recordHistory(previous, product)
+
+	movement := s.appendStockMovement(productID, req.Quantity, req.Reason, req.Reference, userID, stockAfter)
+	return &movement, nil
+}
+
+// recordStockAdjustment writes a stock change made by a product write to
+// the ledger as an adjustment, as the database does
+func (s *Store) recordStockAdjustment(before, after models.Product, userID *int) {
+	if after.Stock != before.Stock {
+		s.appendStockMovement(after.ID, after.Stock-before.Stock, models.StockReasonAdjustment, nil, userID, after.Stock)
+	}
+}
+
+func (s *Store) appendStockMovement(productID, quantity int, reason string, reference *string, userID *int, stockAfter int) models.StockMovement {
+	s.nextStockMovementID++
+	movement := models.StockMovement{
+		ID:         s.nextStockMovementID,
+		ProductID:  productID,
+		Quantity:   quantity,
+		Reason:     reason,
+		Reference:  cloneString(reference),
+		UserID:     cloneInt(userID),
+		StockAfter: stockAfter,
+		CreatedAt:  s.now(),
+	}
+	s.stockMovements = append(s.stockMovements, movement)
+	return movement
+}
+
+func (s *Store) ListStockMovements(ctx context.Context, productID int, reason string, pagination *db.PaginationParams) ([]models.StockMovement, int, error) {
+	pagination.Validate()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Appended in creation order, so walking backwards is newest first
+	movements := []models.StockMovement{}
+	for i := len(s.stockMovements) - 1; i >= 0; i-- {
+		m := s.stockMovements[i]
+		if m.ProductID == productID && (reason == "" || m.Reason == reason) {
+			m.Reference, m.UserID = cloneString(m.Reference), cloneInt(m.UserID)
+			movements = append(movements, m)
+		}
+	}
+
+	return listPage(movements, db.StockMovementSortKeys, db.StockMovementSortValue, pagination)
+}
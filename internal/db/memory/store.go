@@ -0,0 +1,235 @@
+// Package memory provides an in-memory implementation of db.Store.
+// It mirrors the PostgreSQL behavior (constraints, cascades, history trigger,
+// search and pagination) closely enough for handler tests and demo mode.
+package memory
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// Store is a thread-safe in-memory db.Store
+type Store struct {
+	mu sync.RWMutex
+
+	// txMu serializes WithinTx calls
+	txMu sync.Mutex
+
+	roles               map[int]models.Role
+	users               map[int]models.User
+	categories          map[int]models.Category
+	products            map[int]models.Product
+	productHistory      []models.ProductHistory
+	productCategory     map[int]map[int]bool // product ID → set of category IDs
+	exchangeRates       map[int]models.ExchangeRate
+	stockMovements      []models.StockMovement
+	nextRoleID          int
+	nextUserID          int
+	nextCategoryID      int
+	nextProductID       int
+	nextHistoryID       int
+	nextExchangeRateID  int
+	nextStockMovementID int
+
+	// now is replaceable so tests can control timestamps
+	now func() time.Time
+}
+
+var _ db.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		roles:           make(map[int]models.Role),
+		users:           make(map[int]models.User),
+		categories:      make(map[int]models.Category),
+		products:        make(map[int]models.Product),
+		productCategory: make(map[int]map[int]bool),
+		exchangeRates:   make(map[int]models.ExchangeRate),
+		now:             time.Now,
+	}
+}
+
+// paginate applies LIMIT/OFFSET semantics to an already sorted slice
+func paginate[T any](items []T, pagination *db.PaginationParams) []T {
+	offset := pagination.Offset()
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + pagination.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// sortBy sorts items with the comparators registered for the requested sort
+// terms, falling back to def when no field is allowed. Ties are broken by
+// the tiebreak comparator so results are deterministic.
+func sortBy[T any](items []T, filter *db.FilterParams, comparators map[string]func(a, b T) int, def func(a, b T) int, tiebreak func(a, b T) int) {
+	type term struct {
+		cmp  func(a, b T) int
+		desc bool
+	}
+	var terms []term
+	for _, t := range filter.SortTerms() {
+		if cmp, ok := comparators[t.Field]; ok {
+			terms = append(terms, term{cmp, t.Desc})
+		}
+	}
+	if len(terms) == 0 {
+		terms = []term{{def, false}}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, t := range terms {
+			c := t.cmp(items[i], items[j])
+			if t.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return tiebreak(items[i], items[j]) < 0
+	})
+}
+
+// keysetPage mirrors the keyset reads of the PostgreSQL store: items must
+// be sorted by keys, and the rows past the cursor are returned in read
+// order (reversed for backward cursors), up to one more than the limit
+func keysetPage[T any](items []T, keys []db.SortKey, value func(T, string) string, pagination *db.PaginationParams) ([]T, error) {
+	rows := items
+	if c := pagination.Cursor; c != nil {
+		if err := c.Check(keys); err != nil {
+			return nil, err
+		}
+
+		// position compares an item with the cursor in listing order
+		position := func(item T) int {
+			for i, key := range keys {
+				if c := db.CompareSortValues(key.Type, value(item, key.Field), c.Values[i]); c != 0 {
+					if key.Desc {
+						return -c
+					}
+					return c
+				}
+			}
+			return 0
+		}
+
+		if c.Backward {
+			end := sort.Search(len(items), func(i int) bool { return position(items[i]) >= 0 })
+			rows = slices.Clone(items[:end])
+			slices.Reverse(rows)
+		} else {
+			start := sort.Search(len(items), func(i int) bool { return position(items[i]) > 0 })
+			rows = items[start:]
+		}
+	}
+
+	if len(rows) > pagination.Limit+1 {
+		rows = rows[:pagination.Limit+1]
+	}
+	return rows, nil
+}
+
+// listPage selects the requested page of items, already sorted by keys, and
+// the total the PostgreSQL store would report. A nil pagination returns all items.
+func listPage[T any](items []T, keys []db.SortKey, value func(T, string) string, pagination *db.PaginationParams) ([]T, int, error) {
+	if pagination == nil {
+		return items, len(items), nil
+	}
+	if !pagination.Keyset {
+		return paginate(items, pagination), len(items), nil
+	}
+
+	rows, err := keysetPage(items, keys, value, pagination)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(items)
+	if pagination.SkipTotal {
+		total = 0
+	}
+	return db.FinishKeysetPage(pagination, keys, rows, value), total, nil
+}
+
+// tokenize approximates to_tsvector('simple', ...): lowercase words
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchesFullText approximates to_tsvector('simple', text) @@ plainto_tsquery('simple', query)
+func matchesFullText(text, query string) bool {
+	words := make(map[string]bool)
+	for _, w := range tokenize(text) {
+		words[w] = true
+	}
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return false
+	}
+	for _, term := range terms {
+		if !words[term] {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func cloneInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	v := *i
+	return &v
+}
+
+// versionMatches mirrors "$n::int[] IS NULL OR version = ANY($n)"
+func versionMatches(version int, ifVersions []int) bool {
+	return ifVersions == nil || slices.Contains(ifVersions, version)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func compareStrings(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	return a.Compare(b)
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+type txKey struct{}
+
+// snapshot is a deep copy of the store contents used to roll back
+type snapshot struct {
+	roles               map[int]models.Role
+	users               map[int]models.User
+	categories          map[int]models.Category
+	products            map[int]models.Product
+	productHistory      []models.ProductHistory
+	productCategory     map[int]map[int]bool
+	exchangeRates       map[int]models.ExchangeRate
+	stockMovements      []models.StockMovement
+	nextRoleID          int
+	nextUserID          int
+	nextCategoryID      int
+	nextProductID       int
+	nextHistoryID       int
+	nextExchangeRateID  int
+	nextStockMovementID int
+}
+
+// WithinTx serializes transactions and restores the previous contents when
+// fn fails. Writes made outside a transaction while one is running are not
+// isolated from it, which is acceptable for tests and demo mode.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == nil {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+		ctx = context.WithValue(ctx, txKey{}, true)
+	}
+
+	saved := s.snapshot()
+	if err := fn(ctx); err != nil {
+		s.restore(saved)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) snapshot() snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := snapshot{
+		roles:               make(map[int]models.Role, len(s.roles)),
+		users:               make(map[int]models.User, len(s.users)),
+		categories:          make(map[int]models.Category, len(s.categories)),
+		products:            make(map[int]models.Product, len(s.products)),
+		productHistory:      append([]models.ProductHistory(nil), s.productHistory...),
+		productCategory:     make(map[int]map[int]bool, len(s.productCategory)),
+		exchangeRates:       make(map[int]models.ExchangeRate, len(s.exchangeRates)),
+		stockMovements:      append([]models.StockMovement(nil), s.stockMovements...),
+		nextRoleID:          s.nextRoleID,
+		nextUserID:          s.nextUserID,
+		nextCategoryID:      s.nextCategoryID,
+		nextProductID:       s.nextProductID,
+		nextHistoryID:       s.nextHistoryID,
+		nextExchangeRateID:  s.nextExchangeRateID,
+		nextStockMovementID: s.nextStockMovementID,
+	}
+	for id, r := range s.roles {
+		snap.roles[id] = r
+	}
+	for id, u := range s.users {
+		snap.users[id] = u
+	}
+	for id, c := range s.categories {
+		snap.categories[id] = c
+	}
+	for id, p := range s.products {
+		snap.products[id] = p
+	}
+	for id, r := range s.exchangeRates {
+		snap.exchangeRates[id] = r
+	}
+	for id, links := range s.productCategory {
+		copied := make(map[int]bool, len(links))
+		for categoryID := range links {
+			copied[categoryID] = true
+		}
+		snap.productCategory[id] = copied
+	}
+
+	return snap
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles = snap.roles
+	s.users = snap.users
+	s.categories = snap.categories
+	s.products = snap.products
+	s.productHistory = snap.productHistory
+	s.productCategory = snap.productCategory
+	s.exchangeRates = snap.exchangeRates
+	s.stockMovements = snap.stockMovements
+	s.nextRoleID = snap.nextRoleID
+	s.nextUserID = snap.nextUserID
+	s.nextCategoryID = snap.nextCategoryID
+	s.nextProductID = snap.nextProductID
+	s.nextHistoryID = snap.nextHistoryID
+	s.nextExchangeRateID = snap.nextExchangeRateID
+	s.nextStockMovementID = snap.nextStockMovementID
+}
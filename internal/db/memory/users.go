@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+func (s *Store) CreateUser(ctx context.Context, email, passwordHash string, roleID *int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return nil, db.Violation("users_email_key")
+		}
+	}
+
+	if roleID != nil {
+		if _, ok := s.roles[*roleID]; !ok {
+			return nil, db.Violation("users_role_id_fkey")
+		}
+	}
+
+	s.nextUserID++
+	user := models.User{
+		ID:           s.nextUserID,
+		Email:        email,
+		PasswordHash: passwordHash,
+		CreatedAt:    s.now(),
+	}
+	if roleID != nil {
+		id := *roleID
+		user.RoleID = &id
+	}
+	s.users[user.ID] = user
+
+	// Like the INSERT ... RETURNING query, the role is not joined here
+	created := user
+	return &created, nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return s.withRole(u), nil
+		}
+	}
+
+	return nil, db.NotFound("user")
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, db.NotFound("user")
+	}
+
+	return s.withRole(u), nil
+}
+
+func (s *Store) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.roles {
+		if r.Name == name {
+			role := r
+			return &role, nil
+		}
+	}
+
+	return nil, db.NotFound("role")
+}
+
+func (s *Store) CreateRole(ctx context.Context, name string) (*models.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.roles {
+		if r.Name == name {
+			return nil, db.Violation("roles_name_key")
+		}
+	}
+
+	s.nextRoleID++
+	role := models.Role{ID: s.nextRoleID, Name: name}
+	s.roles[role.ID] = role
+
+	created := role
+	return &created, nil
+}
+
+func (s *Store) EmailExists(ctx context.Context, email string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// withRole returns a copy of the user with its role joined (LEFT JOIN roles)
+func (s *Store) withRole(u models.User) *models.User {
+	if u.RoleID != nil {
+		id := *u.RoleID
+		u.RoleID = &id
+		if r, ok := s.roles[id]; ok {
+			role := r
+			u.Role = &role
+		}
+	}
+	return &u
+}
@@ -2,41 +2,66 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
 	"github.com/jackc/pgx/v5"
 )
 
+// ProductFilterFields are the fields ?filter= expressions may use on products
+var ProductFilterFields = filterexpr.Fields{
+	"id":         {Column: "p.id", Type: filterexpr.Int},
+	"name":       {Column: "p.name", Type: filterexpr.String},
+	"price":      {Column: "p.price", Type: filterexpr.Number},
+	"currency":   {Column: "p.currency", Type: filterexpr.String},
+	"stock":      {Column: "p.stock", Type: filterexpr.Int},
+	"created_at": {Column: "p.created_at", Type: filterexpr.Time},
+	"updated_at": {Column: "p.updated_at", Type: filterexpr.Time},
+	// The live categories of the product
+	"category": {Column: `SELECT pc.category_id FROM product_category pc
+		INNER JOIN categories c ON c.id = pc.category_id AND c.deleted_at IS NULL
+		WHERE pc.product_id = p.id`, Type: filterexpr.Membership},
+}
+
 // CreateProduct creates a new product with categories
 func (db *DB) CreateProduct(ctx context.Context, req *models.ProductCreateRequest) (*models.Product, error) {
-	tx, err := db.Begin(ctx)
+	tx, err := db.begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
 	query := `
-		INSERT INTO products (name, description, price, stock, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
-		RETURNING id, name, description, price, stock, created_at, updated_at
+		INSERT INTO products (sku, name, description, price, currency, stock, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, sku, name, description, price, currency, stock, created_at, updated_at, version
 	`
 
+	currency := req.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
 	var product models.Product
-	err = tx.QueryRow(ctx, query, req.Name, req.Description, req.Price, req.Stock).Scan(
+	err = tx.QueryRow(ctx, query, req.SKU, req.Name, req.Description, req.Price, currency, req.Stock).Scan(
 		&product.ID,
+		&product.SKU,
 		&product.Name,
 		&product.Description,
 		&product.Price,
+		&product.Currency,
 		&product.Stock,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Version,
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+		return nil, translateError(err, "product", "create")
 	}
 
 	// Insert product-category relationships
@@ -51,7 +76,10 @@ func (db *DB) CreateProduct(ctx context.Context, req *models.ProductCreateReques
 	}
 
 	// Load categories
-	categories, _ := db.GetProductCategories(ctx, product.ID)
+	categories, err := db.GetProductCategories(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
 	product.Categories = categories
 
 	return &product, nil
@@ -59,117 +87,160 @@ func (db *DB) CreateProduct(ctx context.Context, req *models.ProductCreateReques
 
 func (db *DB) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
 	query := `
-		SELECT id, name, description, price, stock, created_at, updated_at
+		SELECT id, sku, name, description, price, currency, stock, created_at, updated_at, version
 		FROM products
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var product models.Product
-	err := db.QueryRow(ctx, query, id).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&product.ID,
+		&product.SKU,
 		&product.Name,
 		&product.Description,
 		&product.Price,
+		&product.Currency,
 		&product.Stock,
 		&product.CreatedAt,
 		&product.UpdatedAt,
+		&product.Version,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("product not found")
-		}
-		return nil, fmt.Errorf("failed to get product: %w", err)
+		return nil, translateError(err, "product", "get")
 	}
 
 	// Load categories
-	categories, _ := db.GetProductCategories(ctx, id)
+	categories, err := db.GetProductCategories(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	product.Categories = categories
 
 	return &product, nil
 }
 
+// GetProductsByIDs reads the live products among ids, with their categories
+// aggregated in the same query, in no particular order
+func (db *DB) GetProductsByIDs(ctx context.Context, ids []int, fields Fieldset) ([]models.Product, error) {
+	columns, dests := selectColumns(productColumns, fields.With("id"))
+	withCategories := fields.Has(FieldCategories)
+	if withCategories {
+		columns += `, COALESCE((
+			SELECT json_agg(json_build_object(
+				'id', c.id, 'name', c.name, 'description', c.description,
+				'created_at', c.created_at, 'updated_at', c.updated_at, 'version', c.version
+			) ORDER BY c.name)
+			FROM product_category pc
+			INNER JOIN categories c ON c.id = pc.category_id AND c.deleted_at IS NULL
+			WHERE pc.product_id = p.id
+		), '[]')`
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM products p WHERE p.id = ANY($1) AND p.deleted_at IS NULL", columns)
+	rows, err := db.conn(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+
+	products, err := ScanRows(rows, func(row pgx.Row) (models.Product, error) {
+		var product models.Product
+		targets := dests(&product)
+		if withCategories {
+			targets = append(targets, &product.Categories)
+		}
+		err := row.Scan(targets...)
+		return product, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan products: %w", err)
+	}
+	return products, nil
+}
+
+// ProductListVersion summarizes the products and the categories they embed.
+// Every write bumps a row version, inserts raise the highest ID and purges
+// lower the count, so the summary moves with any change.
+func (db *DB) ProductListVersion(ctx context.Context) (string, error) {
+	query := `
+		SELECT p.n, p.versions, p.last_id, c.n, c.versions, c.last_id
+		FROM (SELECT COUNT(*) AS n, COALESCE(SUM(version), 0) AS versions, COALESCE(MAX(id), 0) AS last_id FROM products) p,
+		     (SELECT COUNT(*) AS n, COALESCE(SUM(version), 0) AS versions, COALESCE(MAX(id), 0) AS last_id FROM categories) c
+	`
+
+	var products, categories TableVersion
+	err := db.conn(ctx).QueryRow(ctx, query).Scan(
+		&products.Rows, &products.Versions, &products.LastID,
+		&categories.Rows, &categories.Versions, &categories.LastID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to read product list version: %w", err)
+	}
+
+	return products.String() + "/" + categories.String(), nil
+}
+
 // ListProducts retrieves a paginated list of products with filtering and sorting
 func (db *DB) ListProducts(ctx context.Context, pagination *PaginationParams, filter *FilterParams) ([]models.Product, int, error) {
 	pagination.Validate()
 	filter.Validate()
 
-	// Build WHERE clause for search and category filter
-	var whereClause string
-	var fromClause string
+	// Build WHERE clause from the filters
+	fromClause := "FROM products p"
 	var args []interface{}
 	argCount := 0
 
-	if filter.CategoryID != nil {
-		fromClause = "FROM products p INNER JOIN product_category pc ON p.id = pc.product_id"
+	// arg adds a query argument and returns its placeholder
+	arg := func(value interface{}) string {
 		argCount++
-		whereClause = fmt.Sprintf("WHERE pc.category_id = $%d", argCount)
-		args = append(args, *filter.CategoryID)
-	} else {
-		fromClause = "FROM products p"
+		args = append(args, value)
+		return fmt.Sprintf("$%d", argCount)
 	}
+	whereClause := productWhere(filter, arg)
 
-	if filter.Search != "" {
-		argCount++
-		if whereClause != "" {
-			whereClause += fmt.Sprintf(" AND to_tsvector('simple', p.name) @@ plainto_tsquery('simple', $%d)", argCount)
-		} else {
-			whereClause = fmt.Sprintf("WHERE to_tsvector('simple', p.name) @@ plainto_tsquery('simple', $%d)", argCount)
-		}
-		args = append(args, filter.Search)
-	}
+	// Order by the requested field with the id as tiebreaker, so that pages are stable
+	keys := ProductSortKeys(filter)
 
-	// Build ORDER BY clause
-	allowedFields := map[string]string{
-		"name":       "p.name",
-		"price":      "p.price",
-		"stock":      "p.stock",
-		"created_at": "p.created_at",
-	}
-	orderByClause := filter.BuildOrderByClause(allowedFields)
-	if orderByClause == "" {
-		orderByClause = "ORDER BY p.created_at DESC" // Default sorting
+	// Count total records
+	total := 0
+	if !pagination.Keyset || !pagination.SkipTotal {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) %s %s", fromClause, whereClause)
+		var err error
+		total, err = db.CountRows(ctx, countQuery, args...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		}
 	}
 
-	// Count total records
-	countQuery := fmt.Sprintf("SELECT COUNT(DISTINCT p.id) %s %s", fromClause, whereClause)
-	total, err := db.CountRows(ctx, countQuery, args...)
+	// Query products with pagination: after the cursor, or at an offset
+	condition, orderByClause, pageClause, pageArgs, err := pageClauses(keys, pagination, argCount)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		return nil, 0, err
+	}
+	if condition != "" {
+		whereClause += " AND " + condition
 	}
+	args = append(args, pageArgs...)
 
-	// Query products with pagination
-	argCount++
-	limitArg := argCount
-	argCount++
-	offsetArg := argCount
+	// Read only the requested columns, plus those cursors are built from
+	columns, dests := selectColumns(productColumns, filter.Fields.With("id").withKeys(keys))
 
 	query := fmt.Sprintf(`
-		SELECT DISTINCT p.id, p.name, p.description, p.price, p.stock, p.created_at, p.updated_at
+		SELECT %s
 		%s
 		%s
 		%s
-		LIMIT $%d OFFSET $%d
-	`, fromClause, whereClause, orderByClause, limitArg, offsetArg)
-
-	args = append(args, pagination.Limit, pagination.Offset())
+		%s
+	`, columns, fromClause, whereClause, orderByClause, pageClause)
 
-	rows, err := db.Query(ctx, query, args...)
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query products: %w", err)
 	}
 
 	products, err := ScanRows(rows, func(row pgx.Row) (models.Product, error) {
 		var product models.Product
-		err := row.Scan(
-			&product.ID,
-			&product.Name,
-			&product.Description,
-			&product.Price,
-			&product.Stock,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+		err := row.Scan(dests(&product)...)
 		return product, err
 	})
 
@@ -177,27 +248,101 @@ func (db *DB) ListProducts(ctx context.Context, pagination *PaginationParams, fi
 		return nil, 0, fmt.Errorf("failed to scan products: %w", err)
 	}
 
-	// Load categories for each product
-	for i := range products {
-		categories, _ := db.GetProductCategories(ctx, products[i].ID)
-		products[i].Categories = categories
+	if pagination.Keyset {
+		products = FinishKeysetPage(pagination, keys, products, ProductSortValue)
+	}
+
+	if filter.Fields.Has(FieldCategories) {
+		if err := db.loadProductCategories(ctx, products); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	return products, total, nil
 }
 
-func (db *DB) UpdateProduct(ctx context.Context, id int, req *models.ProductUpdateRequest) (*models.Product, error) {
-	tx, err := db.Begin(ctx)
+// productWhere builds the WHERE clause selecting the live products that match
+// filter; arg adds a query argument and returns its placeholder
+func productWhere(filter *FilterParams, arg func(interface{}) string) string {
+	whereClause := "WHERE p.deleted_at IS NULL"
+
+	if len(filter.IDs) > 0 {
+		whereClause += " AND p.id = ANY(" + arg(filter.IDs) + ")"
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		// Links to categories in the trash do not count
+		linked := fmt.Sprintf(`SELECT COUNT(*) FROM product_category pc
+			INNER JOIN categories c ON c.id = pc.category_id AND c.deleted_at IS NULL
+			WHERE pc.product_id = p.id AND pc.category_id = ANY(%s)`, arg(filter.CategoryIDs))
+		if filter.CategoryMatch == CategoryMatchAll {
+			whereClause += fmt.Sprintf(" AND (%s) = %s", linked, arg(len(filter.CategoryIDs)))
+		} else {
+			whereClause += fmt.Sprintf(" AND (%s) > 0", linked)
+		}
+	}
+
+	if filter.Search != "" {
+		whereClause += fmt.Sprintf(" AND to_tsvector('simple', p.name) @@ plainto_tsquery('simple', %s)", arg(filter.Search))
+	}
+
+	if filter.MinPrice != nil {
+		whereClause += " AND p.price >= " + arg(*filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		whereClause += " AND p.price <= " + arg(*filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			whereClause += " AND p.stock > 0"
+		} else {
+			whereClause += " AND p.stock = 0"
+		}
+	}
+	if filter.MinStock != nil {
+		whereClause += " AND p.stock >= " + arg(*filter.MinStock)
+	}
+	if filter.MaxStock != nil {
+		whereClause += " AND p.stock <= " + arg(*filter.MaxStock)
+	}
+	if filter.CreatedAfter != nil {
+		whereClause += " AND p.created_at >= " + arg(*filter.CreatedAfter)
+	}
+	if filter.UpdatedAfter != nil {
+		whereClause += " AND p.updated_at >= " + arg(*filter.UpdatedAfter)
+	}
+	if filter.Expr != nil {
+		whereClause += " AND " + filterexpr.SQL(filter.Expr, arg)
+	}
+
+	return whereClause
+}
+
+func (db *DB) UpdateProduct(ctx context.Context, id int, userID *int, req *models.ProductUpdateRequest, ifVersions []int) (*models.Product, error) {
+	tx, err := db.begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
+	var stockBefore map[int]int
+	if req.Stock != nil {
+		if stockBefore, err = lockStocks(ctx, tx, []int{id}); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build dynamic UPDATE query
 	updates := []string{}
 	args := []interface{}{}
 	argCount := 0
 
+	if req.SKU != nil {
+		argCount++
+		updates = append(updates, fmt.Sprintf("sku = $%d", argCount))
+		args = append(args, *req.SKU)
+	}
+
 	if req.Name != nil {
 		argCount++
 		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
@@ -210,12 +355,26 @@ func (db *DB) UpdateProduct(ctx context.Context, id int, req *models.ProductUpda
 		args = append(args, *req.Description)
 	}
 
+	if req.ClearSKU {
+		updates = append(updates, "sku = NULL")
+	}
+
+	if req.ClearDescription {
+		updates = append(updates, "description = NULL")
+	}
+
 	if req.Price != nil {
 		argCount++
 		updates = append(updates, fmt.Sprintf("price = $%d", argCount))
 		args = append(args, *req.Price)
 	}
 
+	if req.Currency != nil {
+		argCount++
+		updates = append(updates, fmt.Sprintf("currency = $%d", argCount))
+		args = append(args, *req.Currency)
+	}
+
 	if req.Stock != nil {
 		argCount++
 		updates = append(updates, fmt.Sprintf("stock = $%d", argCount))
@@ -223,49 +382,62 @@ func (db *DB) UpdateProduct(ctx context.Context, id int, req *models.ProductUpda
 	}
 
 	if len(updates) == 0 && len(req.CategoryIDs) == 0 {
-		return nil, fmt.Errorf("no fields to update")
+		return nil, fmt.Errorf("%w: no fields to update", ErrValidation)
 	}
 
-	// Always update updated_at
+	// Always update updated_at and bump the version
 	argCount++
-	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount))
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount), "version = version + 1")
 	args = append(args, time.Now())
 
-	// Add product ID as last argument
-	argCount++
-	args = append(args, id)
+	// Add product ID and accepted versions as last arguments
+	argCount += 2
+	args = append(args, id, ifVersions)
 
 	if len(updates) > 0 {
 		query := fmt.Sprintf(`
 			UPDATE products
 			SET %s
-			WHERE id = $%d
-			RETURNING id, name, description, price, stock, created_at, updated_at
-		`, strings.Join(updates, ", "), argCount)
+			WHERE id = $%d AND deleted_at IS NULL AND ($%d::int[] IS NULL OR version = ANY($%d))
+			RETURNING id, sku, name, description, price, currency, stock, created_at, updated_at, version
+		`, strings.Join(updates, ", "), argCount-1, argCount, argCount)
 
 		var product models.Product
 		err = tx.QueryRow(ctx, query, args...).Scan(
 			&product.ID,
+			&product.SKU,
 			&product.Name,
 			&product.Description,
 			&product.Price,
+			&product.Currency,
 			&product.Stock,
 			&product.CreatedAt,
 			&product.UpdatedAt,
+			&product.Version,
 		)
 
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, db.missingOrStale(ctx, "products", "product", id, ifVersions)
+		}
 		if err != nil {
-			if err == pgx.ErrNoRows {
-				return nil, fmt.Errorf("product not found")
-			}
-			return nil, fmt.Errorf("failed to update product: %w", err)
+			return nil, translateError(err, "product", "update")
+		}
+	}
+
+	if req.Stock != nil {
+		if err := recordStockAdjustments(ctx, tx, stockBefore, userID); err != nil {
+			return nil, err
 		}
 	}
 
 	// Update categories if provided
 	if len(req.CategoryIDs) > 0 {
-		// Delete existing categories
-		_, err = tx.Exec(ctx, "DELETE FROM product_category WHERE product_id = $1", id)
+		// Delete existing categories; links to trashed categories are kept so a restore brings them back
+		_, err = tx.Exec(ctx, `
+			DELETE FROM product_category pc
+			USING categories c
+			WHERE pc.product_id = $1 AND c.id = pc.category_id AND c.deleted_at IS NULL
+		`, id)
 		if err != nil {
 			return nil, fmt.Errorf("failed to delete product categories: %w", err)
 		}
@@ -284,98 +456,292 @@ func (db *DB) UpdateProduct(ctx context.Context, id int, req *models.ProductUpda
 	return db.GetProductByID(ctx, id)
 }
 
-func (db *DB) DeleteProduct(ctx context.Context, id int) error {
-	query := `DELETE FROM products WHERE id = $1`
+func (db *DB) DeleteProduct(ctx context.Context, id int, ifVersions []int) error {
+	query := `
+		UPDATE products
+		SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::int[] IS NULL OR version = ANY($2))
+	`
 
-	result, err := db.Exec(ctx, query, id)
+	result, err := db.conn(ctx).Exec(ctx, query, id, ifVersions)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return translateError(err, "product", "delete")
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("product not found")
+		return db.missingOrStale(ctx, "products", "product", id, ifVersions)
 	}
 
 	return nil
 }
 
-func (db *DB) GetProductHistory(ctx context.Context, productID int, start, end *time.Time) ([]models.ProductHistory, error) {
-	query := `
-		SELECT id, product_id, price, stock, changed_at
+func (db *DB) GetProductHistory(ctx context.Context, productID int, start, end *time.Time, pagination *PaginationParams, fields Fieldset) ([]models.ProductHistory, int, error) {
+	whereClause, args := historyWhere(productID, start, end)
+	argCount := len(args)
+
+	total := 0
+	orderByClause := keysetOrderBy(HistorySortKeys, false)
+	pageClause := ""
+	if pagination != nil {
+		pagination.Validate()
+
+		if !pagination.Keyset || !pagination.SkipTotal {
+			var err error
+			total, err = db.CountRows(ctx, "SELECT COUNT(*) FROM product_history "+whereClause, args...)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to count product history: %w", err)
+			}
+		}
+
+		condition, orderBy, limit, pageArgs, err := pageClauses(HistorySortKeys, pagination, argCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		if condition != "" {
+			whereClause += " AND " + condition
+		}
+		orderByClause, pageClause = orderBy, limit
+		args = append(args, pageArgs...)
+	}
+
+	columns, dests := selectColumns(historyColumns, fields.withKeys(HistorySortKeys))
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM product_history
-		WHERE product_id = $1
-	`
+		%s
+		%s
+		%s
+	`, columns, whereClause, orderByClause, pageClause)
+
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query product history: %w", err)
+	}
+
+	history, err := ScanRows(rows, func(row pgx.Row) (models.ProductHistory, error) {
+		var h models.ProductHistory
+		err := row.Scan(dests(&h)...)
+		return h, err
+	})
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan product history: %w", err)
+	}
+
+	if pagination == nil {
+		return history, len(history), nil
+	}
+	if pagination.Keyset {
+		history = FinishKeysetPage(pagination, HistorySortKeys, history, HistorySortValue)
+	}
+	return history, total, nil
+}
 
+// historyWhere builds the WHERE clause selecting the changes of a product
+// between start and end, either of which may be nil
+func historyWhere(productID int, start, end *time.Time) (string, []interface{}) {
+	whereClause := "WHERE product_id = $1"
 	args := []interface{}{productID}
-	argCount := 1
 
 	if start != nil {
-		argCount++
-		query += fmt.Sprintf(" AND changed_at >= $%d", argCount)
 		args = append(args, *start)
+		whereClause += fmt.Sprintf(" AND changed_at >= $%d", len(args))
 	}
 
 	if end != nil {
-		argCount++
-		query += fmt.Sprintf(" AND changed_at <= $%d", argCount)
 		args = append(args, *end)
+		whereClause += fmt.Sprintf(" AND changed_at <= $%d", len(args))
 	}
 
-	query += " ORDER BY changed_at DESC"
+	return whereClause, args
+}
 
-	rows, err := db.Query(ctx, query, args...)
+func (db *DB) GetProductCategories(ctx context.Context, productID int) ([]models.Category, error) {
+	byProduct, err := db.categoriesByProduct(ctx, []int{productID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query product history: %w", err)
+		return nil, err
 	}
+	return byProduct[productID], nil
+}
 
-	history, err := ScanRows(rows, func(row pgx.Row) (models.ProductHistory, error) {
-		var h models.ProductHistory
-		err := row.Scan(&h.ID, &h.ProductID, &h.Price, &h.Stock, &h.ChangedAt)
-		return h, err
-	})
+func (db *DB) ProductIDsBySKU(ctx context.Context, skus []string) (map[string]int, error) {
+	rows, err := db.conn(ctx).Query(ctx, `SELECT sku, id FROM products WHERE sku = ANY($1) AND deleted_at IS NULL`, skus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products by sku: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var sku string
+		var id int
+		if err := rows.Scan(&sku, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan product sku: %w", err)
+		}
+		ids[sku] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read products by sku: %w", err)
+	}
+	return ids, nil
+}
 
+// loadProductCategories fills in the categories of a page of products with
+// a single query instead of one per product
+func (db *DB) loadProductCategories(ctx context.Context, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+
+	byProduct, err := db.categoriesByProduct(ctx, ids)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan product history: %w", err)
+		return err
 	}
 
-	return history, nil
+	for i := range products {
+		products[i].Categories = byProduct[products[i].ID]
+	}
+	return nil
 }
 
-func (db *DB) GetProductCategories(ctx context.Context, productID int) ([]models.Category, error) {
+// categoriesByProduct returns the live categories of each product, ordered by name
+func (db *DB) categoriesByProduct(ctx context.Context, productIDs []int) (map[int][]models.Category, error) {
 	query := `
-		SELECT c.id, c.name, c.description, c.created_at, c.updated_at
+		SELECT pc.product_id, c.id, c.name, c.description, c.created_at, c.updated_at, c.version
 		FROM categories c
 		INNER JOIN product_category pc ON c.id = pc.category_id
-		WHERE pc.product_id = $1
-		ORDER BY c.name
+		WHERE pc.product_id = ANY($1) AND c.deleted_at IS NULL
+		ORDER BY pc.product_id, c.name
 	`
 
-	rows, err := db.Query(ctx, query, productID)
+	rows, err := db.conn(ctx).Query(ctx, query, productIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query product categories: %w", err)
 	}
 
-	categories, err := ScanRows(rows, func(row pgx.Row) (models.Category, error) {
-		var c models.Category
-		err := row.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt)
-		return c, err
+	type productCategory struct {
+		productID int
+		category  models.Category
+	}
+
+	links, err := ScanRows(rows, func(row pgx.Row) (productCategory, error) {
+		var link productCategory
+		c := &link.category
+		err := row.Scan(&link.productID, &c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt, &c.Version)
+		return link, err
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan categories: %w", err)
 	}
 
-	return categories, nil
+	byProduct := make(map[int][]models.Category, len(productIDs))
+	for _, link := range links {
+		byProduct[link.productID] = append(byProduct[link.productID], link.category)
+	}
+
+	return byProduct, nil
 }
 
-// addProductCategories is a helper to add product-category relationships
+// addProductCategories is a helper to add product-category relationships.
+// Categories in the trash are treated as missing.
 func (db *DB) addProductCategories(ctx context.Context, tx pgx.Tx, productID int, categoryIDs []int) error {
 	for _, categoryID := range categoryIDs {
-		query := `INSERT INTO product_category (product_id, category_id) VALUES ($1, $2)`
-		_, err := tx.Exec(ctx, query, productID, categoryID)
+		query := `
+			INSERT INTO product_category (product_id, category_id)
+			SELECT $1, id FROM categories WHERE id = $2 AND deleted_at IS NULL
+		`
+		result, err := tx.Exec(ctx, query, productID, categoryID)
 		if err != nil {
-			return fmt.Errorf("failed to add product category: %w", err)
+			return translateError(err, "product category", "add")
+		}
+		if result.RowsAffected() == 0 {
+			return MissingCategory(categoryID)
 		}
 	}
 	return nil
 }
+
+// ListDeletedProducts returns a page of the products in the trash, most recently deleted first
+func (db *DB) ListDeletedProducts(ctx context.Context, pagination *PaginationParams) ([]models.Product, int, error) {
+	pagination.Validate()
+
+	total, err := db.CountRows(ctx, "SELECT COUNT(*) FROM products WHERE deleted_at IS NOT NULL")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted products: %w", err)
+	}
+
+	query := `
+		SELECT id, sku, name, description, price, currency, stock, created_at, updated_at, version, deleted_at
+		FROM products
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := db.conn(ctx).Query(ctx, query, pagination.Limit, pagination.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query deleted products: %w", err)
+	}
+
+	products, err := ScanRows(rows, func(row pgx.Row) (models.Product, error) {
+		var product models.Product
+		err := row.Scan(
+			&product.ID,
+			&product.SKU,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.Currency,
+			&product.Stock,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+			&product.Version,
+			&product.DeletedAt,
+		)
+		return product, err
+	})
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan deleted products: %w", err)
+	}
+
+	if err := db.loadProductCategories(ctx, products); err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+// RestoreProduct takes a product out of the trash with its links and history
+func (db *DB) RestoreProduct(ctx context.Context, id int) (*models.Product, error) {
+	query := `UPDATE products SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := db.conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return nil, translateError(err, "deleted product", "restore")
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, NotFound("deleted product")
+	}
+
+	return db.GetProductByID(ctx, id)
+}
+
+// PurgeProducts permanently deletes trashed products; links and history cascade
+func (db *DB) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `DELETE FROM products WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	result, err := db.conn(ctx).Exec(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, translateError(err, "product", "purge")
+	}
+
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,118 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) {
+	testharness.Main(m)
+}
+
+// queryCounter counts the statements sent to Postgres
+type queryCounter struct {
+	n atomic.Int64
+}
+
+func (q *queryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	q.n.Add(1)
+	return ctx
+}
+
+func (q *queryCounter) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+// BenchmarkListProductsCategories compares loading the categories of a
+// 100 product page with one query per product against a single batched
+// query. The page itself is read once, outside the timer. It counts
+// PostgreSQL queries, so it needs a live server and does not run in CI.
+//
+//	go test ./internal/db -run '^$' -bench ListProductsCategories
+func BenchmarkListProductsCategories(b *testing.B) {
+	if os.Getenv(testharness.BackendEnv) == testharness.BackendMemory {
+		b.Skip("skipping: this benchmark counts PostgreSQL queries and cannot run on the in-memory store")
+	}
+	h := testharness.NewPostgres(b)
+	ctx := context.Background()
+
+	price := money.MustParse("9.99")
+	for i := 0; i < 100; i++ {
+		_, err := h.Store.CreateProduct(ctx, &models.ProductCreateRequest{
+			Name:        fmt.Sprintf("Benchmark Product %d", i),
+			Price:       &price,
+			Stock:       1,
+			CategoryIDs: []int{1 + i%8, 1 + (i+1)%8},
+		})
+		if err != nil {
+			b.Fatalf("failed to create product: %v", err)
+		}
+	}
+
+	counter := &queryCounter{}
+	poolConfig := h.Pool.Config()
+	poolConfig.ConnConfig.Tracer = counter
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		b.Fatalf("failed to open traced pool: %v", err)
+	}
+	defer pool.Close()
+	store := db.NewDB(pool)
+
+	page, _, err := store.ListProducts(ctx, &db.PaginationParams{Page: 1, Limit: 100}, &db.FilterParams{})
+	if err != nil {
+		b.Fatalf("failed to list products: %v", err)
+	}
+	if len(page) != 100 {
+		b.Fatalf("unexpected page: %d products", len(page))
+	}
+	products := make([]models.Product, len(page))
+
+	run := func(b *testing.B, load func(b *testing.B)) {
+		counter.n.Store(0)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			copy(products, page)
+			for j := range products {
+				products[j].Categories = nil
+			}
+			b.StartTimer()
+
+			load(b)
+		}
+		b.StopTimer()
+		if len(products[0].Categories) == 0 {
+			b.Fatal("categories were not loaded")
+		}
+		b.ReportMetric(float64(counter.n.Load())/float64(b.N), "queries/op")
+	}
+
+	b.Run("per_product", func(b *testing.B) {
+		run(b, func(b *testing.B) {
+			for i := range products {
+				categories, err := store.GetProductCategories(ctx, products[i].ID)
+				if err != nil {
+					b.Fatalf("failed to load categories: %v", err)
+				}
+				products[i].Categories = categories
+			}
+		})
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		run(b, func(b *testing.B) {
+			if err := store.LoadProductCategories(ctx, products); err != nil {
+				b.Fatalf("failed to load categories: %v", err)
+			}
+		})
+	})
+}
@@ -3,8 +3,11 @@ package db
 import (
 	"context"
 	"fmt"
-	"strings"
+	"slices"
+	"time"
 
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -18,9 +21,43 @@ func NewDB(pool *pgxpool.Pool) *DB {
 	return &DB{Pool: pool}
 }
 
+// TableVersion summarizes a table for ProductListVersion and
+// CategoryListVersion: its row count, the sum of its row versions and its
+// highest ID
+type TableVersion struct {
+	Rows     int64
+	Versions int64
+	LastID   int64
+}
+
+func (v TableVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Rows, v.Versions, v.LastID)
+}
+
+// Page size limits applied by PaginationParams.Validate when the params
+// do not carry their own
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
 type PaginationParams struct {
 	Page  int
 	Limit int
+	// MaxLimit caps Limit; 0 uses the package MaxLimit. The HTTP layer sets
+	// it from pagination.max_limit.
+	MaxLimit int
+
+	// Keyset switches to cursor pagination: rows are read after (or, for a
+	// backward cursor, before) Cursor instead of at an offset, and Page is
+	// ignored. A nil Cursor reads the first page.
+	Keyset    bool
+	Cursor    *Cursor
+	SkipTotal bool // Keyset reads only count the matching rows when this is false
+
+	// Set by keyset reads; empty when there is no page in that direction
+	NextCursor string
+	PrevCursor string
 }
 
 func (p *PaginationParams) Offset() int {
@@ -36,44 +73,55 @@ func (p *PaginationParams) Validate() {
 		p.Page = 1
 	}
 	if p.Limit <= 0 {
-		p.Limit = 10
+		p.Limit = DefaultLimit
 	}
-	if p.Limit > 100 {
-		p.Limit = 100 // Max limit
+	maxLimit := p.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = MaxLimit
+	}
+	if p.Limit > maxLimit {
+		p.Limit = maxLimit
 	}
 }
 
 type FilterParams struct {
-	SortBy     string // Field to sort by
-	SortOrder  string // "asc" or "desc"
-	Search     string // Search term
-	CategoryID *int   // Filter by category ID (optional)
+	SortBy    string     // Field to sort by
+	SortOrder string     // "asc" or "desc"
+	Sort      []SortTerm // Several sort keys; takes precedence over SortBy/SortOrder
+	Search    string     // Search term
+
+	// Product filters; nil or empty means no restriction. Bounds are inclusive.
+	IDs           []int  // Products with these IDs
+	CategoryIDs   []int  // Products in these (live) categories
+	CategoryMatch string // CategoryMatchAny or CategoryMatchAll
+	MinPrice      *money.Amount
+	MaxPrice      *money.Amount
+	InStock       *bool // true: stock > 0, false: stock = 0
+	MinStock      *int
+	MaxStock      *int
+	CreatedAfter  *time.Time
+	UpdatedAfter  *time.Time
+	Expr          filterexpr.Expr // Parsed ?filter= expression over ProductFilterFields
+
+	Fields Fieldset // Fields to read; nil reads all of them
 }
 
+// How CategoryIDs combine
+const (
+	CategoryMatchAny = "any" // In at least one of the categories
+	CategoryMatchAll = "all" // In every category
+)
+
 func (f *FilterParams) Validate() {
 	if f.SortOrder != "asc" && f.SortOrder != "desc" {
 		f.SortOrder = "asc"
 	}
-}
-
-// BuildOrderByClause builds an ORDER BY SQL clause
-func (f *FilterParams) BuildOrderByClause(allowedFields map[string]string) string {
-	if f.SortBy == "" {
-		return ""
-	}
-
-	// Check if the field is allowed
-	dbField, ok := allowedFields[f.SortBy]
-	if !ok {
-		return ""
+	if f.CategoryMatch != CategoryMatchAll {
+		f.CategoryMatch = CategoryMatchAny
 	}
-
-	order := "ASC"
-	if strings.ToLower(f.SortOrder) == "desc" {
-		order = "DESC"
+	if len(f.CategoryIDs) > 0 {
+		f.CategoryIDs = slices.Compact(slices.Sorted(slices.Values(f.CategoryIDs)))
 	}
-
-	return fmt.Sprintf("ORDER BY %s %s", dbField, order)
 }
 
 func ScanRow[T any](row pgx.Row, dest *T, scanFunc func(pgx.Row, *T) error) error {
@@ -101,10 +149,29 @@ func ScanRows[T any](rows pgx.Rows, scanFunc func(pgx.Row) (T, error)) ([]T, err
 
 func (db *DB) CountRows(ctx context.Context, query string, args ...interface{}) (int, error) {
 	var count int
-	err := db.QueryRow(ctx, query, args...).Scan(&count)
+	err := db.conn(ctx).QueryRow(ctx, query, args...).Scan(&count)
 	return count, err
 }
 
+// missingOrStale explains why a conditional write on a live row matched
+// nothing: the row is gone (ErrNotFound) or its version changed (ErrPreconditionFailed)
+func (db *DB) missingOrStale(ctx context.Context, table, resource string, id int, ifVersions []int) error {
+	if ifVersions == nil {
+		return NotFound(resource)
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)", table)
+	if err := db.conn(ctx).QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check %s: %w", resource, err)
+	}
+
+	if exists {
+		return fmt.Errorf("%s %w", resource, ErrPreconditionFailed)
+	}
+	return NotFound(resource)
+}
+
 func CalculateTotalPages(total, limit int) int {
 	if limit == 0 {
 		return 0
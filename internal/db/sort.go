@@ -0,0 +1,159 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortTerm is one key of a requested sort
+type SortTerm struct {
+	Field string
+	Desc  bool
+}
+
+// SortTerms returns the requested sort: Sort when set, otherwise the single
+// SortBy/SortOrder pair
+func (f *FilterParams) SortTerms() []SortTerm {
+	if len(f.Sort) > 0 {
+		return f.Sort
+	}
+	if f.SortBy == "" {
+		return nil
+	}
+	return []SortTerm{{Field: f.SortBy, Desc: strings.ToLower(f.SortOrder) == "desc"}}
+}
+
+// SortKey is one ORDER BY term of a listing
+type SortKey struct {
+	Field  string // Name used in sort parameters and in cursors
+	Column string // SQL expression
+	Type   string // One of the Sort* value types
+	Desc   bool
+}
+
+// sortField is a column a listing may be sorted by
+type sortField struct {
+	column string
+	typ    string
+}
+
+// Fields products and categories may be sorted by
+var (
+	productSortFields = map[string]sortField{
+		"name":       {"p.name", SortText},
+		"price":      {"p.price", SortNumeric},
+		"stock":      {"p.stock", SortInt},
+		"created_at": {"p.created_at", SortTimestamp},
+	}
+	categorySortFields = map[string]sortField{
+		"name":       {"name", SortText},
+		"created_at": {"created_at", SortTimestamp},
+	}
+)
+
+// ParseProductSort parses a ?sort= value for products, e.g. "price:desc,name"
+func ParseProductSort(spec string) ([]SortTerm, error) {
+	return parseSort(spec, productSortFields)
+}
+
+// ParseCategorySort parses a ?sort= value for categories
+func ParseCategorySort(spec string) ([]SortTerm, error) {
+	return parseSort(spec, categorySortFields)
+}
+
+// parseSort parses comma-separated field[:asc|desc] terms, accepting only
+// the given fields, each at most once. The order defaults to asc.
+func parseSort(spec string, fields map[string]sortField) ([]SortTerm, error) {
+	var terms []SortTerm
+	for _, part := range strings.Split(spec, ",") {
+		name, order, _ := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.ToLower(name)
+
+		if _, ok := fields[name]; !ok {
+			allowed := make([]string, 0, len(fields))
+			for field := range fields {
+				allowed = append(allowed, field)
+			}
+			slices.Sort(allowed)
+			return nil, fmt.Errorf("unknown field %q (allowed: %s)", name, strings.Join(allowed, ", "))
+		}
+		if slices.ContainsFunc(terms, func(t SortTerm) bool { return t.Field == name }) {
+			return nil, fmt.Errorf("field %q appears more than once", name)
+		}
+
+		switch strings.ToLower(order) {
+		case "", "asc":
+			terms = append(terms, SortTerm{Field: name})
+		case "desc":
+			terms = append(terms, SortTerm{Field: name, Desc: true})
+		default:
+			return nil, fmt.Errorf("order of %q must be asc or desc", name)
+		}
+	}
+	return terms, nil
+}
+
+// sortKeys resolves the requested terms against fields, falling back to def
+// when none is allowed, and appends the id so that the order is total
+func sortKeys(filter *FilterParams, fields map[string]sortField, def []SortKey, idColumn string) []SortKey {
+	var keys []SortKey
+	for _, term := range filter.SortTerms() {
+		field, ok := fields[term.Field]
+		if !ok || slices.ContainsFunc(keys, func(k SortKey) bool { return k.Field == term.Field }) {
+			continue
+		}
+		keys = append(keys, SortKey{Field: term.Field, Column: field.column, Type: field.typ, Desc: term.Desc})
+	}
+	if len(keys) == 0 {
+		keys = slices.Clone(def)
+	}
+	return append(keys, SortKey{Field: "id", Column: idColumn, Type: SortInt})
+}
+
+// ProductSortKeys resolves the product ordering for filter: the requested
+// fields (created_at DESC when none is allowed) with the id as tiebreaker
+func ProductSortKeys(filter *FilterParams) []SortKey {
+	return sortKeys(filter, productSortFields, []SortKey{
+		{Field: "created_at", Column: "p.created_at", Type: SortTimestamp, Desc: true},
+	}, "p.id")
+}
+
+// CategorySearchSortKeys resolves the category search ordering for filter
+// (name when none is allowed) with the id as tiebreaker
+func CategorySearchSortKeys(filter *FilterParams) []SortKey {
+	return sortKeys(filter, categorySortFields, []SortKey{
+		{Field: "name", Column: "name", Type: SortText},
+	}, "id")
+}
+
+// CategorySortKeys is the category listing order: name, then id
+var CategorySortKeys = []SortKey{
+	{Field: "name", Column: "name", Type: SortText},
+	{Field: "id", Column: "id", Type: SortInt},
+}
+
+// HistorySortKeys is the product history order: newest first, then latest id
+var HistorySortKeys = []SortKey{
+	{Field: "changed_at", Column: "changed_at", Type: SortTimestamp, Desc: true},
+	{Field: "id", Column: "id", Type: SortInt, Desc: true},
+}
+
+// StockMovementSortKeys orders the stock ledger of a product newest first
+var StockMovementSortKeys = []SortKey{
+	{Field: "created_at", Column: "created_at", Type: SortTimestamp, Desc: true},
+	{Field: "id", Column: "id", Type: SortInt, Desc: true},
+}
+
+// keysetOrderBy builds the ORDER BY clause for keys, reversed when reading backward
+func keysetOrderBy(keys []SortKey, backward bool) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		order := "ASC"
+		if key.Desc != backward {
+			order = "DESC"
+		}
+		terms[i] = key.Column + " " + order
+	}
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
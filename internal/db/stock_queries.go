@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateStockMovement reads the stock with FOR UPDATE so concurrent
+// movements of a product apply one after the other; the UPDATE fires the
+// history trigger like any other stock change
+func (db *DB) CreateStockMovement(ctx context.Context, productID int, userID *int, req *models.StockMovementCreateRequest) (*models.StockMovement, error) {
+	tx, err := db.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var stock int
+	err = tx.QueryRow(ctx, `SELECT stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, productID).Scan(&stock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NotFound("product")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	stockAfter := stock + req.Quantity
+	if stockAfter < 0 {
+		return nil, InsufficientStock(stock, req.Quantity)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE products
+		SET stock = $1, updated_at = NOW(), version = version + 1
+		WHERE id = $2
+	`, stockAfter, productID)
+	if err != nil {
+		return nil, translateError(err, "product", "update")
+	}
+
+	query := `
+		INSERT INTO stock_movements (product_id, quantity, reason, reference, user_id, stock_after)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, product_id, quantity, reason, reference, user_id, stock_after, created_at
+	`
+	movement, err := scanStockMovement(tx.QueryRow(ctx, query, productID, req.Quantity, req.Reason, req.Reference, userID, stockAfter))
+	if err != nil {
+		return nil, translateError(err, "stock movement", "create")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &movement, nil
+}
+
+// lockStocks locks the given live products and returns their current stock,
+// for recordStockAdjustments to compare against once they are written
+func lockStocks(ctx context.Context, tx pgx.Tx, ids []int) (map[int]int, error) {
+	rows, err := tx.Query(ctx, `SELECT id, stock FROM products WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock products: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := make(map[int]int, len(ids))
+	for rows.Next() {
+		var id, stock int
+		if err := rows.Scan(&id, &stock); err != nil {
+			return nil, fmt.Errorf("failed to scan product stock: %w", err)
+		}
+		stocks[id] = stock
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan product stock: %w", err)
+	}
+	return stocks, nil
+}
+
+// recordStockAdjustments writes an adjustment to the ledger of each product
+// whose stock no longer matches the one lockStocks read, so stock set by
+// a product write is accounted for like a movement
+func recordStockAdjustments(ctx context.Context, tx pgx.Tx, before map[int]int, userID *int) error {
+	ids := make([]int, 0, len(before))
+	stocks := make([]int, 0, len(before))
+	for id, stock := range before {
+		ids, stocks = append(ids, id), append(stocks, stock)
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO stock_movements (product_id, quantity, reason, user_id, stock_after)
+		SELECT p.id, p.stock - b.stock, $3, $4, p.stock
+		FROM unnest($1::int[], $2::int[]) AS b(id, stock)
+		JOIN products p ON p.id = b.id
+		WHERE p.stock <> b.stock
+		ORDER BY p.id
+	`, ids, stocks, models.StockReasonAdjustment, userID)
+	if err != nil {
+		return translateError(err, "stock movement", "create")
+	}
+	return nil
+}
+
+func (db *DB) ListStockMovements(ctx context.Context, productID int, reason string, pagination *PaginationParams) ([]models.StockMovement, int, error) {
+	pagination.Validate()
+
+	whereClause := "WHERE product_id = $1 AND ($2 = '' OR reason = $2)"
+	args := []interface{}{productID, reason}
+
+	total := 0
+	if !pagination.Keyset || !pagination.SkipTotal {
+		var err error
+		total, err = db.CountRows(ctx, "SELECT COUNT(*) FROM stock_movements "+whereClause, args...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+		}
+	}
+
+	condition, orderByClause, pageClause, pageArgs, err := pageClauses(StockMovementSortKeys, pagination, len(args))
+	if err != nil {
+		return nil, 0, err
+	}
+	if condition != "" {
+		whereClause += " AND " + condition
+	}
+	args = append(args, pageArgs...)
+
+	query := fmt.Sprintf(`
+		SELECT id, product_id, quantity, reason, reference, user_id, stock_after, created_at
+		FROM stock_movements
+		%s
+		%s
+		%s
+	`, whereClause, orderByClause, pageClause)
+
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query stock movements: %w", err)
+	}
+
+	movements, err := ScanRows(rows, scanStockMovement)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan stock movements: %w", err)
+	}
+
+	if pagination.Keyset {
+		movements = FinishKeysetPage(pagination, StockMovementSortKeys, movements, StockMovementSortValue)
+	}
+	return movements, total, nil
+}
+
+func scanStockMovement(row pgx.Row) (models.StockMovement, error) {
+	var m models.StockMovement
+	err := row.Scan(&m.ID, &m.ProductID, &m.Quantity, &m.Reason, &m.Reference, &m.UserID, &m.StockAfter, &m.CreatedAt)
+	return m, err
+}
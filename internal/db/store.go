@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// ProductStore persists products, their category links and history.
+// Writes taking ifVersions only apply when the row version is one of them
+// (If-Match) and fail with ErrPreconditionFailed otherwise; nil means unconditional.
+type ProductStore interface {
+	CreateProduct(ctx context.Context, req *models.ProductCreateRequest) (*models.Product, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+	// GetProductsByIDs returns the live products among ids in a single
+	// query; ids without one are left out
+	GetProductsByIDs(ctx context.Context, ids []int, fields Fieldset) ([]models.Product, error)
+	ListProducts(ctx context.Context, pagination *PaginationParams, filter *FilterParams) ([]models.Product, int, error)
+	// ProductListVersion returns a value that changes whenever a product or
+	// category is written, trashed, restored or purged, so a listing can be
+	// revalidated without reading it
+	ProductListVersion(ctx context.Context) (string, error)
+	// UpdateProduct applies req to a live product. A stock change is also
+	// written to the stock ledger as an adjustment by userID.
+	UpdateProduct(ctx context.Context, id int, userID *int, req *models.ProductUpdateRequest, ifVersions []int) (*models.Product, error)
+	// DeleteProduct moves the product to the trash; links and history are kept
+	DeleteProduct(ctx context.Context, id int, ifVersions []int) error
+	ListDeletedProducts(ctx context.Context, pagination *PaginationParams) ([]models.Product, int, error)
+	RestoreProduct(ctx context.Context, id int) (*models.Product, error)
+	// PurgeProducts permanently removes products deleted before the given time
+	PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error)
+	// GetProductHistory lists changes newest first; a nil pagination returns
+	// all of them and nil fields read every field
+	GetProductHistory(ctx context.Context, productID int, start, end *time.Time, pagination *PaginationParams, fields Fieldset) ([]models.ProductHistory, int, error)
+	GetProductCategories(ctx context.Context, productID int) ([]models.Category, error)
+	// ExportProducts calls fn with every product ListProducts would return
+	// for filter, in the same order and with their categories (unless
+	// filter.Fields leaves them out), without loading them all into memory.
+	// An error from fn stops the export and is returned.
+	ExportProducts(ctx context.Context, filter *FilterParams, fn func(*models.Product) error) error
+	// ExportProductHistory streams the changes of a product like ExportProducts
+	ExportProductHistory(ctx context.Context, productID int, start, end *time.Time, fn func(*models.ProductHistory) error) error
+	// LockProducts returns the IDs of at most limit live products matching
+	// filter in ascending order, locking their rows until the transaction ends
+	LockProducts(ctx context.Context, filter *FilterParams, limit int) ([]int, error)
+	// BulkUpdateProducts applies the changes of req (not its selection) to
+	// the given live products; it fails as a whole when one of them cannot
+	// take them, e.g. a stock that would drop below zero. Stock changes are
+	// written to the ledger like in UpdateProduct.
+	BulkUpdateProducts(ctx context.Context, ids []int, userID *int, req *models.ProductBulkUpdateRequest) error
+	// BulkDeleteProducts moves the given live products to the trash
+	BulkDeleteProducts(ctx context.Context, ids []int) error
+	// ProductIDsBySKU maps the given SKUs to the live products holding them;
+	// unknown SKUs are left out
+	ProductIDsBySKU(ctx context.Context, skus []string) (map[string]int, error)
+}
+
+// CategoryStore persists categories; ifVersions works as in ProductStore
+type CategoryStore interface {
+	CreateCategory(ctx context.Context, req *models.CategoryCreateRequest) (*models.Category, error)
+	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	// ListCategories lists categories by name; a nil pagination returns all
+	// of them and nil fields read every field
+	ListCategories(ctx context.Context, search string, pagination *PaginationParams, fields Fieldset) ([]models.Category, int, error)
+	// CategoryListVersion works as ProductListVersion for category listings
+	CategoryListVersion(ctx context.Context) (string, error)
+	UpdateCategory(ctx context.Context, id int, req *models.CategoryUpdateRequest, ifVersions []int) (*models.Category, error)
+	// DeleteCategory moves the category to the trash; product links are kept
+	DeleteCategory(ctx context.Context, id int, ifVersions []int) error
+	ListDeletedCategories(ctx context.Context) ([]models.Category, error)
+	RestoreCategory(ctx context.Context, id int) (*models.Category, error)
+	// PurgeCategories permanently removes categories deleted before the given time
+	PurgeCategories(ctx context.Context, deletedBefore time.Time) (int, error)
+	SearchCategories(ctx context.Context, searchTerm string, pagination *PaginationParams, filter *FilterParams) ([]models.Category, int, error)
+	// ExportCategories streams the categories ListCategories would return
+	ExportCategories(ctx context.Context, search string, fn func(*models.Category) error) error
+}
+
+// UserStore persists users and roles
+type UserStore interface {
+	CreateUser(ctx context.Context, email, passwordHash string, roleID *int) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetRoleByName(ctx context.Context, name string) (*models.Role, error)
+	CreateRole(ctx context.Context, name string) (*models.Role, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+}
+
+// ExchangeRateStore persists exchange rates
+type ExchangeRateStore interface {
+	CreateExchangeRate(ctx context.Context, req *models.ExchangeRateCreateRequest) (*models.ExchangeRate, error)
+	ListExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, id int) error
+	// GetEffectiveRate returns the latest base→quote rate effective at the given time
+	GetEffectiveRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error)
+}
+
+// StockMovementStore persists the stock ledger of products
+type StockMovementStore interface {
+	// CreateStockMovement locks the live product, adds the quantity of req to
+	// its stock and records the movement. A stock that would drop below zero
+	// fails with ErrConflict and changes nothing.
+	CreateStockMovement(ctx context.Context, productID int, userID *int, req *models.StockMovementCreateRequest) (*models.StockMovement, error)
+	// ListStockMovements lists the movements of a product newest first,
+	// optionally only those with the given reason
+	ListStockMovements(ctx context.Context, productID int, reason string, pagination *PaginationParams) ([]models.StockMovement, int, error)
+}
+
+// Store groups every repository; *DB is the PostgreSQL implementation
+// and memory.Store the in-memory one used for tests and demo mode
+type Store interface {
+	ProductStore
+	CategoryStore
+	UserStore
+	ExchangeRateStore
+	StockMovementStore
+	Transactor
+}
+
+var _ Store = (*DB)(nil)
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
-	"github.com/jackc/pgx/v5"
 )
 
 func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, roleID *int) (*models.User, error) {
@@ -16,7 +15,7 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, roleID
 	`
 
 	var user models.User
-	err := db.QueryRow(ctx, query, email, passwordHash, roleID).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, email, passwordHash, roleID).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -25,7 +24,7 @@ func (db *DB) CreateUser(ctx context.Context, email, passwordHash string, roleID
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, translateError(err, "user", "create")
 	}
 
 	return &user, nil
@@ -45,7 +44,7 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 	var roleID *int
 	var roleName *string
 
-	err := db.QueryRow(ctx, query, email).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -56,10 +55,7 @@ func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, e
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, translateError(err, "user", "get")
 	}
 
 	// Set role if it exists
@@ -86,7 +82,7 @@ func (db *DB) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	var roleID *int
 	var roleName *string
 
-	err := db.QueryRow(ctx, query, id).Scan(
+	err := db.conn(ctx).QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -97,10 +93,7 @@ func (db *DB) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, translateError(err, "user", "get")
 	}
 
 	// Set role if it exists
@@ -117,13 +110,10 @@ func (db *DB) GetRoleByName(ctx context.Context, name string) (*models.Role, err
 	query := `SELECT id, name FROM roles WHERE name = $1`
 
 	var role models.Role
-	err := db.QueryRow(ctx, query, name).Scan(&role.ID, &role.Name)
+	err := db.conn(ctx).QueryRow(ctx, query, name).Scan(&role.ID, &role.Name)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("role not found")
-		}
-		return nil, fmt.Errorf("failed to get role: %w", err)
+		return nil, translateError(err, "role", "get")
 	}
 
 	return &role, nil
@@ -137,10 +127,10 @@ func (db *DB) CreateRole(ctx context.Context, name string) (*models.Role, error)
 	`
 
 	var role models.Role
-	err := db.QueryRow(ctx, query, name).Scan(&role.ID, &role.Name)
+	err := db.conn(ctx).QueryRow(ctx, query, name).Scan(&role.ID, &role.Name)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create role: %w", err)
+		return nil, translateError(err, "role", "create")
 	}
 
 	return &role, nil
@@ -150,7 +140,7 @@ func (db *DB) EmailExists(ctx context.Context, email string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 
 	var exists bool
-	err := db.QueryRow(ctx, query, email).Scan(&exists)
+	err := db.conn(ctx).QueryRow(ctx, query, email).Scan(&exists)
 
 	if err != nil {
 		return false, fmt.Errorf("failed to check email existence: %w", err)
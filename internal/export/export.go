@@ -0,0 +1,164 @@
+// Package export writes tables of values as CSV, NDJSON or XLSX, one row
+// at a time, so exports of any size stream straight to the client.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// Supported formats
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+	XLSX   = "xlsx"
+)
+
+// Formats lists the supported formats
+var Formats = []string{CSV, NDJSON, XLSX}
+
+// ListSeparator joins list values in CSV and XLSX cells; it is also the
+// separator product imports read categories with
+const ListSeparator = "|"
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Writer writes the rows of a table. Values may be nil, strings, ints,
+// money amounts, times, []string or pointers to those.
+type Writer interface {
+	// Write writes one row; values line up with the columns
+	Write(values []interface{}) error
+	// Close finishes the file; the output is incomplete until it is called
+	Close() error
+}
+
+// NewWriter starts a table with the given columns in w; priceFormat is the
+// money JSON format used by NDJSON rows
+func NewWriter(w io.Writer, format string, columns []string, priceFormat string) (Writer, error) {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case NDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w), columns: columns, priceFormat: priceFormat}, nil
+	case XLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	w.record = w.record[:0]
+	for _, value := range values {
+		text, _ := cell(value)
+		w.record = append(w.record, text)
+	}
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// ndjsonWriter writes each row as an object keyed by column
+type ndjsonWriter struct {
+	w           *bufio.Writer
+	columns     []string
+	priceFormat string
+}
+
+func (w *ndjsonWriter) Write(values []interface{}) error {
+	w.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(w.columns[i])
+		data, err := w.encode(value)
+		if err != nil {
+			return err
+		}
+		w.w.Write(key)
+		w.w.WriteByte(':')
+		w.w.Write(data)
+	}
+	w.w.WriteByte('}')
+	return w.w.WriteByte('\n')
+}
+
+// encode writes amounts in the writer's price format
+func (w *ndjsonWriter) encode(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case money.Amount:
+		return v.JSON(w.priceFormat), nil
+	case *money.Amount:
+		if v != nil {
+			return v.JSON(w.priceFormat), nil
+		}
+	}
+	return json.Marshal(value)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.w.Flush()
+}
+
+// cell formats a value as text, reporting whether it is a number
+func cell(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, false
+	case *string:
+		if v == nil {
+			return "", false
+		}
+		return *v, false
+	case int:
+		return strconv.Itoa(v), true
+	case money.Amount:
+		return v.String(), true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339), false
+	case *time.Time:
+		if v == nil {
+			return "", false
+		}
+		return v.UTC().Format(time.RFC3339), false
+	case []string:
+		return strings.Join(v, ListSeparator), false
+	default:
+		return fmt.Sprint(v), false
+	}
+}
@@ -0,0 +1,225 @@
+// Package filterexpr implements the small filter language accepted in
+// ?filter=, e.g. `price >= 100 AND stock < 5 AND category IN (1,3)`.
+//
+// Expressions are checked against a whitelist of fields, like the sortable
+// fields of each listing, and compile to SQL in which every value is a
+// bound argument. The in-memory store evaluates the same expressions with
+// Eval.
+//
+// Grammar (keywords are case-insensitive):
+//
+//	expr       = and { "OR" and }
+//	and        = unary { "AND" unary }
+//	unary      = "NOT" unary | "(" expr ")" | predicate
+//	predicate  = field op value
+//	           | field "CONTAINS" string
+//	           | field [ "NOT" ] "IN" "(" value { "," value } ")"
+//	op         = "=" | "!=" | "<>" | "<" | "<=" | ">" | ">="
+//	value      = number | 'string' | "string"
+package filterexpr
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// Limits that keep expressions cheap to parse and to run
+const (
+	MaxLength = 1000 // Characters
+	maxTerms  = 50   // Predicates
+	maxDepth  = 20   // Nested parentheses
+)
+
+// Type is the type of a field's values
+type Type int
+
+const (
+	Number     Type = iota // money.Amount
+	Int                    // int
+	String                 // string; only =, !=, CONTAINS and IN
+	Time                   // time.Time, written as a quoted RFC3339 date
+	Membership             // A set of ints, e.g. the categories of a product; only =, !=, IN
+)
+
+// Field is a field expressions may refer to
+type Field struct {
+	// Column is the SQL expression of the field. For Membership fields it is
+	// a subquery returning the members of the current row.
+	Column string
+	Type   Type
+}
+
+// Fields whitelists the fields of a resource by name
+type Fields map[string]Field
+
+// Expr is a parsed expression
+type Expr interface {
+	sql(arg func(interface{}) string) string
+	eval(get Getter) bool
+}
+
+// Getter returns the value of a field for the row being evaluated, with the
+// Go type listed for its Type ([]int for Membership)
+type Getter func(field string) interface{}
+
+// SyntaxError describes why an expression was rejected
+type SyntaxError struct {
+	Pos int // Byte offset in the expression
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// SQL compiles expr into a boolean SQL condition. arg binds a value and
+// returns its placeholder.
+func SQL(expr Expr, arg func(interface{}) string) string {
+	return expr.sql(arg)
+}
+
+// Eval reports whether the row described by get matches expr
+func Eval(expr Expr, get Getter) bool {
+	return expr.eval(get)
+}
+
+// logical is an AND or OR of two expressions
+type logical struct {
+	op          string
+	left, right Expr
+}
+
+func (e *logical) sql(arg func(interface{}) string) string {
+	return "(" + e.left.sql(arg) + " " + e.op + " " + e.right.sql(arg) + ")"
+}
+
+func (e *logical) eval(get Getter) bool {
+	if e.op == "AND" {
+		return e.left.eval(get) && e.right.eval(get)
+	}
+	return e.left.eval(get) || e.right.eval(get)
+}
+
+type not struct {
+	x Expr
+}
+
+func (e *not) sql(arg func(interface{}) string) string {
+	return "(NOT " + e.x.sql(arg) + ")"
+}
+
+func (e *not) eval(get Getter) bool {
+	return !e.x.eval(get)
+}
+
+// comparison is `field op value`, op being a comparison operator or CONTAINS
+type comparison struct {
+	name  string
+	field Field
+	op    string
+	value interface{}
+}
+
+var sqlOps = map[string]string{"=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+func (e *comparison) sql(arg func(interface{}) string) string {
+	switch {
+	case e.field.Type == Membership:
+		member := arg(e.value) + " IN (" + e.field.Column + ")"
+		if e.op == "!=" {
+			return "(NOT " + member + ")"
+		}
+		return member
+	case e.op == "CONTAINS":
+		return e.field.Column + " ILIKE " + arg("%"+escapeLike(e.value.(string))+"%")
+	default:
+		return e.field.Column + " " + sqlOps[e.op] + " " + arg(e.value)
+	}
+}
+
+func (e *comparison) eval(get Getter) bool {
+	actual := get(e.name)
+	switch {
+	case e.field.Type == Membership:
+		return slices.Contains(actual.([]int), e.value.(int)) == (e.op == "=")
+	case e.op == "CONTAINS":
+		return strings.Contains(strings.ToLower(actual.(string)), strings.ToLower(e.value.(string)))
+	}
+
+	c := compare(e.field.Type, actual, e.value)
+	switch e.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// in is `field [NOT] IN (values)`
+type in struct {
+	name    string
+	field   Field
+	values  []interface{}
+	negated bool
+}
+
+func (e *in) sql(arg func(interface{}) string) string {
+	placeholders := make([]string, len(e.values))
+	for i, v := range e.values {
+		placeholders[i] = arg(v)
+	}
+	list := "(" + strings.Join(placeholders, ", ") + ")"
+
+	var cond string
+	if e.field.Type == Membership {
+		cond = "EXISTS (SELECT 1 FROM (" + e.field.Column + ") AS m(v) WHERE m.v IN " + list + ")"
+	} else {
+		cond = e.field.Column + " IN " + list
+	}
+	if e.negated {
+		return "(NOT " + cond + ")"
+	}
+	return cond
+}
+
+func (e *in) eval(get Getter) bool {
+	actual := get(e.name)
+	found := slices.ContainsFunc(e.values, func(v interface{}) bool {
+		if e.field.Type == Membership {
+			return slices.Contains(actual.([]int), v.(int))
+		}
+		return compare(e.field.Type, actual, v) == 0
+	})
+	return found != e.negated
+}
+
+func compare(t Type, a, b interface{}) int {
+	switch t {
+	case Number:
+		return a.(money.Amount).Cmp(b.(money.Amount))
+	case Int:
+		return cmp.Compare(a.(int), b.(int))
+	case Time:
+		return a.(time.Time).Compare(b.(time.Time))
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
+
+// escapeLike escapes the ILIKE wildcards so CONTAINS matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,393 @@
+package filterexpr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestMain(m *testing.M) {
+	testharness.Main(m)
+}
+
+// fields mirrors the product filter, reading from a row aliased as r
+var fields = filterexpr.Fields{
+	"price":      {Column: "r.price", Type: filterexpr.Number},
+	"stock":      {Column: "r.stock", Type: filterexpr.Int},
+	"name":       {Column: "r.name", Type: filterexpr.String},
+	"created_at": {Column: "r.created_at", Type: filterexpr.Time},
+	"category":   {Column: "SELECT unnest(r.categories)", Type: filterexpr.Membership},
+}
+
+type row struct {
+	price      money.Amount
+	stock      int
+	name       string
+	createdAt  time.Time
+	categories []int
+}
+
+func (r row) get(field string) interface{} {
+	switch field {
+	case "price":
+		return r.price
+	case "stock":
+		return r.stock
+	case "name":
+		return r.name
+	case "created_at":
+		return r.createdAt
+	default:
+		return r.categories
+	}
+}
+
+var rows = []row{
+	{money.MustParse("10.50"), 5, "Laptop 50% off", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), []int{1, 2}},
+	{money.MustParse("3"), 0, "Mouse_pad", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), []int{2}},
+	{money.MustParse("99.99"), 40, "Keyboard", time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC), nil},
+	{money.MustParse("0.30"), 12, "O'Brien cable", time.Date(2024, 6, 15, 8, 30, 0, 0, time.UTC), []int{3}},
+}
+
+// render returns the SQL of expr with $n placeholders, numbered from first,
+// and the arguments bound to them
+func render(expr filterexpr.Expr, first int) (string, []interface{}) {
+	var args []interface{}
+	sql := filterexpr.SQL(expr, func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", first+len(args)-1)
+	})
+	return sql, args
+}
+
+// matches returns the indexes of the rows expr accepts
+func matches(expr filterexpr.Expr) []int {
+	var got []int
+	for i, r := range rows {
+		if filterexpr.Eval(expr, r.get) {
+			got = append(got, i)
+		}
+	}
+	return got
+}
+
+var expressions = []struct {
+	name    string
+	input   string
+	sql     string
+	args    []interface{}
+	matches []int
+}{
+	{
+		name:    "comparison",
+		input:   "price >= 10.5",
+		sql:     "r.price >= $1",
+		args:    []interface{}{money.MustParse("10.5")},
+		matches: []int{0, 2},
+	},
+	{
+		name:    "not equal is written <> in SQL",
+		input:   "stock != 0",
+		sql:     "r.stock <> $1",
+		args:    []interface{}{0},
+		matches: []int{0, 2, 3},
+	},
+	{
+		name:    "<> is accepted as !=",
+		input:   "stock <> 0",
+		sql:     "r.stock <> $1",
+		args:    []interface{}{0},
+		matches: []int{0, 2, 3},
+	},
+	{
+		name:    "AND binds tighter than OR",
+		input:   "stock = 0 OR stock > 10 AND price < 1",
+		sql:     "(r.stock = $1 OR (r.stock > $2 AND r.price < $3))",
+		args:    []interface{}{0, 10, money.MustParse("1")},
+		matches: []int{1, 3},
+	},
+	{
+		name:    "parentheses override precedence",
+		input:   "(stock = 0 OR stock > 10) AND price < 1",
+		sql:     "((r.stock = $1 OR r.stock > $2) AND r.price < $3)",
+		args:    []interface{}{0, 10, money.MustParse("1")},
+		matches: []int{3},
+	},
+	{
+		name:    "AND is left associative",
+		input:   "stock > 0 AND stock < 40 AND price > 1",
+		sql:     "((r.stock > $1 AND r.stock < $2) AND r.price > $3)",
+		args:    []interface{}{0, 40, money.MustParse("1")},
+		matches: []int{0},
+	},
+	{
+		name:    "NOT binds tighter than AND",
+		input:   "NOT stock = 0 AND price < 50",
+		sql:     "((NOT r.stock = $1) AND r.price < $2)",
+		args:    []interface{}{0, money.MustParse("50")},
+		matches: []int{0, 3},
+	},
+	{
+		name:    "NOT over parentheses",
+		input:   "NOT (stock = 0 OR price > 50)",
+		sql:     "(NOT (r.stock = $1 OR r.price > $2))",
+		args:    []interface{}{0, money.MustParse("50")},
+		matches: []int{0, 3},
+	},
+	{
+		name:    "keywords are case-insensitive",
+		input:   "stock in (0, 40) or not name contains 'o'",
+		sql:     "(r.stock IN ($1, $2) OR (NOT r.name ILIKE $3))",
+		args:    []interface{}{0, 40, "%o%"},
+		matches: []int{1, 2},
+	},
+	{
+		name:    "IN list",
+		input:   "stock IN (0, 12, 7)",
+		sql:     "r.stock IN ($1, $2, $3)",
+		args:    []interface{}{0, 12, 7},
+		matches: []int{1, 3},
+	},
+	{
+		name:    "NOT IN list",
+		input:   "name NOT IN ('Keyboard', 'Mouse_pad')",
+		sql:     "(NOT r.name IN ($1, $2))",
+		args:    []interface{}{"Keyboard", "Mouse_pad"},
+		matches: []int{0, 3},
+	},
+	{
+		name:    "IN with a single value",
+		input:   "price IN (3.00)",
+		sql:     "r.price IN ($1)",
+		args:    []interface{}{money.MustParse("3")},
+		matches: []int{1},
+	},
+	{
+		name:    "membership equality",
+		input:   "category = 2",
+		sql:     "$1 IN (SELECT unnest(r.categories))",
+		args:    []interface{}{2},
+		matches: []int{0, 1},
+	},
+	{
+		name:    "membership inequality",
+		input:   "category != 2",
+		sql:     "(NOT $1 IN (SELECT unnest(r.categories)))",
+		args:    []interface{}{2},
+		matches: []int{2, 3},
+	},
+	{
+		name:    "membership IN",
+		input:   "category IN (1, 3)",
+		sql:     "EXISTS (SELECT 1 FROM (SELECT unnest(r.categories)) AS m(v) WHERE m.v IN ($1, $2))",
+		args:    []interface{}{1, 3},
+		matches: []int{0, 3},
+	},
+	{
+		name:    "membership NOT IN",
+		input:   "category NOT IN (1, 3)",
+		sql:     "(NOT EXISTS (SELECT 1 FROM (SELECT unnest(r.categories)) AS m(v) WHERE m.v IN ($1, $2)))",
+		args:    []interface{}{1, 3},
+		matches: []int{1, 2},
+	},
+	{
+		name:    "CONTAINS is case-insensitive",
+		input:   "name CONTAINS 'KEY'",
+		sql:     "r.name ILIKE $1",
+		args:    []interface{}{"%KEY%"},
+		matches: []int{2},
+	},
+	{
+		name:    "CONTAINS matches % literally",
+		input:   "name CONTAINS '0%'",
+		sql:     "r.name ILIKE $1",
+		args:    []interface{}{`%0\%%`},
+		matches: []int{0},
+	},
+	{
+		name:    "CONTAINS matches _ literally",
+		input:   "name CONTAINS 'e_p'",
+		sql:     "r.name ILIKE $1",
+		args:    []interface{}{`%e\_p%`},
+		matches: []int{1},
+	},
+	{
+		name:    "CONTAINS escapes backslashes",
+		input:   `name CONTAINS 'a\b'`,
+		sql:     "r.name ILIKE $1",
+		args:    []interface{}{`%a\\b%`},
+		matches: nil,
+	},
+	{
+		name:    "doubled quotes inside strings",
+		input:   "name CONTAINS 'o''b'",
+		sql:     "r.name ILIKE $1",
+		args:    []interface{}{"%o'b%"},
+		matches: []int{3},
+	},
+	{
+		name:    "dates",
+		input:   "created_at >= '2024-01-10T00:00:00Z' AND created_at < '2024-06-15T08:30:00Z'",
+		sql:     "(r.created_at >= $1 AND r.created_at < $2)",
+		args:    []interface{}{time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 15, 8, 30, 0, 0, time.UTC)},
+		matches: []int{0, 1},
+	},
+	{
+		name:    "dates with an offset",
+		input:   "created_at = '2024-01-01T00:59:59+01:00'",
+		sql:     "r.created_at = $1",
+		args:    []interface{}{time.Date(2024, 1, 1, 0, 59, 59, 0, time.FixedZone("", 3600))},
+		matches: []int{2},
+	},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range expressions {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := filterexpr.Parse(tt.input, fields)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned %v", tt.input, err)
+			}
+
+			sql, args := render(expr, 1)
+			if sql != tt.sql {
+				t.Errorf("SQL = %q, want %q", sql, tt.sql)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+
+			if got := matches(expr); fmt.Sprint(got) != fmt.Sprint(tt.matches) {
+				t.Errorf("Eval matched rows %v, want %v", got, tt.matches)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+		msg   string
+	}{
+		{"empty", "", 0, "expected a field name"},
+		{"unknown field", "colour = 'red'", 0, `unknown field "colour" (allowed: category, created_at, name, price, stock)`},
+		{"missing operator", "price 10", 6, "expected"},
+		{"missing value", "price >", 7, "price expects a number"},
+		{"lone bang", "stock ! 1", 6, "!"},
+		{"number for a string", "name = 5", 7, "name expects a quoted string"},
+		{"string for a number", "price = '5'", 8, "price expects a number"},
+		{"decimal for an integer", "stock = 1.5", 8, "stock expects an integer"},
+		{"date that is not RFC3339", "created_at > '2024-01-01'", 13, "created_at expects a quoted RFC3339 date"},
+		{"CONTAINS on a number", "price CONTAINS '1'", 6, "CONTAINS only applies to text fields"},
+		{"ordering on membership", "category > 1", 9, "category only supports =, != and IN"},
+		{"unterminated string", "name = 'abc", 7, "unterminated string"},
+		{"trailing condition", "stock = 1 stock = 2", 10, "expected AND, OR or end of filter"},
+		{"dangling AND", "stock = 1 AND", 13, "expected a field name"},
+		{"unclosed parenthesis", "(stock = 1", 10, ")"},
+		{"unopened parenthesis", "stock = 1)", 9, "expected AND, OR or end of filter"},
+		{"empty IN list", "stock IN ()", 10, "stock expects an integer"},
+		{"IN list without commas", "stock IN (1 2)", 12, `expected "," or ")"`},
+		{"IN without parentheses", "stock IN 1", 9, "("},
+		{"keyword as field", "AND = 1", 0, "expected a field name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filterexpr.Parse(tt.input, fields)
+			var syntaxErr *filterexpr.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) returned %v, want a *SyntaxError", tt.input, err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Pos = %d, want %d (%v)", syntaxErr.Pos, tt.pos, err)
+			}
+			if !strings.Contains(syntaxErr.Msg, tt.msg) {
+				t.Errorf("Msg = %q, want it to contain %q", syntaxErr.Msg, tt.msg)
+			}
+			if want := fmt.Sprintf("at position %d: ", tt.pos+1); !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("Error() = %q, want prefix %q", err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	conditions := func(n int) string {
+		return strings.Repeat("stock = 1 OR ", n-1) + "stock = 1"
+	}
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "stock = 1" + strings.Repeat(")", depth)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		msg   string
+	}{
+		{"longest filter", "name = '" + strings.Repeat("a", filterexpr.MaxLength-9) + "'", ""},
+		{"too long", "name = '" + strings.Repeat("a", filterexpr.MaxLength-8) + "'", fmt.Sprintf("filter is longer than %d characters", filterexpr.MaxLength)},
+		{"most conditions", conditions(50), ""},
+		{"too many conditions", conditions(51), "filter has more than 50 conditions"},
+		{"deepest nesting", nested(20), ""},
+		{"nested too deep", nested(21), "parentheses are nested more than 20 levels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filterexpr.Parse(tt.input, fields)
+			if tt.msg == "" {
+				if err != nil {
+					t.Fatalf("Parse returned %v, want no error", err)
+				}
+				return
+			}
+			var syntaxErr *filterexpr.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse returned %v, want a *SyntaxError", err)
+			}
+			if !strings.Contains(syntaxErr.Msg, tt.msg) {
+				t.Errorf("Msg = %q, want it to contain %q", syntaxErr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+// TestSQLMatchesEval runs every expression through Postgres against the
+// sample rows and checks it selects the same rows Eval does
+func TestSQLMatchesEval(t *testing.T) {
+	h := testharness.NewPostgres(t)
+	ctx := context.Background()
+
+	const from = ` FROM (SELECT $1::numeric AS price, $2::int AS stock, $3::text AS name,
+		$4::timestamptz AS created_at, $5::int[] AS categories) AS r`
+
+	for _, tt := range expressions {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := filterexpr.Parse(tt.input, fields)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned %v", tt.input, err)
+			}
+			sql, args := render(expr, 6)
+
+			for i, r := range rows {
+				bound := append([]interface{}{r.price, r.stock, r.name, r.createdAt, r.categories}, args...)
+				var got bool
+				if err := h.Pool.QueryRow(ctx, "SELECT "+sql+from, bound...).Scan(&got); err != nil {
+					t.Fatalf("query %q failed: %v", sql, err)
+				}
+				if want := filterexpr.Eval(expr, r.get); got != want {
+					t.Errorf("row %d: SQL selected it = %t, Eval = %t", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package filterexpr
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // Unquoted for strings
+	pos  int
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case strings.ContainsRune("=!<>", rune(c)):
+			op := string(c)
+			if i+1 < len(s) && (s[i:i+2] == "!=" || s[i:i+2] == "<=" || s[i:i+2] == ">=" || s[i:i+2] == "<>") {
+				op = s[i : i+2]
+			}
+			if op == "!" {
+				return nil, &SyntaxError{i, `unexpected "!", did you mean "!="?`}
+			}
+			start := i
+			i += len(op)
+			if op == "<>" {
+				op = "!="
+			}
+			tokens = append(tokens, token{tokOp, op, start})
+		case c == '\'' || c == '"':
+			// Quotes are escaped by doubling them, as in SQL
+			var b strings.Builder
+			start, j := i, i+1
+			for {
+				if j >= len(s) {
+					return nil, &SyntaxError{start, "unterminated string"}
+				}
+				if s[j] == c {
+					if j+1 < len(s) && s[j+1] == c {
+						b.WriteByte(c)
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[j])
+				j++
+			}
+			tokens = append(tokens, token{tokString, b.String(), start})
+			i = j + 1
+		case c == '-' || c == '.' || isDigit(c):
+			start := i
+			if c == '-' {
+				i++
+			}
+			for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, s[start:i], start})
+		case isLetter(c):
+			start := i
+			for i < len(s) && (isLetter(s[i]) || isDigit(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, s[start:i], start})
+		default:
+			return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+var keywords = []string{"AND", "OR", "NOT", "IN", "CONTAINS"}
+
+type parser struct {
+	tokens []token
+	i      int
+	fields Fields
+	terms  int
+	depth  int
+}
+
+// Parse parses s, accepting only the given fields
+func Parse(s string, fields Fields) (Expr, error) {
+	if len(s) > MaxLength {
+		return nil, &SyntaxError{0, fmt.Sprintf("filter is longer than %d characters", MaxLength)}
+	}
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, "expected AND, OR or end of filter, got %s", tok.describe())
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *parser) keyword(kw string) bool {
+	if tok := p.peek(); tok.kind == tokIdent && strings.EqualFold(tok.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorAt(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{tok.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{"OR", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{"AND", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.keyword("NOT") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{x}, nil
+	}
+
+	if tok := p.peek(); tok.kind == tokLParen {
+		if p.depth++; p.depth > maxDepth {
+			return nil, p.errorAt(tok, "parentheses are nested more than %d levels", maxDepth)
+		}
+		p.next()
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, p.errorAt(tok, "expected \")\", got %s", tok.describe())
+		}
+		p.depth--
+		return x, nil
+	}
+
+	return p.predicate()
+}
+
+func (p *parser) predicate() (Expr, error) {
+	tok := p.next()
+	if tok.kind != tokIdent || slices.ContainsFunc(keywords, func(kw string) bool { return strings.EqualFold(kw, tok.text) }) {
+		return nil, p.errorAt(tok, "expected a field name, got %s", tok.describe())
+	}
+	name := strings.ToLower(tok.text)
+	field, ok := p.fields[name]
+	if !ok {
+		return nil, p.errorAt(tok, "unknown field %q (allowed: %s)", tok.text, strings.Join(p.fieldNames(), ", "))
+	}
+	if p.terms++; p.terms > maxTerms {
+		return nil, p.errorAt(tok, "filter has more than %d conditions", maxTerms)
+	}
+
+	negated := p.keyword("NOT")
+	if p.keyword("IN") {
+		return p.in(name, field, negated)
+	}
+	if negated {
+		return nil, p.errorAt(p.peek(), "expected IN after NOT, got %s", p.peek().describe())
+	}
+
+	if opTok := p.peek(); p.keyword("CONTAINS") {
+		if field.Type != String {
+			return nil, p.errorAt(opTok, "CONTAINS only applies to text fields")
+		}
+		value, err := p.value(name, field)
+		if err != nil {
+			return nil, err
+		}
+		return &comparison{name, field, "CONTAINS", value}, nil
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokOp {
+		return nil, p.errorAt(opTok, "expected an operator after %s, got %s", name, opTok.describe())
+	}
+	if field.Type == String && opTok.text != "=" && opTok.text != "!=" {
+		return nil, p.errorAt(opTok, "%s only supports =, !=, CONTAINS and IN", name)
+	}
+	if field.Type == Membership && opTok.text != "=" && opTok.text != "!=" {
+		return nil, p.errorAt(opTok, "%s only supports =, != and IN", name)
+	}
+	value, err := p.value(name, field)
+	if err != nil {
+		return nil, err
+	}
+	return &comparison{name, field, opTok.text, value}, nil
+}
+
+func (p *parser) in(name string, field Field, negated bool) (Expr, error) {
+	if tok := p.next(); tok.kind != tokLParen {
+		return nil, p.errorAt(tok, "expected \"(\" after IN, got %s", tok.describe())
+	}
+	var values []interface{}
+	for {
+		value, err := p.value(name, field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		tok := p.next()
+		if tok.kind == tokRParen {
+			return &in{name, field, values, negated}, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorAt(tok, "expected \",\" or \")\", got %s", tok.describe())
+		}
+	}
+}
+
+// value parses a literal of the field's type
+func (p *parser) value(name string, field Field) (interface{}, error) {
+	tok := p.next()
+	switch field.Type {
+	case Number:
+		if tok.kind == tokNumber {
+			if amount, err := money.Parse(tok.text); err == nil {
+				return amount, nil
+			}
+		}
+		return nil, p.errorAt(tok, "%s expects a number, got %s", name, tok.describe())
+	case Int, Membership:
+		if tok.kind == tokNumber {
+			if n, err := strconv.Atoi(tok.text); err == nil {
+				return n, nil
+			}
+		}
+		return nil, p.errorAt(tok, "%s expects an integer, got %s", name, tok.describe())
+	case Time:
+		if tok.kind == tokString {
+			if t, err := time.Parse(time.RFC3339, tok.text); err == nil {
+				return t, nil
+			}
+		}
+		return nil, p.errorAt(tok, "%s expects a quoted RFC3339 date, got %s", name, tok.describe())
+	default:
+		if tok.kind == tokString {
+			return tok.text, nil
+		}
+		return nil, p.errorAt(tok, "%s expects a quoted string, got %s", name, tok.describe())
+	}
+}
+
+func (p *parser) fieldNames() []string {
+	names := make([]string, 0, len(p.fields))
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
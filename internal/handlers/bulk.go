@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkUpdateProducts godoc
+// @Summary      Actualizar productos en lote
+// @Description  Aplica los mismos cambios a varios productos, elegidos por ids o por filter (una expresión como la de ?filter= en GET /products, ej. category IN (1)). Cambios posibles: set_price o adjust_price_percent (redondeado a centavos), set_stock o adjust_stock, add_category_ids y remove_category_ids. Todo ocurre en una transacción: si un producto no admite el cambio (ej. el stock quedaría negativo) no se modifica ninguno. El historial de precios y stock se registra por producto, y cada cambio de stock agrega un movimiento 'adjustment' al libro del producto. Requiere rol de administrador. Emite un único evento WebSocket 'product:bulk_updated'.
+// @Tags         productos
+// @Accept       json
+// @Produce      json
+// @Param        request  body  models.ProductBulkUpdateRequest  true  "Selección (ids o filter) y cambios a aplicar"
+// @Success      200  {object}  models.ApiResponse{data=models.ProductBulkResult}  "Resumen: productos modificados e IDs pedidos que no existen"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Selección, filtro o cambios inválidos, o el resultado viola una regla (ej. stock negativo)"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "add_category_ids contiene categorías inexistentes"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/bulk [patch]
+func (h *Handler) BulkUpdateProducts(c *gin.Context) {
+	var req models.ProductBulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.Products.BulkUpdate(c.Request.Context(), actor(c), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, result)
+}
+
+// BulkDeleteProducts godoc
+// @Summary      Eliminar productos en lote
+// @Description  Mueve a la papelera varios productos, elegidos por ids o por filter, en una sola transacción. Requiere rol de administrador. Emite un único evento WebSocket 'product:bulk_deleted'.
+// @Tags         productos
+// @Accept       json
+// @Produce      json
+// @Param        request  body  models.ProductBulkDeleteRequest  true  "Selección: ids o filter"
+// @Success      200  {object}  models.ApiResponse{data=models.ProductBulkResult}  "Resumen: productos eliminados e IDs pedidos que no existen"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Selección o filtro inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/bulk [delete]
+func (h *Handler) BulkDeleteProducts(c *gin.Context) {
+	var req models.ProductBulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.RespondBindingError(c, err)
+		return
+	}
+
+	result, err := h.Products.BulkDelete(c.Request.Context(), actor(c), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, result)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCacheStats godoc
+// @Summary      Estadísticas del cache de lecturas
+// @Description  Devuelve aciertos, fallos y entradas del cache de productos y categorías, en total y por tipo de lectura. Requiere rol de administrador.
+// @Tags         cache
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.ApiResponse{data=models.CacheStats}  "Estadísticas del cache"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Security     BearerAuth
+// @Router       /cache/stats [get]
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	stats, err := h.Cache.Stats(c.Request.Context(), actor(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, stats)
+}
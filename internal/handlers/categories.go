@@ -3,20 +3,30 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
-	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
 	"github.com/gin-gonic/gin"
 )
 
 // ListCategories godoc
 // @Summary      Listar categorías
-// @Description  Obtiene la lista completa de categorías con búsqueda opcional por nombre
+// @Description  Obtiene la lista completa de categorías con búsqueda opcional por nombre. Con cursor devuelve páginas ordenadas por nombre.
 // @Tags         categorías
 // @Accept       json
 // @Produce      json
-// @Param        search  query     string  false  "Término de búsqueda en nombre de categoría"
-// @Success      200  {object}  models.ApiResponse{data=models.CategoryListResponse}  "Lista de categorías"
+// @Param        search         query     string  false  "Término de búsqueda en nombre de categoría"
+// @Param        cursor         query     string  false  "Cursor opaco para paginar; vacío pide la primera página"
+// @Param        limit          query     int     false  "Categorías por página con cursor (máximo 100)"  default(10)
+// @Param        include_total  query     bool    false  "Con cursor, incluir el total de categorías"  default(false)
+// @Param        fields         query     string  false  "Campos a devolver separados por coma, ej. id,name"
+// @Param        If-None-Match  header    string  false  "ETag de una respuesta anterior; si no cambió responde 304"
+// @Success      200  {object}  models.ApiResponse{data=models.CategoryListResponse}  "Lista de categorías (con cursor: models.CategoryCursorResponse)"
+// @Success      304  "Ninguna categoría cambió desde el ETag enviado"
+// @Header       200  {string}  ETag           "Identifica esta versión de la lista"
+// @Header       200  {string}  Cache-Control  "private, max-age=<http_cache.categories_max_age>"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Cursor o campos inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
@@ -25,18 +35,53 @@ func (h *Handler) ListCategories(c *gin.Context) {
 	// Get optional search parameter
 	search := c.Query("search")
 
-	categories, err := h.DB.ListCategories(c.Request.Context(), search)
+	pagination, err := h.cursorPagination(c)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to list categories")
+		c.Error(err)
 		return
 	}
 
-	response := models.CategoryListResponse{
+	fields, ok := parseFields(c, db.CategoryFields)
+	if !ok {
+		return
+	}
+
+	cacheable := models.Cacheable{
+		MaxAge: time.Duration(h.HTTPCache.CategoriesMaxAge),
+	}
+	version, err := h.Categories.ListVersion(c.Request.Context(), actor(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if listCacheable(c, &cacheable, version) {
+		return
+	}
+
+	categories, total, err := h.Categories.List(c.Request.Context(), actor(c), search, pagination, fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var response interface{} = models.CategoryListResponse{
 		Categories: categories,
-		Total:      len(categories),
+		Total:      total,
+	}
+	if pagination != nil {
+		response = models.CategoryCursorResponse{
+			Categories: categories,
+			CursorPage: cursorPage(pagination, total),
+		}
+	}
+
+	data, err := sparse(c, response, "categories", fields)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	models.RespondSuccess(c, http.StatusOK, response)
+	models.RespondCacheable(c, cacheable, data)
 }
 
 // GetCategory godoc
@@ -45,8 +90,13 @@ func (h *Handler) ListCategories(c *gin.Context) {
 // @Tags         categorías
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "ID de la categoría"
+// @Param        id                 path      int     true   "ID de la categoría"
+// @Param        If-None-Match      header    string  false  "ETag de una respuesta anterior; si no cambió responde 304"
+// @Param        If-Modified-Since  header    string  false  "Fecha HTTP; si la categoría no cambió desde entonces responde 304"
 // @Success      200  {object}  models.ApiResponse{data=models.Category}  "Detalle de la categoría"
+// @Success      304  "La categoría no cambió"
+// @Header       200  {string}  ETag           "Versión de la categoría, para enviar en If-Match"
+// @Header       200  {string}  Last-Modified  "Última modificación de la categoría"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Categoría no encontrada"
@@ -61,13 +111,17 @@ func (h *Handler) GetCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := h.DB.GetCategoryByID(c.Request.Context(), id)
+	category, err := h.Categories.Get(c.Request.Context(), actor(c), id)
 	if err != nil {
-		models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Category not found")
+		c.Error(err)
 		return
 	}
 
-	models.RespondSuccess(c, http.StatusOK, category)
+	models.RespondCacheable(c, models.Cacheable{
+		ETag:         models.ETag(category.Version),
+		LastModified: category.UpdatedAt,
+		MaxAge:       time.Duration(h.HTTPCache.CategoriesMaxAge),
+	}, category)
 }
 
 // CreateCategory godoc
@@ -78,9 +132,11 @@ func (h *Handler) GetCategory(c *gin.Context) {
 // @Produce      json
 // @Param        request  body      models.CategoryCreateRequest  true  "Datos de la categoría"
 // @Success      201  {object}  models.ApiResponse{data=models.Category}  "Categoría creada exitosamente"
+// @Header       201  {string}  ETag  "Versión de la categoría"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Datos de entrada inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Ya existe una categoría con ese nombre"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /categories [post]
@@ -88,35 +144,38 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	var req models.CategoryCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		models.RespondError(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		models.RespondBindingError(c, err)
 		return
 	}
 
-	category, err := h.DB.CreateCategory(c.Request.Context(), &req)
+	category, err := h.Categories.Create(c.Request.Context(), actor(c), &req)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "CREATE_ERROR", "Failed to create category")
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventCategoryCreated, category)
-
+	c.Header("ETag", models.ETag(category.Version))
 	models.RespondSuccess(c, http.StatusCreated, category)
 }
 
 // UpdateCategory godoc
 // @Summary      Actualizar categoría
-// @Description  Actualiza una categoría existente. Requiere rol de administrador. Los campos no enviados no se modifican. Emite evento WebSocket 'category:updated'.
+// @Description  Actualiza una categoría existente. Requiere rol de administrador. Los campos no enviados no se modifican. Con If-Match solo se aplica si la categoría sigue en esa versión; si no, responde 412 con la versión actual. Emite evento WebSocket 'category:updated'.
 // @Tags         categorías
 // @Accept       json
 // @Produce      json
 // @Param        id       path      int                           true  "ID de la categoría"
 // @Param        request  body      models.CategoryUpdateRequest  true  "Datos a actualizar (campos opcionales)"
+// @Param        If-Match header    string                        false "ETag obtenido al leer la categoría (ej. \"3\")"
 // @Success      200  {object}  models.ApiResponse{data=models.Category}  "Categoría actualizada exitosamente"
+// @Header       200  {string}  ETag  "Nueva versión de la categoría"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido o datos de entrada inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Categoría no encontrada"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Ya existe una categoría con ese nombre"
+// @Failure      412  {object}  models.ApiResponse{data=models.Category,error=models.ApiError}  "La categoría cambió; incluye la versión actual"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /categories/{id} [put]
@@ -131,38 +190,35 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	var req models.CategoryUpdateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		models.RespondError(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		models.RespondBindingError(c, err)
 		return
 	}
 
-	category, err := h.DB.UpdateCategory(c.Request.Context(), id, &req)
+	category, err := h.Categories.Update(c.Request.Context(), actor(c), id, &req, models.ParseIfMatch(c.GetHeader("If-Match")))
 	if err != nil {
-		if err.Error() == "category not found" {
-			models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Category not found")
-			return
-		}
-		models.RespondError(c, http.StatusInternalServerError, "UPDATE_ERROR", "Failed to update category")
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventCategoryUpdated, category)
-
+	c.Header("ETag", models.ETag(category.Version))
 	models.RespondSuccess(c, http.StatusOK, category)
 }
 
 // DeleteCategory godoc
 // @Summary      Eliminar categoría
-// @Description  Elimina una categoría del sistema. Requiere rol de administrador. Emite evento WebSocket 'category:deleted'.
+// @Description  Mueve una categoría a la papelera; deja de mostrarse en los productos hasta que se restaure. Requiere rol de administrador. Emite evento WebSocket 'category:deleted'.
 // @Tags         categorías
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "ID de la categoría"
+// @Param        id        path      int     true   "ID de la categoría"
+// @Param        If-Match  header    string  false  "ETag obtenido al leer la categoría (ej. \"3\")"
 // @Success      200  {object}  models.ApiResponse{data=object}  "Categoría eliminada exitosamente"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Categoría no encontrada"
+// @Failure      412  {object}  models.ApiResponse{data=models.Category,error=models.ApiError}  "La categoría cambió; incluye la versión actual"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /categories/{id} [delete]
@@ -174,17 +230,10 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.DeleteCategory(c.Request.Context(), id); err != nil {
-		if err.Error() == "category not found" {
-			models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Category not found")
-			return
-		}
-		models.RespondError(c, http.StatusInternalServerError, "DELETE_ERROR", "Failed to delete category")
+	if err := h.Categories.Delete(c.Request.Context(), actor(c), id, models.ParseIfMatch(c.GetHeader("If-Match"))); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventCategoryDeleted, gin.H{"id": id})
-
 	models.RespondSuccess(c, http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ListExchangeRates godoc
+// @Summary      Listar tipos de cambio
+// @Description  Obtiene los tipos de cambio cargados, agrupados por par de monedas y del más reciente al más antiguo. Opcionalmente filtra por moneda base y/o cotizada.
+// @Tags         tipos de cambio
+// @Accept       json
+// @Produce      json
+// @Param        base   query     string  false  "Moneda base (ISO 4217, ej. USD)"
+// @Param        quote  query     string  false  "Moneda cotizada (ISO 4217, ej. ARS)"
+// @Success      200  {object}  models.ApiResponse{data=models.ExchangeRateListResponse}  "Lista de tipos de cambio"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /exchange-rates [get]
+func (h *Handler) ListExchangeRates(c *gin.Context) {
+	base := strings.ToUpper(c.Query("base"))
+	quote := strings.ToUpper(c.Query("quote"))
+
+	rates, err := h.ExchangeRates.List(c.Request.Context(), actor(c), base, quote)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := models.ExchangeRateListResponse{
+		Rates: rates,
+		Total: len(rates),
+	}
+
+	models.RespondSuccess(c, http.StatusOK, response)
+}
+
+// CreateExchangeRate godoc
+// @Summary      Cargar tipo de cambio
+// @Description  Registra que 1 unidad de base_currency equivale a rate unidades de quote_currency desde effective_at (por defecto, ahora). Requiere rol de administrador. Emite evento WebSocket 'exchange_rate:created'.
+// @Tags         tipos de cambio
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ExchangeRateCreateRequest  true  "Datos del tipo de cambio"
+// @Success      201  {object}  models.ApiResponse{data=models.ExchangeRate}  "Tipo de cambio creado exitosamente"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Datos de entrada inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Ya existe un tipo de cambio para ese par y fecha"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /exchange-rates [post]
+func (h *Handler) CreateExchangeRate(c *gin.Context) {
+	var req models.ExchangeRateCreateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.RespondBindingError(c, err)
+		return
+	}
+
+	rate, err := h.ExchangeRates.Create(c.Request.Context(), actor(c), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusCreated, rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary      Eliminar tipo de cambio
+// @Description  Elimina un tipo de cambio. Requiere rol de administrador. Emite evento WebSocket 'exchange_rate:deleted'.
+// @Tags         tipos de cambio
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID del tipo de cambio"
+// @Success      200  {object}  models.ApiResponse{data=object}  "Tipo de cambio eliminado exitosamente"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Tipo de cambio no encontrado"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /exchange-rates/{id} [delete]
+func (h *Handler) DeleteExchangeRate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid exchange rate ID")
+		return
+	}
+
+	if err := h.ExchangeRates.Delete(c.Request.Context(), actor(c), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
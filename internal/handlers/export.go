@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/export"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// exportColumn is a column of an export and how to read it from a row
+type exportColumn[T any] struct {
+	name  string
+	value func(*T) interface{}
+}
+
+// Columns of each export, in file order. Products list their categories by
+// name, the way imports read them.
+var (
+	productExportColumns = []exportColumn[models.Product]{
+		{"id", func(p *models.Product) interface{} { return p.ID }},
+		{"sku", func(p *models.Product) interface{} { return p.SKU }},
+		{"name", func(p *models.Product) interface{} { return p.Name }},
+		{"description", func(p *models.Product) interface{} { return p.Description }},
+		{"price", func(p *models.Product) interface{} { return p.Price }},
+		{"currency", func(p *models.Product) interface{} { return p.Currency }},
+		{"stock", func(p *models.Product) interface{} { return p.Stock }},
+		{db.FieldCategories, func(p *models.Product) interface{} {
+			names := make([]string, len(p.Categories))
+			for i, c := range p.Categories {
+				names[i] = c.Name
+			}
+			return names
+		}},
+		{"created_at", func(p *models.Product) interface{} { return p.CreatedAt }},
+		{"updated_at", func(p *models.Product) interface{} { return p.UpdatedAt }},
+		{"version", func(p *models.Product) interface{} { return p.Version }},
+	}
+	categoryExportColumns = []exportColumn[models.Category]{
+		{"id", func(c *models.Category) interface{} { return c.ID }},
+		{"name", func(c *models.Category) interface{} { return c.Name }},
+		{"description", func(c *models.Category) interface{} { return c.Description }},
+		{"created_at", func(c *models.Category) interface{} { return c.CreatedAt }},
+		{"updated_at", func(c *models.Category) interface{} { return c.UpdatedAt }},
+		{"version", func(c *models.Category) interface{} { return c.Version }},
+	}
+	historyExportColumns = []exportColumn[models.ProductHistory]{
+		{"id", func(h *models.ProductHistory) interface{} { return h.ID }},
+		{"product_id", func(h *models.ProductHistory) interface{} { return h.ProductID }},
+		{"price", func(h *models.ProductHistory) interface{} { return h.Price }},
+		{"currency", func(h *models.ProductHistory) interface{} { return h.Currency }},
+		{"stock", func(h *models.ProductHistory) interface{} { return h.Stock }},
+		{"changed_at", func(h *models.ProductHistory) interface{} { return h.ChangedAt }},
+	}
+)
+
+// ExportProducts godoc
+// @Summary      Exportar productos
+// @Description  Descarga todos los productos que cumplen los filtros (los mismos que GET /products) en CSV, NDJSON o XLSX, con sus categorías por nombre separadas por "|". El archivo se genera fila por fila mientras se lee la base, sin paginar. Los precios se exportan en la moneda de cada producto. Con fields=sku,name,description,price,currency,stock&include=categories el CSV se puede volver a importar con POST /products/import.
+// @Tags         productos
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format          query  string  false  "Formato del archivo"  default(csv)  Enums(csv, ndjson, xlsx)
+// @Param        sort_by         query  string  false  "Campo para ordenar"  default(created_at)  Enums(name, price, stock, created_at)
+// @Param        sort_order      query  string  false  "Dirección de ordenamiento"  default(desc)  Enums(asc, desc)
+// @Param        sort            query  string  false  "Orden por varios campos, ej. price:desc,name:asc"
+// @Param        search          query  string  false  "Término de búsqueda full-text en nombres de productos"
+// @Param        filter          query  string  false  "Expresión de filtro, como en GET /products"
+// @Param        category_ids    query  string  false  "IDs de categorías separados por coma (ej. 1,3)"
+// @Param        category_match  query  string  false  "Con varias categorías: 'any' (alguna) o 'all' (todas)"  default(any)  Enums(any, all)
+// @Param        min_price       query  number  false  "Precio mínimo (inclusive)"
+// @Param        max_price       query  number  false  "Precio máximo (inclusive)"
+// @Param        in_stock        query  bool    false  "true: solo con stock; false: solo sin stock"
+// @Param        min_stock       query  int     false  "Stock mínimo (inclusive)"
+// @Param        max_stock       query  int     false  "Stock máximo (inclusive)"
+// @Param        created_after   query  string  false  "Creados desde esta fecha (RFC3339)"
+// @Param        updated_after   query  string  false  "Modificados desde esta fecha (RFC3339)"
+// @Param        fields          query  string  false  "Columnas a exportar separadas por coma, ej. sku,name,price"
+// @Param        include         query  string  false  "Con fields, agrega la columna de categorías"  Enums(categories)
+// @Success      200  {file}    file  "Archivo con una fila por producto"
+// @Header       200  {string}  Content-Disposition  "attachment; filename=products.<formato>"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Formato, filtro u orden inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/export [get]
+func (h *Handler) ExportProducts(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	filter := &db.FilterParams{
+		SortBy:    c.DefaultQuery("sort_by", "created_at"),
+		SortOrder: c.DefaultQuery("sort_order", "desc"),
+		Search:    c.Query("search"),
+	}
+	if !parseSort(c, filter, db.ParseProductSort) || !parseProductFilters(c, filter) {
+		return
+	}
+
+	fields, ok := parseFields(c, db.ProductFields, db.FieldCategories)
+	if !ok {
+		return
+	}
+	filter.Fields = fields
+
+	streamExport(c, "products", format, exportColumns(productExportColumns, fields), func(fn func(*models.Product) error) error {
+		return h.Products.Export(c.Request.Context(), actor(c), filter, fn)
+	})
+}
+
+// ExportCategories godoc
+// @Summary      Exportar categorías
+// @Description  Descarga todas las categorías, ordenadas por nombre, en CSV, NDJSON o XLSX
+// @Tags         categorías
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format  query  string  false  "Formato del archivo"  default(csv)  Enums(csv, ndjson, xlsx)
+// @Param        search  query  string  false  "Término de búsqueda en nombre de categoría"
+// @Param        fields  query  string  false  "Columnas a exportar separadas por coma, ej. id,name"
+// @Success      200  {file}    file  "Archivo con una fila por categoría"
+// @Header       200  {string}  Content-Disposition  "attachment; filename=categories.<formato>"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Formato o campos inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /categories/export [get]
+func (h *Handler) ExportCategories(c *gin.Context) {
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	fields, ok := parseFields(c, db.CategoryFields)
+	if !ok {
+		return
+	}
+
+	search := c.Query("search")
+	streamExport(c, "categories", format, exportColumns(categoryExportColumns, fields), func(fn func(*models.Category) error) error {
+		return h.Categories.Export(c.Request.Context(), actor(c), search, fn)
+	})
+}
+
+// ExportProductHistory godoc
+// @Summary      Exportar historial de producto
+// @Description  Descarga todos los cambios de precio y stock de un producto, del más reciente al más antiguo, en CSV, NDJSON o XLSX
+// @Tags         productos
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        id      path   int     true   "ID del producto"
+// @Param        format  query  string  false  "Formato del archivo"  default(csv)  Enums(csv, ndjson, xlsx)
+// @Param        start   query  string  false  "Fecha de inicio (RFC3339: 2024-01-01T00:00:00Z)"
+// @Param        end     query  string  false  "Fecha de fin (RFC3339: 2024-12-31T23:59:59Z)"
+// @Param        fields  query  string  false  "Columnas a exportar separadas por coma, ej. changed_at,price"
+// @Success      200  {file}    file  "Archivo con una fila por cambio"
+// @Header       200  {string}  Content-Disposition  "attachment; filename=product-<id>-history.<formato>"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID, formato, fechas o campos inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado o en la papelera"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/{id}/history/export [get]
+func (h *Handler) ExportProductHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid product ID")
+		return
+	}
+
+	format, ok := parseExportFormat(c)
+	if !ok {
+		return
+	}
+
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	fields, ok := parseFields(c, db.HistoryFields)
+	if !ok {
+		return
+	}
+
+	name := fmt.Sprintf("product-%d-history", id)
+	streamExport(c, name, format, exportColumns(historyExportColumns, fields), func(fn func(*models.ProductHistory) error) error {
+		return h.Products.ExportHistory(c.Request.Context(), actor(c), id, start, end, fn)
+	})
+}
+
+// parseExportFormat reads ?format=, responding with 400 when it is not supported
+func parseExportFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.DefaultQuery("format", export.CSV))
+	if !slices.Contains(export.Formats, format) {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_FORMAT",
+			"format must be one of: "+strings.Join(export.Formats, ", "))
+		return "", false
+	}
+	return format, true
+}
+
+// exportColumns keeps the columns in fields; nil fields keep them all
+func exportColumns[T any](columns []exportColumn[T], fields db.Fieldset) []exportColumn[T] {
+	var picked []exportColumn[T]
+	for _, column := range columns {
+		if fields.Has(column.name) {
+			picked = append(picked, column)
+		}
+	}
+	return picked
+}
+
+// streamExport writes the rows run hands to its callback as a file named
+// after name. The response only starts with the first row, so errors
+// before it (a bad filter, a failed query) are reported as usual. An error
+// after it can no longer change the status: the connection is closed
+// instead, so the client sees a truncated download rather than a complete
+// looking file.
+func streamExport[T any](c *gin.Context, name, format string, columns []exportColumn[T], run func(fn func(*T) error) error) {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+
+	var w export.Writer
+	start := func() error {
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = export.NewWriter(c.Writer, format, names, models.PriceFormat(c))
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	err := run(func(row *T) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i, column := range columns {
+			values[i] = column.value(row)
+		}
+		return w.Write(values)
+	})
+	if err == nil && w == nil {
+		// No rows: the file only has its header
+		err = start()
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+		return
+	}
+	log.Printf("Export of %s failed after it started: %v", name, err)
+	if conn, _, hijackErr := http.NewResponseController(c.Writer).Hijack(); hijackErr == nil {
+		conn.Close()
+	}
+}
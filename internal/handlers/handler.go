@@ -1,22 +1,209 @@
 package handlers
 
 import (
-	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
-	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/middleware"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/gin-gonic/gin"
 )
 
-// Handler holds dependencies for all handlers
+// Handler holds dependencies for all handlers. Handlers only translate
+// HTTP to service calls; business rules live in the service package.
 type Handler struct {
-	DB         *db.DB
-	JWTService *auth.JWTService
-	Hub        *websockets.Hub
+	Auth          *service.AuthService
+	Products      *service.ProductService
+	Categories    *service.CategoryService
+	ExchangeRates *service.ExchangeRateService
+	Trash         *service.TrashService
+	Cache         *service.CacheService
+	Import        *service.ImportService
+	Stock         *service.StockService
+	Pagination    config.PaginationConfig
+	HTTPCache     config.HTTPCacheConfig
 }
 
-func NewHandler(database *db.DB, jwtService *auth.JWTService, hub *websockets.Hub) *Handler {
+func NewHandler(services *service.Services, pagination config.PaginationConfig, httpCache config.HTTPCacheConfig) *Handler {
 	return &Handler{
-		DB:         database,
-		JWTService: jwtService,
-		Hub:        hub,
+		Auth:          services.Auth,
+		Products:      services.Products,
+		Categories:    services.Categories,
+		ExchangeRates: services.ExchangeRates,
+		Trash:         services.Trash,
+		Cache:         services.Cache,
+		Import:        services.Import,
+		Stock:         services.Stock,
+		Pagination:    pagination,
+		HTTPCache:     httpCache,
+	}
+}
+
+// actor builds the service actor from the claims stored by RequireAuth
+func actor(c *gin.Context) *service.Actor {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return nil
+	}
+	email, _ := middleware.GetUserEmail(c)
+	role, _ := middleware.GetUserRole(c)
+
+	return &service.Actor{UserID: userID, Email: email, Role: role}
+}
+
+// cursorPagination reads ?cursor=, ?limit= and ?include_total=. Listings
+// switch to keyset pagination when cursor is present; an empty cursor reads
+// the first page. It returns nil when the request uses offset pagination.
+func (h *Handler) cursorPagination(c *gin.Context) (*db.PaginationParams, error) {
+	raw, ok := c.GetQuery("cursor")
+	if !ok {
+		return nil, nil
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.DefaultLimit)))
+	includeTotal, _ := strconv.ParseBool(c.Query("include_total"))
+	pagination := &db.PaginationParams{
+		Limit:     limit,
+		MaxLimit:  h.Pagination.MaxLimit,
+		Keyset:    true,
+		SkipTotal: !includeTotal,
+	}
+
+	if raw != "" {
+		cursor, err := db.DecodeCursor(raw)
+		if err != nil {
+			return nil, err
+		}
+		pagination.Cursor = cursor
+	}
+	return pagination, nil
+}
+
+// cursorPage describes the page read with pagination
+func cursorPage(pagination *db.PaginationParams, total int) models.CursorPage {
+	page := models.CursorPage{Limit: pagination.Limit}
+	if pagination.NextCursor != "" {
+		page.NextCursor = &pagination.NextCursor
+	}
+	if pagination.PrevCursor != "" {
+		page.PrevCursor = &pagination.PrevCursor
+	}
+	if !pagination.SkipTotal {
+		page.Total = &total
+	}
+	return page
+}
+
+// parseSort reads ?sort= (e.g. "price:desc,name:asc") into filter with the
+// resource's parser, responding with 400 when it is malformed
+func parseSort(c *gin.Context, filter *db.FilterParams, parse func(string) ([]db.SortTerm, error)) bool {
+	raw := c.Query("sort")
+	if raw == "" {
+		return true
+	}
+	terms, err := parse(raw)
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_SORT", "Invalid sort: "+err.Error())
+		return false
+	}
+	filter.Sort = terms
+	return true
+}
+
+// parseFields reads ?fields= (e.g. "id,name,price") and, for resources with
+// embeds, ?include= (e.g. "categories") into the fieldset the store reads.
+// Without either every field is read and everything is embedded; with
+// fields, embeds are only read when included. It responds with 400 on
+// unknown names.
+func parseFields(c *gin.Context, allowed []string, embeds ...string) (db.Fieldset, bool) {
+	rawFields, rawInclude := c.Query("fields"), c.Query("include")
+	if rawFields == "" && rawInclude == "" {
+		return nil, true
+	}
+
+	var fields db.Fieldset
+	if rawFields == "" {
+		for _, field := range allowed {
+			if !slices.Contains(embeds, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	for _, param := range []struct {
+		name, raw string
+		allowed   []string
+	}{{"fields", rawFields, allowed}, {"include", rawInclude, embeds}} {
+		for _, name := range strings.Split(param.raw, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(param.allowed, name) {
+				models.RespondError(c, http.StatusBadRequest, "INVALID_FIELDS",
+					"Invalid "+param.name+": unknown field \""+name+"\" (allowed: "+strings.Join(param.allowed, ", ")+")")
+				return nil, false
+			}
+			if !slices.Contains(fields, name) {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields, true
+}
+
+// listCacheable tags a listing with the store version it is read at and
+// the query and price format that shape it. Unlike a digest of the body,
+// the tag is known before the listing is loaded, so an unchanged listing is
+// answered with 304 without reading it; it reports whether that happened.
+func listCacheable(c *gin.Context, cache *models.Cacheable, version string) bool {
+	sum := sha256.Sum256([]byte(version + "?" + c.Request.URL.RawQuery + "#" + models.PriceFormat(c)))
+	cache.ETag = `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	return models.RespondNotModified(c, *cache)
+}
+
+// sparse trims each object listed under key in response to the requested
+// fields; a price keeps its conversion. A nil fieldset leaves it untouched.
+// Trimmed responses are encoded here, so amounts follow the request's price
+// format before the fields are picked.
+func sparse(c *gin.Context, response interface{}, key string, fields db.Fieldset) (interface{}, error) {
+	if fields == nil {
+		return response, nil
+	}
+	if fields.Has("price") {
+		fields = fields.With("conversion")
+	}
+
+	data, err := models.EncodeJSON(c, response)
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(body[key], &items); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		for name := range item {
+			if !fields.Has(name) {
+				delete(item, name)
+			}
+		}
+	}
+	if body[key], err = json.Marshal(items); err != nil {
+		return nil, err
 	}
+	return body, nil
 }
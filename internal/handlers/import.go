@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes bounds the body of an import
+const maxImportBytes = 10 << 20
+
+// importFormats maps the accepted content types to import formats
+var importFormats = map[string]string{
+	"text/csv":             service.ImportFormatCSV,
+	"application/x-ndjson": service.ImportFormatNDJSON,
+	"application/ndjson":   service.ImportFormatNDJSON,
+	"application/jsonl":    service.ImportFormatNDJSON,
+}
+
+// ImportProducts godoc
+// @Summary      Importar productos en lote
+// @Description  Crea o actualiza productos desde un archivo CSV (con encabezado: sku, name, description, price, currency, stock, categories; varias categorías separadas por "|") o NDJSON (un producto por línea, categories como array). Las categorías se indican por nombre o por ID (si un valor coincide con un nombre, se usa ese nombre). Las filas cuyo sku pertenece a un producto existente lo actualizan (un cambio de stock se registra como movimiento 'adjustment'); el resto crea productos. Con mode=atomic no se escribe nada si alguna fila tiene errores (responde 422 con el reporte); con mode=best_effort se escriben las filas válidas y se informan las demás. Con dry_run=true se valida todo sin guardar cambios y se responde con el mismo status que la importación real (422 si mode=atomic tiene filas inválidas). Requiere rol de administrador. Emite un único evento WebSocket 'product:imported'.
+// @Tags         productos
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        file     body   string  true   "Contenido del archivo CSV o NDJSON (máximo 10 MB y 10000 filas)"
+// @Param        mode     query  string  false  "Qué hacer con las filas inválidas"  default(atomic)  Enums(atomic, best_effort)
+// @Param        dry_run  query  bool    false  "Validar y reportar sin guardar cambios"  default(false)
+// @Success      200  {object}  models.ApiResponse{data=models.ProductImportReport}  "Reporte por fila de la importación"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Archivo o modo inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      413  {object}  models.ApiResponse{error=models.ApiError}  "El archivo supera el tamaño máximo"
+// @Failure      415  {object}  models.ApiResponse{error=models.ApiError}  "Content-Type no soportado"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError,data=models.ProductImportReport}  "Modo atomic con filas inválidas (también con dry_run); no se importó nada"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/import [post]
+func (h *Handler) ImportProducts(c *gin.Context) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	format, ok := importFormats[mediaType]
+	if !ok {
+		models.RespondError(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_DRY_RUN", "dry_run must be true or false")
+		return
+	}
+	mode := c.DefaultQuery("mode", models.ImportModeAtomic)
+
+	rows, err := service.ReadProductImport(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes), format)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		models.RespondError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", fmt.Sprintf("The import must not exceed %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, err := h.Import.ImportProducts(c.Request.Context(), actor(c), rows, mode, dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// A dry run answers as the real import would
+	if report.Mode == models.ImportModeAtomic && report.Failed > 0 {
+		models.RespondErrorWithData(c, http.StatusUnprocessableEntity, "IMPORT_REJECTED",
+			fmt.Sprintf("No product was imported: %d of %d rows have errors", report.Failed, report.Total), report)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, report)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/jsonpatch"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPatchBytes bounds the body of a patch
+const maxPatchBytes = 1 << 20
+
+// PatchProduct godoc
+// @Summary      Modificar producto con un patch
+// @Description  Aplica un JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json) o un JSON Patch (RFC 6902, Content-Type application/json-patch+json) sobre el documento editable del producto: sku, name, description, price, currency, stock y category_ids. Con merge patch, null borra sku o description; con JSON Patch se puede, por ejemplo, agregar una categoría con {"op":"add","path":"/category_ids/-","value":3}. El patch se aplica entero o no se aplica, en una transacción, y el resultado se valida como un producto nuevo. Un cambio de stock se registra como movimiento 'adjustment'. Con If-Match solo se aplica si el producto sigue en esa versión. Requiere rol de administrador. Emite evento WebSocket 'product:updated' si algo cambió.
+// @Tags         productos
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path    int                          true   "ID del producto"
+// @Param        patch     body    models.ProductPatchDocument  true   "Merge patch (campos a cambiar) o JSON Patch (array de operaciones)"
+// @Param        If-Match  header  string                       false  "ETag obtenido al leer el producto (ej. \"3\")"
+// @Success      200  {object}  models.ApiResponse{data=models.Product}  "Producto modificado"
+// @Header       200  {string}  ETag  "Nueva versión del producto"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID o patch inválidos, o el resultado no es un producto válido"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Falló una operación test del JSON Patch, o el sku ya está en uso"
+// @Failure      412  {object}  models.ApiResponse{data=models.Product,error=models.ApiError}  "El producto cambió; incluye la versión actual"
+// @Failure      413  {object}  models.ApiResponse{error=models.ApiError}  "El patch supera el tamaño máximo"
+// @Failure      415  {object}  models.ApiResponse{error=models.ApiError}  "Content-Type no soportado"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "Una operación no se puede aplicar (ej. path inexistente) o category_ids contiene categorías inexistentes"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/{id} [patch]
+func (h *Handler) PatchProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid product ID")
+		return
+	}
+
+	patch, ok := readPatch(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.Products.Patch(c.Request.Context(), actor(c), id, patch, models.ParseIfMatch(c.GetHeader("If-Match")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", models.ETag(product.Version))
+	models.RespondSuccess(c, http.StatusOK, product)
+}
+
+// PatchCategory godoc
+// @Summary      Modificar categoría con un patch
+// @Description  Aplica un JSON Merge Patch (application/merge-patch+json) o un JSON Patch (application/json-patch+json) sobre name y description de la categoría; null en un merge patch borra la descripción. El patch se aplica entero o no se aplica. Con If-Match solo se aplica si la categoría sigue en esa versión. Requiere rol de administrador. Emite evento WebSocket 'category:updated' si algo cambió.
+// @Tags         categorías
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path    int                           true   "ID de la categoría"
+// @Param        patch     body    models.CategoryPatchDocument  true   "Merge patch (campos a cambiar) o JSON Patch (array de operaciones)"
+// @Param        If-Match  header  string                        false  "ETag obtenido al leer la categoría (ej. \"3\")"
+// @Success      200  {object}  models.ApiResponse{data=models.Category}  "Categoría modificada"
+// @Header       200  {string}  ETag  "Nueva versión de la categoría"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID o patch inválidos, o el resultado no es una categoría válida"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Categoría no encontrada"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Falló una operación test del JSON Patch, o ya existe una categoría con ese nombre"
+// @Failure      412  {object}  models.ApiResponse{data=models.Category,error=models.ApiError}  "La categoría cambió; incluye la versión actual"
+// @Failure      413  {object}  models.ApiResponse{error=models.ApiError}  "El patch supera el tamaño máximo"
+// @Failure      415  {object}  models.ApiResponse{error=models.ApiError}  "Content-Type no soportado"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "Una operación no se puede aplicar (ej. path inexistente)"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /categories/{id} [patch]
+func (h *Handler) PatchCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid category ID")
+		return
+	}
+
+	patch, ok := readPatch(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.Categories.Patch(c.Request.Context(), actor(c), id, patch, models.ParseIfMatch(c.GetHeader("If-Match")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", models.ETag(category.Version))
+	models.RespondSuccess(c, http.StatusOK, category)
+}
+
+// readPatch reads the request body as the kind of patch its Content-Type
+// names, responding with an error when it cannot
+func readPatch(c *gin.Context) (service.PatchFunc, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		models.RespondError(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE",
+			"Content-Type must be "+jsonpatch.MergePatchType+" or "+jsonpatch.JSONPatchType)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		models.RespondError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", fmt.Sprintf("The patch must not exceed %d bytes", tooLarge.Limit))
+		return nil, false
+	}
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+
+	return func(doc []byte) ([]byte, error) {
+		return apply(doc, body)
+	}, true
+}
@@ -3,51 +3,140 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
-	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
 	"github.com/gin-gonic/gin"
 )
 
 // ListProducts godoc
 // @Summary      Listar productos con paginación
-// @Description  Obtiene una lista paginada de productos con opciones de filtrado, ordenamiento y búsqueda full-text
+// @Description  Obtiene una lista paginada de productos con opciones de filtrado, ordenamiento y búsqueda full-text. Con ?ids= obtiene varios productos puntuales (con sus categorías) en una sola query
 // @Tags         productos
 // @Accept       json
 // @Produce      json
-// @Param        page        query     int     false  "Número de página"  default(1)
+// @Param        page        query     int     false  "Número de página (paginación por offset)"  default(1)
+// @Param        cursor      query     string  false  "Cursor opaco (next_cursor/prev_cursor) para paginación por keyset; vacío pide la primera página"
+// @Param        include_total  query  bool    false  "Con cursor, incluir el total de productos (cuesta un COUNT)"  default(false)
 // @Param        limit       query     int     false  "Productos por página (máximo 100)"  default(10)
 // @Param        sort_by     query     string  false  "Campo para ordenar"  default(created_at)  Enums(name, price, stock, created_at)
 // @Param        sort_order  query     string  false  "Dirección de ordenamiento"  default(desc)  Enums(asc, desc)
+// @Param        sort        query     string  false  "Orden por varios campos, ej. price:desc,name:asc (reemplaza sort_by/sort_order; siempre desempata por id)"
+// @Param        ids         query     string  false  "IDs separados por coma (máximo 100): devuelve esos productos en ese orden y los IDs inexistentes en not_found, sin paginar ni filtrar"
 // @Param        search      query     string  false  "Término de búsqueda full-text en nombres de productos"
-// @Success      200  {object}  models.ApiResponse{data=models.ProductListResponse}  "Lista de productos"
+// @Param        category_id  query  int     false  "Filtrar por ID de categoría"
+// @Param        filter          query  string  false  "Expresión de filtro, ej. price >= 100 AND stock < 5 AND category IN (1,3). Campos: id, name, price, currency, stock, created_at, updated_at, category. Operadores: = != < <= > >= IN, NOT IN, CONTAINS, AND, OR, NOT y paréntesis"
+// @Param        category_ids    query  string  false  "IDs de categorías separados por coma (ej. 1,3)"
+// @Param        category_match  query  string  false  "Con varias categorías: 'any' (alguna) o 'all' (todas)"  default(any)  Enums(any, all)
+// @Param        min_price       query  number  false  "Precio mínimo (inclusive, en la moneda de cada producto)"
+// @Param        max_price       query  number  false  "Precio máximo (inclusive)"
+// @Param        in_stock        query  bool    false  "true: solo con stock; false: solo sin stock"
+// @Param        min_stock       query  int     false  "Stock mínimo (inclusive)"
+// @Param        max_stock       query  int     false  "Stock máximo (inclusive)"
+// @Param        created_after   query  string  false  "Creados desde esta fecha (RFC3339)"
+// @Param        updated_after   query  string  false  "Modificados desde esta fecha (RFC3339)"
+// @Param        currency    query     string  false  "Convertir precios a esta moneda (ISO 4217, ej. USD)"
+// @Param        fields      query     string  false  "Campos a devolver separados por coma, ej. id,name,price (se leen solo esas columnas)"
+// @Param        include     query     string  false  "Datos embebidos: categories. Con fields, las categorías solo se devuelven si se incluyen"  Enums(categories)
+// @Param        If-None-Match  header  string  false  "ETag de una respuesta anterior; si no cambió responde 304"
+// @Success      200  {object}  models.ApiResponse{data=models.ProductListResponse}  "Lista de productos (con cursor: models.ProductCursorResponse; con ids: models.ProductBatchResponse)"
+// @Success      304  "Ningún producto ni categoría cambió desde el ETag enviado (sin currency se responde sin leer la página)"
+// @Header       200  {string}  ETag           "Identifica esta versión de la página"
+// @Header       200  {string}  Cache-Control  "private, max-age=<http_cache.products_max_age> (no-cache si es 0)"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Moneda, filtro, campos o cursor inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products [get]
 func (h *Handler) ListProducts(c *gin.Context) {
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	pagination := &db.PaginationParams{
-		Page:  page,
-		Limit: limit,
-	}
-
 	// Parse filter parameters
 	filter := &db.FilterParams{
 		SortBy:    c.DefaultQuery("sort_by", "created_at"),
 		SortOrder: c.DefaultQuery("sort_order", "desc"),
 		Search:    c.Query("search"),
 	}
+	if !parseSort(c, filter, db.ParseProductSort) || !parseProductFilters(c, filter) {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	fields, ok := parseFields(c, db.ProductFields, db.FieldCategories)
+	if !ok {
+		return
+	}
+	filter.Fields = fields
+
+	cacheable := models.Cacheable{
+		MaxAge: time.Duration(h.HTTPCache.ProductsMaxAge),
+	}
+
+	// Unless prices are converted, the listing only depends on the catalog,
+	// so an unchanged one is answered before it is read
+	if currency == "" {
+		version, err := h.Products.ListVersion(c.Request.Context(), actor(c))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if listCacheable(c, &cacheable, version) {
+			return
+		}
+	}
+
+	// ?ids= looks those products up instead of listing a page
+	if raw := c.QueryArray("ids"); len(raw) > 0 {
+		h.getProductsByIDs(c, raw, fields, currency, cacheable)
+		return
+	}
+
+	// Keyset pagination when a cursor is given
+	keyset, err := h.cursorPagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if keyset != nil {
+		products, total, err := h.Products.List(c.Request.Context(), actor(c), keyset, filter, currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		response, err := sparse(c, models.ProductCursorResponse{
+			Products:   products,
+			CursorPage: cursorPage(keyset, total),
+		}, "products", fields)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		models.RespondCacheable(c, cacheable, response)
+		return
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.DefaultLimit)))
+
+	pagination := &db.PaginationParams{
+		Page:     page,
+		Limit:    limit,
+		MaxLimit: h.Pagination.MaxLimit,
+	}
 
 	// Get products from database
-	products, total, err := h.DB.ListProducts(c.Request.Context(), pagination, filter)
+	products, total, err := h.Products.List(c.Request.Context(), actor(c), pagination, filter, currency)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to list products")
+		c.Error(err)
 		return
 	}
 
@@ -55,15 +144,52 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	totalPages := db.CalculateTotalPages(total, pagination.Limit)
 
 	// Build response
-	response := models.ProductListResponse{
+	response, err := sparse(c, models.ProductListResponse{
 		Products:   products,
 		Total:      total,
 		Page:       pagination.Page,
 		Limit:      pagination.Limit,
 		TotalPages: totalPages,
+	}, "products", fields)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	models.RespondSuccess(c, http.StatusOK, response)
+	models.RespondCacheable(c, cacheable, response)
+}
+
+// getProductsByIDs answers ListProducts when ?ids= is given; pagination,
+// sorting and filters do not apply
+func (h *Handler) getProductsByIDs(c *gin.Context, raw []string, fields db.Fieldset, currency string, cacheable models.Cacheable) {
+	var ids []int
+	for _, list := range raw {
+		for _, part := range strings.Split(list, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || id <= 0 {
+				models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "ids must be a comma separated list of product IDs")
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	products, notFound, err := h.Products.GetMany(c.Request.Context(), actor(c), ids, fields, currency)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response, err := sparse(c, models.ProductBatchResponse{
+		Products: products,
+		NotFound: notFound,
+	}, "products", fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondCacheable(c, cacheable, response)
 }
 
 // GetProduct godoc
@@ -72,11 +198,18 @@ func (h *Handler) ListProducts(c *gin.Context) {
 // @Tags         productos
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "ID del producto"
+// @Param        id        path      int     true   "ID del producto"
+// @Param        currency           query     string  false  "Convertir el precio a esta moneda (ISO 4217, ej. USD)"
+// @Param        If-None-Match      header    string  false  "ETag de una respuesta anterior; si no cambió responde 304"
+// @Param        If-Modified-Since  header    string  false  "Fecha HTTP; si el producto no cambió desde entonces responde 304"
 // @Success      200  {object}  models.ApiResponse{data=models.Product}  "Detalle del producto"
-// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
+// @Success      304  "El producto no cambió"
+// @Header       200  {string}  ETag           "Versión del producto y de sus categorías, para enviar en If-Match o If-None-Match (débil si se convirtió el precio)"
+// @Header       200  {string}  Last-Modified  "Última modificación del producto o sus categorías"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID o moneda inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "No hay tipo de cambio para la moneda pedida"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products/{id} [get]
@@ -88,14 +221,42 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
 	// Get product from database
-	product, err := h.DB.GetProductByID(c.Request.Context(), id)
+	product, err := h.Products.Get(c.Request.Context(), actor(c), id, currency)
 	if err != nil {
-		models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Product not found")
+		c.Error(err)
 		return
 	}
 
-	models.RespondSuccess(c, http.StatusOK, product)
+	cache := models.Cacheable{
+		ETag:         models.ProductETag(product),
+		LastModified: productLastModified(product),
+		MaxAge:       time.Duration(h.HTTPCache.ProductsMaxAge),
+	}
+	if product.Conversion != nil {
+		// The converted price also depends on the exchange rate, so let the
+		// ETag be derived from the body instead of the stored version
+		cache.ETag, cache.LastModified = "", time.Time{}
+	}
+
+	models.RespondCacheable(c, cache, product)
+}
+
+// productLastModified is the latest change to a product or the categories
+// embedded in it
+func productLastModified(product *models.Product) time.Time {
+	modified := product.UpdatedAt
+	for _, category := range product.Categories {
+		if category.UpdatedAt.After(modified) {
+			modified = category.UpdatedAt
+		}
+	}
+	return modified
 }
 
 // CreateProduct godoc
@@ -106,9 +267,11 @@ func (h *Handler) GetProduct(c *gin.Context) {
 // @Produce      json
 // @Param        request  body      models.ProductCreateRequest  true  "Datos del producto"
 // @Success      201  {object}  models.ApiResponse{data=models.Product}  "Producto creado exitosamente"
+// @Header       201  {string}  ETag  "Versión del producto"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Datos de entrada inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "category_ids contiene categorías inexistentes"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products [post]
@@ -117,36 +280,39 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 
 	// Bind and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		models.RespondError(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		models.RespondBindingError(c, err)
 		return
 	}
 
 	// Create product
-	product, err := h.DB.CreateProduct(c.Request.Context(), &req)
+	product, err := h.Products.Create(c.Request.Context(), actor(c), &req)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "CREATE_ERROR", "Failed to create product")
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventProductCreated, product)
-
+	c.Header("ETag", models.ETag(product.Version))
 	models.RespondSuccess(c, http.StatusCreated, product)
 }
 
 // UpdateProduct godoc
 // @Summary      Actualizar producto
-// @Description  Actualiza un producto existente. Requiere rol de administrador. Los campos no enviados no se modifican. Emite evento WebSocket 'product:updated'.
+// @Description  Actualiza un producto existente. Requiere rol de administrador. Los campos no enviados no se modifican. Un cambio de stock se registra como movimiento 'adjustment' en el libro del producto. Con If-Match solo se aplica si el producto sigue en esa versión; si no, responde 412 con la versión actual. Emite evento WebSocket 'product:updated'.
 // @Tags         productos
 // @Accept       json
 // @Produce      json
 // @Param        id       path      int                          true  "ID del producto"
 // @Param        request  body      models.ProductUpdateRequest  true  "Datos a actualizar (campos opcionales)"
+// @Param        If-Match header    string                       false "ETag obtenido al leer el producto (ej. \"3\")"
 // @Success      200  {object}  models.ApiResponse{data=models.Product}  "Producto actualizado exitosamente"
+// @Header       200  {string}  ETag  "Nueva versión del producto"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido o datos de entrada inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      412  {object}  models.ApiResponse{data=models.Product,error=models.ApiError}  "El producto cambió; incluye la versión actual"
+// @Failure      422  {object}  models.ApiResponse{error=models.ApiError}  "category_ids contiene categorías inexistentes"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products/{id} [put]
@@ -162,39 +328,36 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 
 	// Bind and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		models.RespondError(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		models.RespondBindingError(c, err)
 		return
 	}
 
 	// Update product
-	product, err := h.DB.UpdateProduct(c.Request.Context(), id, &req)
+	product, err := h.Products.Update(c.Request.Context(), actor(c), id, &req, models.ParseIfMatch(c.GetHeader("If-Match")))
 	if err != nil {
-		if err.Error() == "product not found" {
-			models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Product not found")
-			return
-		}
-		models.RespondError(c, http.StatusInternalServerError, "UPDATE_ERROR", "Failed to update product")
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventProductUpdated, product)
-
+	c.Header("ETag", models.ETag(product.Version))
 	models.RespondSuccess(c, http.StatusOK, product)
 }
 
 // DeleteProduct godoc
 // @Summary      Eliminar producto
-// @Description  Elimina un producto del sistema. Requiere rol de administrador. Emite evento WebSocket 'product:deleted'.
+// @Description  Mueve un producto a la papelera; se puede restaurar hasta que se purgue. Requiere rol de administrador. Emite evento WebSocket 'product:deleted'.
 // @Tags         productos
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "ID del producto"
+// @Param        id        path      int     true   "ID del producto"
+// @Param        If-Match  header    string  false  "ETag obtenido al leer el producto (ej. \"3\")"
 // @Success      200  {object}  models.ApiResponse{data=object}  "Producto eliminado exitosamente"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
 // @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
 // @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      412  {object}  models.ApiResponse{data=models.Product,error=models.ApiError}  "El producto cambió; incluye la versión actual"
+// @Failure      428  {object}  models.ApiResponse{error=models.ApiError}  "Falta If-Match (si concurrency.require_if_match está activo)"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products/{id} [delete]
@@ -207,18 +370,11 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	}
 
 	// Delete product
-	if err := h.DB.DeleteProduct(c.Request.Context(), id); err != nil {
-		if err.Error() == "product not found" {
-			models.RespondError(c, http.StatusNotFound, "NOT_FOUND", "Product not found")
-			return
-		}
-		models.RespondError(c, http.StatusInternalServerError, "DELETE_ERROR", "Failed to delete product")
+	if err := h.Products.Delete(c.Request.Context(), actor(c), id, models.ParseIfMatch(c.GetHeader("If-Match"))); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Broadcast WebSocket event
-	websockets.BroadcastEvent(h.Hub, websockets.EventProductDeleted, gin.H{"id": id})
-
 	models.RespondSuccess(c, http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
@@ -231,9 +387,14 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 // @Param        id     path      int     true   "ID del producto"
 // @Param        start  query     string  false  "Fecha de inicio (RFC3339: 2024-01-01T00:00:00Z)"
 // @Param        end    query     string  false  "Fecha de fin (RFC3339: 2024-12-31T23:59:59Z)"
-// @Success      200  {object}  models.ApiResponse{data=models.ProductHistoryResponse}  "Historial del producto"
-// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido o formato de fecha incorrecto"
+// @Param        cursor         query  string  false  "Cursor opaco para paginar el historial; vacío pide la primera página"
+// @Param        limit          query  int     false  "Registros por página con cursor (máximo 100)"  default(10)
+// @Param        include_total  query  bool    false  "Con cursor, incluir el total de registros"  default(false)
+// @Param        fields         query  string  false  "Campos a devolver separados por coma, ej. changed_at,price"
+// @Success      200  {object}  models.ApiResponse{data=models.ProductHistoryResponse}  "Historial del producto (con cursor: models.ProductHistoryCursorResponse)"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido, formato de fecha incorrecto, campos o cursor inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado o en la papelera"
 // @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
 // @Security     BearerAuth
 // @Router       /products/{id}/history [get]
@@ -246,37 +407,169 @@ func (h *Handler) GetProductHistory(c *gin.Context) {
 	}
 
 	// Parse date range parameters (optional)
-	var startDate, endDate *time.Time
+	startDate, endDate, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
 
-	if startStr := c.Query("start"); startStr != "" {
-		start, err := time.Parse(time.RFC3339, startStr)
-		if err != nil {
-			models.RespondError(c, http.StatusBadRequest, "INVALID_DATE", "Invalid start date format (use RFC3339)")
-			return
-		}
-		startDate = &start
+	pagination, err := h.cursorPagination(c)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	if endStr := c.Query("end"); endStr != "" {
-		end, err := time.Parse(time.RFC3339, endStr)
-		if err != nil {
-			models.RespondError(c, http.StatusBadRequest, "INVALID_DATE", "Invalid end date format (use RFC3339)")
-			return
-		}
-		endDate = &end
+	fields, ok := parseFields(c, db.HistoryFields)
+	if !ok {
+		return
 	}
 
 	// Get product history
-	history, err := h.DB.GetProductHistory(c.Request.Context(), id, startDate, endDate)
+	history, total, err := h.Products.History(c.Request.Context(), actor(c), id, startDate, endDate, pagination, fields)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to get product history")
+		c.Error(err)
 		return
 	}
 
-	response := models.ProductHistoryResponse{
+	var response interface{} = models.ProductHistoryResponse{
 		History: history,
-		Total:   len(history),
+		Total:   total,
 	}
+	if pagination != nil {
+		response = models.ProductHistoryCursorResponse{
+			History:    history,
+			CursorPage: cursorPage(pagination, total),
+		}
+	}
+
+	data, err := sparse(c, response, "history", fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, data)
+}
 
-	models.RespondSuccess(c, http.StatusOK, response)
+// parseDateRange reads the optional ?start= and ?end= of a history,
+// responding with 400 when one is malformed
+func parseDateRange(c *gin.Context) (start, end *time.Time, ok bool) {
+	for _, p := range []struct {
+		name string
+		dest **time.Time
+	}{{"start", &start}, {"end", &end}} {
+		if value := c.Query(p.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				models.RespondError(c, http.StatusBadRequest, "INVALID_DATE", "Invalid "+p.name+" date format (use RFC3339)")
+				return nil, nil, false
+			}
+			*p.dest = &t
+		}
+	}
+	return start, end, true
+}
+
+// parseProductFilters reads the optional product filters into filter,
+// responding with 400 when one is malformed
+func parseProductFilters(c *gin.Context, filter *db.FilterParams) bool {
+	invalid := func(code, message string) bool {
+		models.RespondError(c, http.StatusBadRequest, code, message)
+		return false
+	}
+
+	if raw := c.Query("filter"); raw != "" {
+		expr, err := filterexpr.Parse(raw, db.ProductFilterFields)
+		if err != nil {
+			return invalid("INVALID_FILTER", "Invalid filter "+err.Error())
+		}
+		filter.Expr = expr
+	}
+
+	// category_id and category_ids (comma separated or repeated) combine
+	raw := c.QueryArray("category_ids")
+	if id := c.Query("category_id"); id != "" {
+		raw = append(raw, id)
+	}
+	for _, list := range raw {
+		for _, part := range strings.Split(list, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return invalid("INVALID_CATEGORY_ID", "Invalid category_id parameter")
+			}
+			filter.CategoryIDs = append(filter.CategoryIDs, id)
+		}
+	}
+	switch match := c.DefaultQuery("category_match", db.CategoryMatchAny); match {
+	case db.CategoryMatchAny, db.CategoryMatchAll:
+		filter.CategoryMatch = match
+	default:
+		return invalid("INVALID_FILTER", "category_match must be 'any' or 'all'")
+	}
+
+	prices := []struct {
+		name string
+		dest **money.Amount
+	}{{"min_price", &filter.MinPrice}, {"max_price", &filter.MaxPrice}}
+	for _, p := range prices {
+		if value := c.Query(p.name); value != "" {
+			amount, err := money.Parse(value)
+			if err != nil {
+				return invalid("INVALID_FILTER", p.name+" must be a decimal number")
+			}
+			*p.dest = &amount
+		}
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Cmp(*filter.MaxPrice) > 0 {
+		return invalid("INVALID_FILTER", "min_price must not be greater than max_price")
+	}
+
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("INVALID_FILTER", "in_stock must be true or false")
+		}
+		filter.InStock = &inStock
+	}
+	stocks := []struct {
+		name string
+		dest **int
+	}{{"min_stock", &filter.MinStock}, {"max_stock", &filter.MaxStock}}
+	for _, p := range stocks {
+		if value := c.Query(p.name); value != "" {
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				return invalid("INVALID_FILTER", p.name+" must be an integer")
+			}
+			*p.dest = &stock
+		}
+	}
+	if filter.MinStock != nil && filter.MaxStock != nil && *filter.MinStock > *filter.MaxStock {
+		return invalid("INVALID_FILTER", "min_stock must not be greater than max_stock")
+	}
+
+	dates := []struct {
+		name string
+		dest **time.Time
+	}{{"created_after", &filter.CreatedAfter}, {"updated_after", &filter.UpdatedAfter}}
+	for _, p := range dates {
+		if value := c.Query(p.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return invalid("INVALID_DATE", "Invalid "+p.name+" format (use RFC3339)")
+			}
+			*p.dest = &t
+		}
+	}
+
+	return true
+}
+
+// parseCurrency reads the optional ?currency= parameter, responding with 400 when it is malformed
+func parseCurrency(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if currency != "" && !models.IsCurrencyCode(currency) {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_CURRENCY", "currency must be a 3-letter ISO 4217 code")
+		return "", false
+	}
+	return currency, true
 }
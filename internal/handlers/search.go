@@ -11,7 +11,7 @@ import (
 
 // Search godoc
 // @Summary      Búsqueda universal
-// @Description  Busca productos o categorías según el parámetro 'type'. Soporta paginación, ordenamiento y filtros. Para productos, permite filtrar por category_id.
+// @Description  Busca productos o categorías según el parámetro 'type'. Soporta paginación, ordenamiento y filtros. Para productos, permite filtrar por categorías, rango de precio y stock, y fechas de alta o modificación.
 // @Tags         búsqueda
 // @Accept       json
 // @Produce      json
@@ -21,7 +21,21 @@ import (
 // @Param        limit        query     int     false  "Items por página"  default(20)
 // @Param        sort_by      query     string  false  "Campo para ordenar (name, price, stock, created_at para products)"  default(name)
 // @Param        sort_order   query     string  false  "Dirección de ordenamiento"  default(asc)  Enums(asc, desc)
+// @Param        sort         query     string  false  "Orden por varios campos, ej. price:desc,name:asc (name y created_at para categorías); siempre desempata por id"
 // @Param        category_id  query     int     false  "Filtrar productos por ID de categoría (solo para type=product)"
+// @Param        filter          query  string  false  "Expresión de filtro, ej. price >= 100 AND stock < 5 AND category IN (1,3). Campos: id, name, price, currency, stock, created_at, updated_at, category. Operadores: = != < <= > >= IN, NOT IN, CONTAINS, AND, OR, NOT y paréntesis"
+// @Param        category_ids    query  string  false  "IDs de categorías separados por coma (ej. 1,3)"
+// @Param        category_match  query  string  false  "Con varias categorías: 'any' (alguna) o 'all' (todas)"  default(any)  Enums(any, all)
+// @Param        min_price       query  number  false  "Precio mínimo (inclusive, en la moneda de cada producto)"
+// @Param        max_price       query  number  false  "Precio máximo (inclusive)"
+// @Param        in_stock        query  bool    false  "true: solo con stock; false: solo sin stock"
+// @Param        min_stock       query  int     false  "Stock mínimo (inclusive)"
+// @Param        max_stock       query  int     false  "Stock máximo (inclusive)"
+// @Param        created_after   query  string  false  "Creados desde esta fecha (RFC3339)"
+// @Param        updated_after   query  string  false  "Modificados desde esta fecha (RFC3339)"
+// @Param        currency     query     string  false  "Convertir precios a esta moneda (solo para type=product)"
+// @Param        fields       query     string  false  "Campos a devolver separados por coma, ej. id,name,price"
+// @Param        include      query     string  false  "Datos embebidos (solo para type=product): categories"  Enums(categories)
 // @Success      200  {object}  models.ApiResponse{data=models.ProductListResponse}  "Resultados de búsqueda (ProductListResponse o CategoryListResponse según type)"
 // @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "Parámetros inválidos"
 // @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
@@ -49,11 +63,12 @@ func (h *Handler) Search(c *gin.Context) {
 func (h *Handler) searchProducts(c *gin.Context, query string) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.SearchDefaultLimit)))
 
 	pagination := &db.PaginationParams{
-		Page:  page,
-		Limit: limit,
+		Page:     page,
+		Limit:    limit,
+		MaxLimit: h.Pagination.MaxLimit,
 	}
 
 	// Parse filter parameters
@@ -63,28 +78,37 @@ func (h *Handler) searchProducts(c *gin.Context, query string) {
 		SortOrder: c.DefaultQuery("sort_order", "asc"),
 	}
 
-	// Parse category_id filter (optional)
-	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
-		categoryID, err := strconv.Atoi(categoryIDStr)
-		if err != nil {
-			models.RespondError(c, http.StatusBadRequest, "INVALID_CATEGORY_ID", "Invalid category_id parameter")
-			return
-		}
-		filter.CategoryID = &categoryID
+	if !parseSort(c, filter, db.ParseProductSort) || !parseProductFilters(c, filter) {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
 	}
 
-	products, total, err := h.DB.ListProducts(c.Request.Context(), pagination, filter)
+	fields, ok := parseFields(c, db.ProductFields, db.FieldCategories)
+	if !ok {
+		return
+	}
+	filter.Fields = fields
+
+	products, total, err := h.Products.List(c.Request.Context(), actor(c), pagination, filter, currency)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "SEARCH_ERROR", "Failed to search products")
+		c.Error(err)
 		return
 	}
 
-	response := models.ProductListResponse{
+	response, err := sparse(c, models.ProductListResponse{
 		Products:   products,
 		Total:      total,
 		Page:       pagination.Page,
 		Limit:      pagination.Limit,
 		TotalPages: db.CalculateTotalPages(total, pagination.Limit),
+	}, "products", fields)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
 	models.RespondSuccess(c, http.StatusOK, response)
@@ -93,11 +117,12 @@ func (h *Handler) searchProducts(c *gin.Context, query string) {
 func (h *Handler) searchCategories(c *gin.Context, query string) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.SearchDefaultLimit)))
 
 	pagination := &db.PaginationParams{
-		Page:  page,
-		Limit: limit,
+		Page:     page,
+		Limit:    limit,
+		MaxLimit: h.Pagination.MaxLimit,
 	}
 
 	// Parse filter parameters
@@ -105,19 +130,32 @@ func (h *Handler) searchCategories(c *gin.Context, query string) {
 		SortBy:    c.DefaultQuery("sort_by", "name"),
 		SortOrder: c.DefaultQuery("sort_order", "asc"),
 	}
+	if !parseSort(c, filter, db.ParseCategorySort) {
+		return
+	}
+
+	fields, ok := parseFields(c, db.CategoryFields)
+	if !ok {
+		return
+	}
+	filter.Fields = fields
 
-	categories, total, err := h.DB.SearchCategories(c.Request.Context(), query, pagination, filter)
+	categories, total, err := h.Categories.Search(c.Request.Context(), actor(c), query, pagination, filter)
 	if err != nil {
-		models.RespondError(c, http.StatusInternalServerError, "SEARCH_ERROR", "Failed to search categories")
+		c.Error(err)
 		return
 	}
 
-	response := models.CategoryListResponse{
+	response, err := sparse(c, models.CategoryListResponse{
 		Categories: categories,
 		Total:      total,
 		Page:       pagination.Page,
 		Limit:      pagination.Limit,
 		TotalPages: db.CalculateTotalPages(total, pagination.Limit),
+	}, "categories", fields)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
 	models.RespondSuccess(c, http.StatusOK, response)
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateStockMovement godoc
+// @Summary      Registrar movimiento de stock
+// @Description  Suma al stock del producto una cantidad con signo y lo registra en el libro de movimientos con su motivo, una referencia opcional (ej. número de pedido) y el usuario que lo cargó. Motivos: sale y damage restan (cantidad negativa), restock y return suman (positiva), adjustment admite ambos signos. El producto se bloquea mientras se aplica, así que movimientos simultáneos se aplican de a uno; si el stock quedara negativo se rechaza sin cambiar nada. También queda en el historial del producto. Requiere rol de administrador. Emite evento WebSocket 'product:stock_moved'.
+// @Tags         productos
+// @Accept       json
+// @Produce      json
+// @Param        id        path  int                                true  "ID del producto"
+// @Param        movement  body  models.StockMovementCreateRequest  true  "Movimiento a registrar"
+// @Success      201  {object}  models.ApiResponse{data=models.StockMovement}  "Movimiento registrado, con el stock resultante"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID o datos inválidos, o el signo de la cantidad no corresponde al motivo"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Stock insuficiente"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/{id}/stock-movements [post]
+func (h *Handler) CreateStockMovement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid product ID")
+		return
+	}
+
+	var req models.StockMovementCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.RespondBindingError(c, err)
+		return
+	}
+
+	movement, err := h.Stock.Move(c.Request.Context(), actor(c), id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusCreated, movement)
+}
+
+// ListStockMovements godoc
+// @Summary      Listar movimientos de stock
+// @Description  Obtiene el libro de movimientos de stock de un producto, del más reciente al más antiguo. Pagina por offset (page/limit) o con cursor, y opcionalmente filtra por motivo.
+// @Tags         productos
+// @Accept       json
+// @Produce      json
+// @Param        id             path   int     true   "ID del producto"
+// @Param        reason         query  string  false  "Motivo (sale, restock, adjustment, return, damage)"
+// @Param        page           query  int     false  "Número de página (paginación por offset)"  default(1)
+// @Param        limit          query  int     false  "Movimientos por página (máximo 100)"  default(10)
+// @Param        cursor         query  string  false  "Cursor opaco para paginación por cursor; vacío pide la primera página"
+// @Param        include_total  query  bool    false  "Con cursor, incluir el total de movimientos"  default(false)
+// @Success      200  {object}  models.ApiResponse{data=models.StockMovementListResponse}  "Movimientos del producto (con cursor: models.StockMovementCursorResponse)"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID, motivo o cursor inválidos"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "Producto no encontrado"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /products/{id}/stock-movements [get]
+func (h *Handler) ListStockMovements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid product ID")
+		return
+	}
+	reason := c.Query("reason")
+
+	keyset, err := h.cursorPagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if keyset != nil {
+		movements, total, err := h.Stock.List(c.Request.Context(), actor(c), id, reason, keyset)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		models.RespondSuccess(c, http.StatusOK, models.StockMovementCursorResponse{
+			Movements:  movements,
+			CursorPage: cursorPage(keyset, total),
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.DefaultLimit)))
+	pagination := &db.PaginationParams{Page: page, Limit: limit, MaxLimit: h.Pagination.MaxLimit}
+
+	movements, total, err := h.Stock.List(c.Request.Context(), actor(c), id, reason, pagination)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, models.StockMovementListResponse{
+		Movements:  movements,
+		Total:      total,
+		Page:       pagination.Page,
+		Limit:      pagination.Limit,
+		TotalPages: db.CalculateTotalPages(total, pagination.Limit),
+	})
+}
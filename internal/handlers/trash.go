@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ListTrashedProducts godoc
+// @Summary      Listar productos en la papelera
+// @Description  Obtiene los productos eliminados que todavía pueden restaurarse, del más reciente al más antiguo. Requiere rol de administrador.
+// @Tags         papelera
+// @Accept       json
+// @Produce      json
+// @Param        page   query     int  false  "Número de página"  default(1)
+// @Param        limit  query     int  false  "Productos por página (máximo 100)"  default(10)
+// @Success      200  {object}  models.ApiResponse{data=models.ProductListResponse}  "Productos eliminados"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /trash/products [get]
+func (h *Handler) ListTrashedProducts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.Pagination.DefaultLimit)))
+
+	pagination := &db.PaginationParams{
+		Page:     page,
+		Limit:    limit,
+		MaxLimit: h.Pagination.MaxLimit,
+	}
+
+	products, total, err := h.Trash.Products(c.Request.Context(), actor(c), pagination)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := models.ProductListResponse{
+		Products:   products,
+		Total:      total,
+		Page:       pagination.Page,
+		Limit:      pagination.Limit,
+		TotalPages: db.CalculateTotalPages(total, pagination.Limit),
+	}
+
+	models.RespondSuccess(c, http.StatusOK, response)
+}
+
+// ListTrashedCategories godoc
+// @Summary      Listar categorías en la papelera
+// @Description  Obtiene las categorías eliminadas que todavía pueden restaurarse, de la más reciente a la más antigua. Requiere rol de administrador.
+// @Tags         papelera
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.ApiResponse{data=models.CategoryListResponse}  "Categorías eliminadas"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /trash/categories [get]
+func (h *Handler) ListTrashedCategories(c *gin.Context) {
+	categories, err := h.Trash.Categories(c.Request.Context(), actor(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := models.CategoryListResponse{
+		Categories: categories,
+		Total:      len(categories),
+	}
+
+	models.RespondSuccess(c, http.StatusOK, response)
+}
+
+// RestoreProduct godoc
+// @Summary      Restaurar producto
+// @Description  Saca un producto de la papelera junto con sus categorías e historial. Requiere rol de administrador. Emite evento WebSocket 'product:restored'.
+// @Tags         papelera
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID del producto"
+// @Success      200  {object}  models.ApiResponse{data=models.Product}  "Producto restaurado"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "El producto no está en la papelera"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /trash/products/{id}/restore [post]
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid product ID")
+		return
+	}
+
+	product, err := h.Trash.RestoreProduct(c.Request.Context(), actor(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, product)
+}
+
+// RestoreCategory godoc
+// @Summary      Restaurar categoría
+// @Description  Saca una categoría de la papelera; los productos que la tenían la recuperan. Requiere rol de administrador. Emite evento WebSocket 'category:restored'.
+// @Tags         papelera
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "ID de la categoría"
+// @Success      200  {object}  models.ApiResponse{data=models.Category}  "Categoría restaurada"
+// @Failure      400  {object}  models.ApiResponse{error=models.ApiError}  "ID inválido"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      404  {object}  models.ApiResponse{error=models.ApiError}  "La categoría no está en la papelera"
+// @Failure      409  {object}  models.ApiResponse{error=models.ApiError}  "Ya existe otra categoría con ese nombre"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /trash/categories/{id}/restore [post]
+func (h *Handler) RestoreCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		models.RespondError(c, http.StatusBadRequest, "INVALID_ID", "Invalid category ID")
+		return
+	}
+
+	category, err := h.Trash.RestoreCategory(c.Request.Context(), actor(c), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, category)
+}
+
+// PurgeTrash godoc
+// @Summary      Purgar papelera
+// @Description  Elimina definitivamente los productos y categorías que llevan en la papelera más que el período de retención (trash.retention), junto con su historial y relaciones. Requiere rol de administrador. Emite evento WebSocket 'trash:purged'.
+// @Tags         papelera
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.ApiResponse{data=models.TrashPurgeResponse}  "Resultado de la purga"
+// @Failure      401  {object}  models.ApiResponse{error=models.ApiError}  "No autenticado"
+// @Failure      403  {object}  models.ApiResponse{error=models.ApiError}  "Requiere rol de administrador"
+// @Failure      500  {object}  models.ApiResponse{error=models.ApiError}  "Error interno del servidor"
+// @Security     BearerAuth
+// @Router       /trash/purge [post]
+func (h *Handler) PurgeTrash(c *gin.Context) {
+	result, err := h.Trash.Purge(c.Request.Context(), actor(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	models.RespondSuccess(c, http.StatusOK, result)
+}
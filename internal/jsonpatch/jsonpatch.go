@@ -0,0 +1,436 @@
+// Package jsonpatch applies JSON Merge Patches (RFC 7396) and JSON Patches
+// (RFC 6902) to JSON documents.
+//
+// Documents are decoded with json.Number, so prices and other decimals pass
+// through a patch unchanged. A patch either applies as a whole or returns an
+// error and leaves the document alone.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types that select each kind of patch
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// MaxOperations bounds the operations of a single JSON Patch
+const MaxOperations = 100
+
+var (
+	// ErrInvalid is returned for patches that are not well formed
+	ErrInvalid = errors.New("invalid patch")
+	// ErrNotApplicable is returned when an operation does not fit the
+	// document, e.g. it removes a member that does not exist
+	ErrNotApplicable = errors.New("patch cannot be applied")
+	// ErrTestFailed is returned when a test operation does not match
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// MergePatch applies an RFC 7396 merge patch to doc: members of patch
+// replace those of doc, null removes them and objects merge recursively
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = merge(object[name], value)
+		}
+	}
+	return object
+}
+
+// Apply applies the operations of an RFC 6902 JSON Patch to doc in order
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations", ErrInvalid)
+	}
+	if len(ops) > MaxOperations {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", ErrInvalid, MaxOperations)
+	}
+
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, raw := range ops {
+		op, err := parseOperation(raw)
+		if err == nil {
+			root, err = op.apply(root)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// operation is a parsed JSON Patch operation
+type operation struct {
+	op    string
+	path  []string
+	from  []string
+	value interface{}
+}
+
+func parseOperation(raw map[string]json.RawMessage) (*operation, error) {
+	var op operation
+	if err := json.Unmarshal(raw["op"], &op.op); err != nil || op.op == "" {
+		return nil, fmt.Errorf("%w: op is required", ErrInvalid)
+	}
+
+	var err error
+	if op.path, err = pointerMember(raw, "path"); err != nil {
+		return nil, err
+	}
+
+	switch op.op {
+	case "add", "replace", "test":
+		value, ok := raw["value"]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires a value", ErrInvalid, op.op)
+		}
+		if op.value, err = decode(value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	case "move", "copy":
+		if op.from, err = pointerMember(raw, "from"); err != nil {
+			return nil, err
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalid, op.op)
+	}
+	return &op, nil
+}
+
+// pointerMember reads and parses the JSON Pointer in raw[name]
+func pointerMember(raw map[string]json.RawMessage, name string) ([]string, error) {
+	var pointer string
+	if err := json.Unmarshal(raw[name], &pointer); err != nil {
+		return nil, fmt.Errorf("%w: %s is required", ErrInvalid, name)
+	}
+	return parsePointer(pointer)
+}
+
+// JSON Pointer escapes for "~" and "/" in reference tokens
+var (
+	escape   = strings.NewReplacer("~", "~0", "/", "~1")
+	unescape = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q is not a JSON Pointer", ErrInvalid, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+func (op *operation) apply(root interface{}) (interface{}, error) {
+	switch op.op {
+	case "add":
+		return put(root, op.path, op.value, true)
+	case "remove":
+		if len(op.path) == 0 {
+			return nil, fmt.Errorf("%w: cannot remove the whole document", ErrNotApplicable)
+		}
+		root, _, err := remove(root, op.path)
+		return root, err
+	case "replace":
+		if _, err := get(root, op.path); err != nil {
+			return nil, err
+		}
+		return put(root, op.path, op.value, false)
+	case "move":
+		if isPrefix(op.from, op.path) && len(op.from) < len(op.path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrNotApplicable)
+		}
+		root, value, err := remove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return put(root, op.path, value, true)
+	case "copy":
+		value, err := get(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return put(root, op.path, clone(value), true)
+	default: // test
+		value, err := get(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, fmt.Errorf("%w: the value at %s differs", ErrTestFailed, pointerString(op.path))
+		}
+		return root, nil
+	}
+}
+
+// get returns the value at path
+func get(node interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[token]
+			if !ok {
+				return nil, notFound(path[:i+1])
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(n)-1, path[:i+1])
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, notFound(path[:i+1])
+		}
+	}
+	return node, nil
+}
+
+// put sets the value at path and returns the updated node. With insert, as
+// in add, values are inserted into arrays and "-" appends; otherwise the
+// value replaces the existing one.
+func put(node interface{}, path []string, value interface{}, insert bool) (interface{}, error) {
+	return putAt(node, path, 0, value, insert)
+}
+
+func putAt(node interface{}, path []string, depth int, value interface{}, insert bool) (interface{}, error) {
+	if depth == len(path) {
+		return value, nil
+	}
+	token, last := path[depth], depth == len(path)-1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok && !(last && insert) {
+			return nil, notFound(path[:depth+1])
+		}
+		child, err := putAt(child, path, depth+1, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []interface{}:
+		if last && insert {
+			if token == "-" {
+				return append(n, value), nil
+			}
+			index, err := arrayIndex(token, len(n), path)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:index], append([]interface{}{value}, n[index:]...)...), nil
+		}
+		index, err := arrayIndex(token, len(n)-1, path[:depth+1])
+		if err != nil {
+			return nil, err
+		}
+		child, err := putAt(n[index], path, depth+1, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		n[index] = child
+		return n, nil
+	default:
+		return nil, notFound(path[:depth+1])
+	}
+}
+
+// remove deletes the value at path, returning the updated node and the value
+func remove(node interface{}, path []string) (interface{}, interface{}, error) {
+	return removeAt(node, path, 0)
+}
+
+func removeAt(node interface{}, path []string, depth int) (interface{}, interface{}, error) {
+	token, last := path[depth], depth == len(path)-1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, notFound(path[:depth+1])
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := removeAt(child, path, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+		return n, removed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(n)-1, path[:depth+1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[index]
+			return append(n[:index], n[index+1:]...), removed, nil
+		}
+		child, removed, err := removeAt(n[index], path, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[index] = child
+		return n, removed, nil
+	default:
+		return nil, nil, notFound(path[:depth+1])
+	}
+}
+
+// arrayIndex parses an array index token no greater than max
+func arrayIndex(token string, max int, path []string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %s is not an array index", ErrNotApplicable, pointerString(path))
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("%w: %s is out of bounds", ErrNotApplicable, pointerString(path))
+	}
+	return index, nil
+}
+
+func notFound(path []string) error {
+	return fmt.Errorf("%w: %s does not exist", ErrNotApplicable, pointerString(path))
+}
+
+func pointerString(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(escape.Replace(token))
+	}
+	return b.String()
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decode parses a single JSON value, keeping numbers as json.Number
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+func clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			out[name] = clone(member)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = clone(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// equal compares JSON values; numbers are equal when their values are
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		rx, okx := new(big.Rat).SetString(x.String())
+		ry, oky := new(big.Rat).SetString(y.String())
+		return okx && oky && rx.Cmp(ry) == 0
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, member := range x {
+			other, ok := y[name]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
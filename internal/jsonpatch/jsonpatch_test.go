@@ -0,0 +1,429 @@
+package jsonpatch_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/jsonpatch"
+)
+
+// canonical re-encodes a JSON value with sorted keys, keeping numbers as written
+func canonical(t *testing.T, data string) string {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		// RFC 6902 appendix A
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eats"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eats", "grass"]}`,
+		},
+		{
+			name: "A.8 testing a value: success",
+			doc:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"},
+				{"op": "test", "path": "/foo/1", "value": 2}]`,
+			want: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:  "A.9 testing a value: error",
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			err:   jsonpatch.ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:  "A.12 adding to a nonexistent target",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name: "A.14 ~ escape ordering",
+			doc:  `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10},
+				{"op": "test", "path": "/~1", "value": 9}]`,
+			want: `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:  "A.15 comparing strings and numbers",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			err:   jsonpatch.ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+
+		// Pointers
+		{
+			name:  "~1 addresses a member with a slash",
+			doc:   `{"a/b": 1}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			want:  `{"a/b": 2}`,
+		},
+		{
+			name:  "~0 addresses a member with a tilde",
+			doc:   `{"m~n": 1}`,
+			patch: `[{"op": "remove", "path": "/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "empty reference token",
+			doc:   `{"": 1}`,
+			patch: `[{"op": "replace", "path": "/", "value": 2}]`,
+			want:  `{"": 2}`,
+		},
+		{
+			name:  "pointer without a leading slash",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "remove", "path": "foo"}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "- appends to an array",
+			doc:   `{"tags": ["a"]}`,
+			patch: `[{"op": "add", "path": "/tags/-", "value": "b"}, {"op": "add", "path": "/tags/-", "value": "c"}]`,
+			want:  `{"tags": ["a", "b", "c"]}`,
+		},
+		{
+			name:  "- is only valid as the target of add",
+			doc:   `{"tags": ["a"]}`,
+			patch: `[{"op": "remove", "path": "/tags/-"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "adding at the array length appends",
+			doc:   `{"tags": ["a"]}`,
+			patch: `[{"op": "add", "path": "/tags/1", "value": "b"}]`,
+			want:  `{"tags": ["a", "b"]}`,
+		},
+		{
+			name:  "adding past the array length",
+			doc:   `{"tags": ["a"]}`,
+			patch: `[{"op": "add", "path": "/tags/2", "value": "b"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "array indexes have no leading zeros",
+			doc:   `{"tags": ["a", "b"]}`,
+			patch: `[{"op": "remove", "path": "/tags/01"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "replacing a missing member",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "replace", "path": "/bar", "value": 2}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "replacing the whole document",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "replace", "path": "", "value": [1]}]`,
+			want:  `[1]`,
+		},
+		{
+			name:  "removing the whole document",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "remove", "path": ""}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+
+		// move and copy
+		{
+			name:  "copying a value",
+			doc:   `{"a": {"b": [1, 2]}}`,
+			patch: `[{"op": "copy", "from": "/a/b", "path": "/c"}]`,
+			want:  `{"a": {"b": [1, 2]}, "c": [1, 2]}`,
+		},
+		{
+			name: "copies are independent of the original",
+			doc:  `{"a": {"b": [1, 2]}}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/c"},
+				{"op": "add", "path": "/c/b/-", "value": 3}]`,
+			want: `{"a": {"b": [1, 2]}, "c": {"b": [1, 2, 3]}}`,
+		},
+		{
+			name:  "copying into an array",
+			doc:   `{"a": [1, 2, 3]}`,
+			patch: `[{"op": "copy", "from": "/a/2", "path": "/a/0"}]`,
+			want:  `{"a": [3, 1, 2, 3]}`,
+		},
+		{
+			name:  "moving a value to the same place",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a"}]`,
+			want:  `{"a": 1}`,
+		},
+		{
+			name:  "moving a value into itself",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "moving from a missing member",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "move", "from": "/b", "path": "/c"}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "copy requires from",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "copy", "path": "/c"}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+
+		// test and numbers
+		{
+			name:  "numbers are equal by value",
+			doc:   `{"price": 10.50, "stock": 10}`,
+			patch: `[{"op": "test", "path": "/price", "value": 10.5}, {"op": "test", "path": "/stock", "value": 1e1}]`,
+			want:  `{"price": 10.50, "stock": 10}`,
+		},
+		{
+			name:  "different numbers",
+			doc:   `{"price": 10.50}`,
+			patch: `[{"op": "test", "path": "/price", "value": 10.51}]`,
+			err:   jsonpatch.ErrTestFailed,
+		},
+		{
+			name:  "objects are equal regardless of member order",
+			doc:   `{"a": {"x": 1, "y": [true, null]}}`,
+			patch: `[{"op": "test", "path": "/a", "value": {"y": [true, null], "x": 1.0}}]`,
+			want:  `{"a": {"x": 1, "y": [true, null]}}`,
+		},
+		{
+			name:  "arrays are equal only in the same order",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "test", "path": "/a", "value": [2, 1]}]`,
+			err:   jsonpatch.ErrTestFailed,
+		},
+		{
+			name:  "null is not missing",
+			doc:   `{"a": null}`,
+			patch: `[{"op": "test", "path": "/b", "value": null}]`,
+			err:   jsonpatch.ErrNotApplicable,
+		},
+		{
+			name:  "decimals pass through unchanged",
+			doc:   `{"price": 10.50, "cost": 0.10}`,
+			patch: `[{"op": "replace", "path": "/price", "value": 12.30}]`,
+			want:  `{"price": 12.30, "cost": 0.10}`,
+		},
+
+		// Malformed patches
+		{
+			name:  "not an array",
+			doc:   `{}`,
+			patch: `{"op": "add", "path": "/a", "value": 1}`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "missing op",
+			doc:   `{}`,
+			patch: `[{"path": "/a", "value": 1}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "unknown op",
+			doc:   `{}`,
+			patch: `[{"op": "merge", "path": "/a", "value": 1}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "missing path",
+			doc:   `{}`,
+			patch: `[{"op": "add", "value": 1}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "add without a value",
+			doc:   `{}`,
+			patch: `[{"op": "add", "path": "/a"}]`,
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "null is a value",
+			doc:   `{}`,
+			patch: `[{"op": "add", "path": "/a", "value": null}]`,
+			want:  `{"a": null}`,
+		},
+		{
+			name:  "too many operations",
+			doc:   `{}`,
+			patch: "[" + strings.TrimSuffix(strings.Repeat(`{"op": "test", "path": "", "value": {}},`, jsonpatch.MaxOperations+1), ",") + "]",
+			err:   jsonpatch.ErrInvalid,
+		},
+		{
+			name:  "most operations",
+			doc:   `{}`,
+			patch: "[" + strings.TrimSuffix(strings.Repeat(`{"op": "test", "path": "", "value": {}},`, jsonpatch.MaxOperations), ",") + "]",
+			want:  `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonpatch.Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Apply returned %q, %v; want %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply returned %v", err)
+			}
+			if canonical(t, string(got)) != canonical(t, tt.want) {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	doc := []byte(`{"stock": 5, "tags": ["a"]}`)
+	original := bytes.Clone(doc)
+
+	_, err := jsonpatch.Apply(doc, []byte(`[
+		{"op": "replace", "path": "/stock", "value": 7},
+		{"op": "add", "path": "/tags/-", "value": "b"},
+		{"op": "test", "path": "/stock", "value": 5}
+	]`))
+	if !errors.Is(err, jsonpatch.ErrTestFailed) {
+		t.Fatalf("Apply returned %v, want %v", err, jsonpatch.ErrTestFailed)
+	}
+	if want := "operation 2: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("error %q does not name the failing operation (%q)", err, want)
+	}
+	if !bytes.Equal(doc, original) {
+		t.Errorf("document changed to %s", doc)
+	}
+}
+
+// TestMergePatch runs the examples of RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// Removing a member that is not there is not an error
+		{`{"a":1}`, `{"b":null}`, `{"a":1}`},
+		// Decimals keep their digits
+		{`{"price":10.50}`, `{"cost":0.10}`, `{"cost":0.10,"price":10.50}`},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", i, tt.patch), func(t *testing.T) {
+			got, err := jsonpatch.MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch returned %v", err)
+			}
+			if canonical(t, string(got)) != canonical(t, tt.want) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchErrors(t *testing.T) {
+	_, err := jsonpatch.MergePatch([]byte(`{"a":1}`), []byte(`{"a":`))
+	if !errors.Is(err, jsonpatch.ErrInvalid) {
+		t.Errorf("truncated patch: got %v, want %v", err, jsonpatch.ErrInvalid)
+	}
+	_, err = jsonpatch.MergePatch([]byte(`{"a":1}`), []byte(`{"a":2} {"b":3}`))
+	if !errors.Is(err, jsonpatch.ErrInvalid) {
+		t.Errorf("trailing data: got %v, want %v", err, jsonpatch.ErrInvalid)
+	}
+}
@@ -1,14 +1,24 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/jsonpatch"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorHandler is a middleware that catches panics and formats errors consistently
+// ErrorHandler is a middleware that catches panics and formats errors consistently.
+// Handlers report failures with c.Error(err) and return; the error is mapped
+// to a status code and error code by MapError.
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
@@ -31,24 +41,81 @@ func ErrorHandler() gin.HandlerFunc {
 		c.Next()
 
 		// Check if there were any errors during request processing
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			err := c.Errors.Last().Err
 
-			statusCode := c.Writer.Status()
-			if statusCode == http.StatusOK {
-				statusCode = http.StatusInternalServerError
+			// Validation failures from the service layer carry field details
+			if fields := models.FieldErrorsFromBinding(err); len(fields) > 0 {
+				models.RespondBindingError(c, err)
+				return
 			}
 
-			models.RespondError(
-				c,
-				statusCode,
-				"REQUEST_ERROR",
-				err.Error(),
-			)
+			// Failed If-Match: send the current representation and its ETag
+			var stale *service.PreconditionFailedError
+			if errors.As(err, &stale) {
+				c.Header("ETag", models.ETag(stale.Version))
+				models.RespondErrorWithData(c, http.StatusPreconditionFailed, "PRECONDITION_FAILED",
+					"The resource was modified since it was read; retry with the current version", stale.Current)
+				return
+			}
+
+			statusCode, code, message := MapError(err)
+			if statusCode == http.StatusInternalServerError {
+				log.Printf("[%s] %s - %v", c.Request.Method, c.Request.URL.Path, err)
+			} else if detail := db.Detail(err); detail != "" {
+				// Constraint details name stored values; they are logged, never sent
+				log.Printf("[%s] %s - %v: %s", c.Request.Method, c.Request.URL.Path, err, detail)
+			}
+
+			models.RespondError(c, statusCode, code, message)
 		}
 	}
 }
 
+// MapError translates a domain error into an HTTP status, error code and message.
+// Unknown errors become a generic 500 so internal details are not leaked.
+func MapError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password"
+	case errors.Is(err, service.ErrUnauthenticated):
+		return http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required"
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden, "INSUFFICIENT_PERMISSIONS", "You don't have permission to access this resource"
+	case errors.Is(err, db.ErrEmailExists):
+		return http.StatusConflict, "EMAIL_EXISTS", "Email already registered"
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound, "NOT_FOUND", sentence(err.Error())
+	case errors.Is(err, db.ErrConflict):
+		return http.StatusConflict, "CONFLICT", sentence(strings.TrimPrefix(err.Error(), db.ErrConflict.Error()+": "))
+	case errors.Is(err, db.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, "PRECONDITION_FAILED", sentence(err.Error())
+	case errors.Is(err, db.ErrInvalidReference):
+		return http.StatusUnprocessableEntity, "INVALID_REFERENCE", sentence(strings.TrimPrefix(err.Error(), db.ErrInvalidReference.Error()+": "))
+	case errors.Is(err, db.ErrInvalidCursor):
+		return http.StatusBadRequest, "INVALID_CURSOR", sentence(err.Error())
+	case errors.Is(err, jsonpatch.ErrInvalid):
+		return http.StatusBadRequest, "INVALID_PATCH", sentence(err.Error())
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return http.StatusConflict, "PATCH_TEST_FAILED", sentence(err.Error())
+	case errors.Is(err, jsonpatch.ErrNotApplicable):
+		return http.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE", sentence(err.Error())
+	case errors.Is(err, db.ErrValidation):
+		return http.StatusBadRequest, "VALIDATION_ERROR", sentence(strings.TrimPrefix(err.Error(), db.ErrValidation.Error()+": "))
+	default:
+		return http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred"
+	}
+}
+
+// sentence capitalizes the first letter of an error message
+func sentence(msg string) string {
+	r, size := utf8.DecodeRuneInString(msg)
+	if r == utf8.RuneError {
+		return msg
+	}
+	return string(unicode.ToUpper(r)) + msg[size:]
+}
+
 // Logger is a simple request logger middleware
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
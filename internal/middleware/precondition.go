@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch rejects writes without an If-Match header with
+// 428 Precondition Required, forcing clients to send the ETag they read.
+// When required is false it lets every request through.
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetHeader("If-Match") == "" {
+			models.RespondError(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "This request must include an If-Match header with the resource ETag")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
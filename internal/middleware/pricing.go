@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// PriceFormat makes the responses of every request encode amounts in the
+// configured JSON format (pricing.json_format)
+func PriceFormat(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		models.SetPriceFormat(c, format)
+		c.Next()
+	}
+}
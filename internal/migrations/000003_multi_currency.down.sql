@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION fn_product_history() RETURNS trigger AS $$
+BEGIN
+    IF (OLD.price IS DISTINCT FROM NEW.price OR OLD.stock IS DISTINCT FROM NEW.stock) THEN
+        INSERT INTO product_history (product_id, price, stock, changed_at)
+        VALUES (NEW.id, NEW.price, NEW.stock, now());
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE product_history DROP COLUMN IF EXISTS currency;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,35 @@
+-- MIGRATION: 0003_multi_currency.up.sql
+-- PURPOSE: Currency per product price and admin-managed exchange rates.
+
+ALTER TABLE products
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'ARS' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE product_history
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'ARS';
+
+-- 1 base_currency = rate quote_currency, valid from effective_at
+CREATE TABLE exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency CHAR(3) NOT NULL CHECK (base_currency ~ '^[A-Z]{3}$'),
+    quote_currency CHAR(3) NOT NULL CHECK (quote_currency ~ '^[A-Z]{3}$'),
+    rate NUMERIC(20,8) NOT NULL CHECK (rate > 0),
+    effective_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (base_currency <> quote_currency),
+    UNIQUE (base_currency, quote_currency, effective_at)
+);
+
+CREATE INDEX idx_exchange_rates_lookup ON exchange_rates (base_currency, quote_currency, effective_at DESC);
+
+-- History now also records currency changes
+CREATE OR REPLACE FUNCTION fn_product_history() RETURNS trigger AS $$
+BEGIN
+    IF (OLD.price IS DISTINCT FROM NEW.price
+        OR OLD.stock IS DISTINCT FROM NEW.stock
+        OR OLD.currency IS DISTINCT FROM NEW.currency) THEN
+        INSERT INTO product_history (product_id, price, currency, stock, changed_at)
+        VALUES (NEW.id, NEW.price, NEW.currency, NEW.stock, now());
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,14 @@
+-- MIGRATION: 0004_soft_delete.down.sql
+-- Rows still in the trash are purged; they would otherwise come back to life
+
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+DROP INDEX IF EXISTS idx_categories_name_active;
+
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+ALTER TABLE categories DROP COLUMN deleted_at;
+ALTER TABLE products DROP COLUMN deleted_at;
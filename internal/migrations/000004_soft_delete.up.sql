@@ -0,0 +1,14 @@
+-- MIGRATION: 0004_soft_delete.up.sql
+-- PURPOSE: Soft delete products and categories. Deleted rows keep their
+--          category links and history until they are purged.
+
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Names only need to be unique among live categories
+ALTER TABLE categories DROP CONSTRAINT categories_name_key;
+CREATE UNIQUE INDEX idx_categories_name_active ON categories(name) WHERE deleted_at IS NULL;
+
+-- Trash listings and the purge job look rows up by deletion time
+CREATE INDEX idx_products_deleted_at ON products(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
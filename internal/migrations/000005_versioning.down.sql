@@ -0,0 +1,4 @@
+-- MIGRATION: 0005_versioning.down.sql
+
+ALTER TABLE categories DROP COLUMN version;
+ALTER TABLE products DROP COLUMN version;
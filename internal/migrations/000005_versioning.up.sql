@@ -0,0 +1,6 @@
+-- MIGRATION: 0005_versioning.up.sql
+-- PURPOSE: Version counter for optimistic concurrency (ETag / If-Match).
+--          Every write to a row increments it.
+
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN version INT NOT NULL DEFAULT 1;
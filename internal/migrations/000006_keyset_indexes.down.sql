@@ -0,0 +1,8 @@
+-- MIGRATION: 0006_keyset_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_product_history_keyset;
+DROP INDEX IF EXISTS idx_categories_keyset_name;
+DROP INDEX IF EXISTS idx_products_keyset_created_at;
+DROP INDEX IF EXISTS idx_products_keyset_stock;
+DROP INDEX IF EXISTS idx_products_keyset_price;
+DROP INDEX IF EXISTS idx_products_keyset_name;
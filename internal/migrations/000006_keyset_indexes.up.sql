@@ -0,0 +1,16 @@
+-- MIGRATION: 0006_keyset_indexes.up.sql
+-- PURPOSE: Keyset pagination reads "rows after (value, id)" in sort order.
+--          Indexes on (sort column, id) let each page start with an index
+--          seek instead of scanning and sorting everything before it.
+
+-- Live products by every sortable field
+CREATE INDEX idx_products_keyset_name ON products(name, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_products_keyset_price ON products(price, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_products_keyset_stock ON products(stock, id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_products_keyset_created_at ON products(created_at, id) WHERE deleted_at IS NULL;
+
+-- Live categories are listed by name; the unique index covers name alone
+CREATE INDEX idx_categories_keyset_name ON categories(name, id) WHERE deleted_at IS NULL;
+
+-- History of one product, newest first
+CREATE INDEX idx_product_history_keyset ON product_history(product_id, changed_at DESC, id DESC);
@@ -0,0 +1,4 @@
+-- MIGRATION: 0007_product_sku.down.sql
+
+DROP INDEX IF EXISTS idx_products_sku_active;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
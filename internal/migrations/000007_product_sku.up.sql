@@ -0,0 +1,8 @@
+-- MIGRATION: 0007_product_sku.up.sql
+-- PURPOSE: External SKU of a product, e.g. the supplier's code. Bulk
+--          imports use it to update products instead of duplicating them.
+
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+
+-- Like category names, SKUs only need to be unique among live products
+CREATE UNIQUE INDEX idx_products_sku_active ON products(sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- MIGRATION: 0008_stock_movements.down.sql
+
+DROP TABLE IF EXISTS stock_movements;
@@ -0,0 +1,18 @@
+-- MIGRATION: 0008_stock_movements.up.sql
+-- PURPOSE: Ledger of stock movements (sales, restocks, returns...). Each
+--          entry records why the stock of a product changed and by whom;
+--          product_history keeps snapshotting the resulting values.
+
+CREATE TABLE stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    reason TEXT NOT NULL CHECK (reason IN ('sale', 'restock', 'adjustment', 'return', 'damage')),
+    reference TEXT,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    stock_after INT NOT NULL CHECK (stock_after >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Movements of a product, newest first
+CREATE INDEX idx_stock_movements_product ON stock_movements (product_id, created_at DESC, id DESC);
@@ -0,0 +1,10 @@
+-- MIGRATION: 0009_timezone_current_database.down.sql
+
+-- bsmart_dev keeps the setting made by 0002
+DO $$
+BEGIN
+    IF current_database() <> 'bsmart_dev' THEN
+        EXECUTE format('ALTER DATABASE %I RESET TIMEZONE', current_database());
+    END IF;
+END
+$$;
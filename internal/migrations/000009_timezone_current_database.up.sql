@@ -0,0 +1,10 @@
+-- MIGRATION: 0009_timezone_current_database.up.sql
+-- PURPOSE: 0002 sets the time zone of bsmart_dev by name. Apply the same
+--          setting to whichever database is being migrated, so test and
+--          production databases with other names get it too.
+
+DO $$
+BEGIN
+    EXECUTE format('ALTER DATABASE %I SET TIMEZONE TO %L', current_database(), 'America/Argentina/Buenos_Aires');
+END
+$$;
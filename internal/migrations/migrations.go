@@ -0,0 +1,159 @@
+// Package migrations embeds the SQL migrations so they can be applied from Go
+// (tests, tooling) as well as with the migrate CLI used by docker-compose.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// superseded are migrations Up records as applied without running them.
+// 000002 sets the time zone of bsmart_dev by name; 000009 sets it on the
+// database being migrated, so databases with other names do not need a
+// bsmart_dev next to them.
+var superseded = map[uint]bool{2: true}
+
+// Migration is a numbered schema change with its up and down scripts
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// List returns the embedded migrations sorted by version
+func List() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		// Files follow golang-migrate naming: 000001_name.up.sql / 000001_name.down.sql
+		name := entry.Name()
+		base, direction, ok := splitDirection(name)
+		if !ok {
+			continue
+		}
+		prefix, label, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		m, exists := byVersion[uint(version)]
+		if !exists {
+			m = &Migration{Version: uint(version), Name: label}
+			byVersion[uint(version)] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func splitDirection(name string) (string, string, bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// Up applies every pending migration. Progress is stored in the same
+// schema_migrations table golang-migrate uses, so both can be mixed.
+func Up(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := List()
+	if err != nil {
+		return err
+	}
+
+	_, err = pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := currentVersion(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if int64(m.Version) <= current {
+			continue
+		}
+		if err := apply(ctx, pool, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func currentVersion(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var version int64
+	var dirty bool
+	err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d; fix it and force the version with the migrate CLI", version)
+	}
+	return version, nil
+}
+
+func apply(ctx context.Context, pool *pgxpool.Pool, m Migration) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if !superseded[m.Version] {
+		if _, err := tx.Exec(ctx, m.Up); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(m.Version)); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
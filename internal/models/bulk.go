@@ -0,0 +1,35 @@
+package models
+
+import "github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+
+// ProductBulkUpdateRequest applies the same changes to the products picked
+// by IDs or by Filter, an expression in the ?filter= language of GET
+// /products. At most one price change and one stock change may be given.
+type ProductBulkUpdateRequest struct {
+	IDs                []int         `json:"ids" binding:"omitempty,unique_ids,dive,gt=0" example:"1,2,3"`
+	Filter             string        `json:"filter" example:"category IN (1) AND stock > 0"`
+	SetPrice           *money.Amount `json:"set_price" swaggertype:"number" example:"19.99" binding:"omitempty,gte=0,price_precision"`
+	AdjustPricePercent *money.Amount `json:"adjust_price_percent" swaggertype:"number" example:"-10" binding:"omitempty,gte=-100"` // e.g. 5 raises prices 5%, rounded to cents
+	SetStock           *int          `json:"set_stock" binding:"omitempty,gte=0"`
+	AdjustStock        *int          `json:"adjust_stock" example:"-2"` // Added to the current stock, which must stay >= 0
+	AddCategoryIDs     []int         `json:"add_category_ids" binding:"omitempty,unique_ids,dive,gt=0"`
+	RemoveCategoryIDs  []int         `json:"remove_category_ids" binding:"omitempty,unique_ids,dive,gt=0"`
+}
+
+// ProductBulkDeleteRequest moves the products picked by IDs or by Filter to the trash
+type ProductBulkDeleteRequest struct {
+	IDs    []int  `json:"ids" binding:"omitempty,unique_ids,dive,gt=0" example:"1,2,3"`
+	Filter string `json:"filter" example:"stock = 0"`
+}
+
+// ProductBulkResult summarizes a bulk operation
+type ProductBulkResult struct {
+	Affected   int   `json:"affected"`
+	ProductIDs []int `json:"product_ids"`         // Products changed, by ID
+	NotFound   []int `json:"not_found,omitempty"` // Requested IDs with no live product
+}
+
+// ProductBulkEvent is the payload of the single event sent after a bulk operation
+type ProductBulkEvent struct {
+	ProductIDs []int `json:"product_ids"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// CacheStats reports how the read cache is doing
+type CacheStats struct {
+	Enabled    bool                          `json:"enabled"`
+	Backend    string                        `json:"backend" example:"memory"`
+	Entries    int                           `json:"entries"`
+	MaxEntries int                           `json:"max_entries,omitempty"`
+	Hits       int64                         `json:"hits"`
+	Misses     int64                         `json:"misses"`
+	HitRatio   float64                       `json:"hit_ratio" example:"0.93"` // hits / (hits + misses)
+	Resources  map[string]CacheResourceStats `json:"resources,omitempty"`      // Keyed by product, category and category_list
+}
+
+// CacheResourceStats are the counters for one kind of cached read
+type CacheResourceStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
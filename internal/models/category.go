@@ -3,20 +3,32 @@ package models
 import "time"
 
 type Category struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name" binding:"required"`
-	Description *string   `json:"description,omitempty" db:"description"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int        `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name" binding:"required"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	Version     int        `json:"version" db:"version" example:"1"`     // Incremented on every write; exposed as the ETag
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Set while the category is in the trash
 }
 
 type CategoryCreateRequest struct {
-	Name        string  `json:"name" binding:"required"`
+	Name        string  `json:"name" binding:"required,category_name"`
 	Description *string `json:"description"`
 }
 
 type CategoryUpdateRequest struct {
-	Name        *string `json:"name"`
+	Name        *string `json:"name" binding:"omitempty,category_name"`
+	Description *string `json:"description"`
+
+	// Set to NULL by patches; a nil Description leaves it unchanged
+	ClearDescription bool `json:"-"`
+}
+
+// CategoryPatchDocument is the representation of a category edited by
+// merge patches and JSON Patches
+type CategoryPatchDocument struct {
+	Name        string  `json:"name" binding:"required,category_name"`
 	Description *string `json:"description"`
 }
 
@@ -28,3 +40,9 @@ type CategoryListResponse struct {
 	Limit      int        `json:"limit,omitempty"`
 	TotalPages int        `json:"total_pages,omitempty"`
 }
+
+// CategoryCursorResponse is a page of categories read with ?cursor=
+type CategoryCursorResponse struct {
+	Categories []Category `json:"categories"`
+	CursorPage
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// Currencies the catalog is sold in; any ISO 4217 code is accepted
+const (
+	CurrencyARS     = "ARS"
+	CurrencyUSD     = "USD"
+	DefaultCurrency = CurrencyARS
+)
+
+// RateMaxDecimals matches the NUMERIC(20,8) rate column
+const RateMaxDecimals = 8
+
+var currencyCodeRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code ("USD")
+func IsCurrencyCode(code string) bool {
+	return currencyCodeRegexp.MatchString(code)
+}
+
+// ExchangeRate means 1 BaseCurrency = Rate QuoteCurrency from EffectiveAt on
+type ExchangeRate struct {
+	ID            int          `json:"id" db:"id"`
+	BaseCurrency  string       `json:"base_currency" db:"base_currency" example:"USD"`
+	QuoteCurrency string       `json:"quote_currency" db:"quote_currency" example:"ARS"`
+	Rate          money.Amount `json:"rate" db:"rate" swaggertype:"number" example:"1050.5"`
+	EffectiveAt   time.Time    `json:"effective_at" db:"effective_at"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+
+	priceFormat string // JSON format of the rate, set by InPriceFormat
+}
+
+func (r ExchangeRate) InPriceFormat(format string) interface{} {
+	return r.withPriceFormat(format)
+}
+
+func (r ExchangeRate) withPriceFormat(format string) ExchangeRate {
+	r.priceFormat = format
+	return r
+}
+
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	type rate ExchangeRate
+	if r.priceFormat != money.FormatString {
+		return json.Marshal(rate(r))
+	}
+	return json.Marshal(struct {
+		rate
+		Rate json.RawMessage `json:"rate"`
+	}{rate(r), r.Rate.JSON(r.priceFormat)})
+}
+
+type ExchangeRateCreateRequest struct {
+	BaseCurrency  string        `json:"base_currency" binding:"required,currency" example:"USD"`
+	QuoteCurrency string        `json:"quote_currency" binding:"required,currency" example:"ARS"`
+	Rate          *money.Amount `json:"rate" swaggertype:"number" example:"1050.5" binding:"required,gt=0,rate_precision"`
+	EffectiveAt   *time.Time    `json:"effective_at"` // Defaults to now
+}
+
+type ExchangeRateListResponse struct {
+	Rates []ExchangeRate `json:"rates"`
+	Total int            `json:"total"`
+}
+
+func (r ExchangeRateListResponse) InPriceFormat(format string) interface{} {
+	r.Rates = inPriceFormat(r.Rates, format)
+	return r
+}
+
+// PriceConversion records how a product price was converted for the response
+type PriceConversion struct {
+	OriginalPrice    money.Amount `json:"original_price" swaggertype:"number" example:"19.99"`
+	OriginalCurrency string       `json:"original_currency" example:"USD"`
+	Rate             money.Amount `json:"rate" swaggertype:"number" example:"1050.5"` // Original currency → response currency
+	RateID           int          `json:"rate_id"`
+	Inverted         bool         `json:"inverted,omitempty"` // Rate derived from the opposite pair (1 / rate)
+	EffectiveAt      time.Time    `json:"effective_at"`
+
+	priceFormat string // JSON format of the amounts, set by Product.InPriceFormat
+}
+
+func (c PriceConversion) withPriceFormat(format string) PriceConversion {
+	c.priceFormat = format
+	return c
+}
+
+func (c PriceConversion) MarshalJSON() ([]byte, error) {
+	type conversion PriceConversion
+	if c.priceFormat != money.FormatString {
+		return json.Marshal(conversion(c))
+	}
+	return json.Marshal(struct {
+		conversion
+		OriginalPrice json.RawMessage `json:"original_price"`
+		Rate          json.RawMessage `json:"rate"`
+	}{conversion(c), c.OriginalPrice.JSON(c.priceFormat), c.Rate.JSON(c.priceFormat)})
+}
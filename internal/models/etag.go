@@ -0,0 +1,138 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag formats a row version as a strong entity tag, e.g. "3"
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ProductETag is the strong entity tag of a product as read. The product
+// embeds its categories, so a digest of their IDs and versions follows the
+// row version (e.g. "3.1f0c9a2e"): renaming or trashing a category changes
+// the tag even though the product row did not change.
+func ProductETag(product *Product) string {
+	if len(product.Categories) == 0 {
+		return ETag(product.Version)
+	}
+
+	digest := sha256.New()
+	for _, category := range product.Categories {
+		fmt.Fprintf(digest, "%d:%d,", category.ID, category.Version)
+	}
+	return `"` + strconv.Itoa(product.Version) + "." + hex.EncodeToString(digest.Sum(nil)[:4]) + `"`
+}
+
+// ParseIfMatch returns the versions listed in an If-Match header. It returns
+// nil when the header is empty or "*" (any version is fine) and a non-nil,
+// possibly empty slice otherwise. Weak tags (W/"3") never match, as If-Match
+// uses strong comparison. A ProductETag counts as its row version, since a
+// write to the product does not depend on the categories embedded in it.
+func ParseIfMatch(header string) []int {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, _, _ := strings.Cut(tag[1:len(tag)-1], ".")
+		if version, err := strconv.Atoi(version); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// Cacheable describes how a read may be cached and revalidated
+type Cacheable struct {
+	ETag         string        // Computed from the response body (weak) when empty
+	LastModified time.Time     // Omitted when zero
+	MaxAge       time.Duration // 0 lets clients store the response but revalidate every time
+}
+
+// RespondCacheable sends data with ETag, Last-Modified and Cache-Control
+// headers, or 304 Not Modified when the request's If-None-Match or
+// If-Modified-Since shows the client already has this representation
+func RespondCacheable(c *gin.Context, cache Cacheable, data interface{}) {
+	body, err := json.Marshal(SuccessResponse(formatPrices(c, data)))
+	if err != nil {
+		c.Error(fmt.Errorf("failed to encode response: %w", err))
+		return
+	}
+
+	if cache.ETag == "" {
+		sum := sha256.Sum256(body)
+		cache.ETag = `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	}
+
+	if RespondNotModified(c, cache) {
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// RespondNotModified sets the cache headers and answers 304 Not Modified
+// when the request shows the client already has the representation tagged
+// cache.ETag. It lets a handler that can tag a response before building it
+// skip the work; when it returns false nothing has been written but headers.
+func RespondNotModified(c *gin.Context, cache Cacheable) bool {
+	c.Header("ETag", cache.ETag)
+	if !cache.LastModified.IsZero() {
+		c.Header("Last-Modified", cache.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if cache.MaxAge > 0 {
+		c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(cache.MaxAge/time.Second)))
+	} else {
+		c.Header("Cache-Control", "private, no-cache")
+	}
+
+	if !notModified(c.Request, cache.ETag, cache.LastModified) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the former
+// is absent (RFC 9110 §13.2.2)
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || weakMatch(tag, etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// weakMatch compares two entity tags ignoring the weak prefix, as
+// If-None-Match does
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
@@ -0,0 +1,58 @@
+package models
+
+import "github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+
+// How a product import treats rows with errors
+const (
+	ImportModeAtomic     = "atomic"      // Nothing is written unless every row is valid
+	ImportModeBestEffort = "best_effort" // Valid rows are written, the rest are reported
+)
+
+// What an import did (or, in a dry run, would do) with a row
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+// ProductImportRow is one product read from an import file. A row whose
+// SKU matches a live product updates it; any other row creates a product.
+type ProductImportRow struct {
+	Line        int // Line of the row in the file
+	SKU         *string
+	Name        string
+	Description *string
+	Price       *money.Amount
+	Currency    string
+	Stock       int
+	Categories  []string     // Category IDs or names
+	Errors      []FieldError // Values that could not be read
+}
+
+// ProductImportResult reports what happened to one row
+type ProductImportResult struct {
+	Line      int          `json:"line"`
+	SKU       string       `json:"sku,omitempty"`
+	Action    string       `json:"action" enums:"create,update,error"`
+	ProductID int          `json:"product_id,omitempty"` // Not set for rows created in a dry run
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ProductImportReport summarizes an import row by row
+type ProductImportReport struct {
+	Mode    string                `json:"mode" enums:"atomic,best_effort"`
+	DryRun  bool                  `json:"dry_run"`
+	Applied bool                  `json:"applied"` // Whether any change was written
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+	Rows    []ProductImportResult `json:"rows"`
+}
+
+// ProductImportEvent is the payload of the single event sent after an import
+type ProductImportEvent struct {
+	Created    int   `json:"created"`
+	Updated    int   `json:"updated"`
+	ProductIDs []int `json:"product_ids"`
+}
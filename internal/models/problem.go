@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type defined by RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix prefixes the error code to build the problem "type" URI
+const ProblemTypePrefix = "urn:problem-type:bsmart:"
+
+// Problem is an RFC 7807 problem details document
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Current  interface{}  `json:"current,omitempty"` // Latest representation on 412 Precondition Failed
+}
+
+// NewProblem builds a problem document for the current request
+func NewProblem(c *gin.Context, statusCode int, code, detail string) Problem {
+	return Problem{
+		Type:     ProblemTypePrefix + strings.ReplaceAll(strings.ToLower(code), "_", "-"),
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   detail,
+		Instance: c.Request.URL.RequestURI(),
+		Code:     code,
+	}
+}
+
+// RespondProblem writes a problem document with the problem+json content type
+func RespondProblem(c *gin.Context, problem Problem) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		c.Status(problem.Status)
+		return
+	}
+	c.Data(problem.Status, ProblemContentType, body)
+}
+
+// WantsProblem reports whether the client negotiated application/problem+json
+// over the default application/json envelope
+func WantsProblem(c *gin.Context) bool {
+	accept := c.GetHeader("Accept")
+	if accept == "" {
+		return false
+	}
+
+	problemQ, jsonQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch mediaType {
+		case ProblemContentType:
+			problemQ = q
+		case "application/json":
+			jsonQ = q
+		}
+	}
+
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+// RespondBindingError reports a request body that failed to bind or validate
+func RespondBindingError(c *gin.Context, err error) {
+	if WantsProblem(c) {
+		problem := NewProblem(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		if fields := FieldErrorsFromBinding(err); len(fields) > 0 {
+			problem.Detail = "The request body failed validation"
+			problem.Errors = fields
+		}
+		RespondProblem(c, problem)
+		return
+	}
+
+	fields := FieldErrorsFromBinding(err)
+	if len(fields) == 0 {
+		RespondError(c, http.StatusBadRequest, "INVALID_INPUT", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, ApiResponse{
+		Success: false,
+		Error: &ApiError{
+			Code:    "INVALID_INPUT",
+			Message: "The request body failed validation",
+			Fields:  fields,
+		},
+	})
+}
@@ -1,42 +1,121 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
 
 type Product struct {
-	ID          int        `json:"id" db:"id"`
-	Name        string     `json:"name" db:"name" binding:"required"`
-	Description *string    `json:"description,omitempty" db:"description"`
-	Price       float64    `json:"price" db:"price" binding:"required,gte=0"`
-	Stock       int        `json:"stock" db:"stock" binding:"required,gte=0"`
-	Categories  []Category `json:"categories,omitempty" db:"-"` // Joined category data
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID          int              `json:"id" db:"id"`
+	SKU         *string          `json:"sku,omitempty" db:"sku" example:"ACME-1042"` // External code, e.g. the supplier's; unique among live products
+	Name        string           `json:"name" db:"name" binding:"required"`
+	Description *string          `json:"description,omitempty" db:"description"`
+	Price       money.Amount     `json:"price" db:"price" swaggertype:"number" example:"19.99" binding:"required,gte=0"`
+	Currency    string           `json:"currency" db:"currency" example:"ARS"`
+	Conversion  *PriceConversion `json:"conversion,omitempty" db:"-"` // Set when prices are requested in another currency
+	Stock       int              `json:"stock" db:"stock" binding:"required,gte=0"`
+	Categories  []Category       `json:"categories,omitempty" db:"-"` // Joined category data
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
+	Version     int              `json:"version" db:"version" example:"1"`     // Incremented on every write; exposed as the ETag
+	DeletedAt   *time.Time       `json:"deleted_at,omitempty" db:"deleted_at"` // Set while the product is in the trash
+
+	priceFormat string // JSON format of the amounts, set by InPriceFormat
+}
+
+func (p Product) InPriceFormat(format string) interface{} {
+	return p.withPriceFormat(format)
+}
+
+func (p Product) withPriceFormat(format string) Product {
+	p.priceFormat = format
+	if p.Conversion != nil {
+		conversion := p.Conversion.withPriceFormat(format)
+		p.Conversion = &conversion
+	}
+	return p
+}
+
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	if p.priceFormat != money.FormatString {
+		return json.Marshal(product(p))
+	}
+	return json.Marshal(struct {
+		product
+		Price json.RawMessage `json:"price"`
+	}{product(p), p.Price.JSON(p.priceFormat)})
 }
 
 // ProductHistory represents a historical record of product changes
 type ProductHistory struct {
-	ID        int       `json:"id" db:"id"`
-	ProductID int       `json:"product_id" db:"product_id"`
-	Price     float64   `json:"price" db:"price"`
-	Stock     int       `json:"stock" db:"stock"`
-	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+	ID        int          `json:"id" db:"id"`
+	ProductID int          `json:"product_id" db:"product_id"`
+	Price     money.Amount `json:"price" db:"price" swaggertype:"number" example:"19.99"`
+	Currency  string       `json:"currency" db:"currency" example:"ARS"`
+	Stock     int          `json:"stock" db:"stock"`
+	ChangedAt time.Time    `json:"changed_at" db:"changed_at"`
+
+	priceFormat string // JSON format of the price, set by InPriceFormat
+}
+
+func (h ProductHistory) InPriceFormat(format string) interface{} {
+	return h.withPriceFormat(format)
+}
+
+func (h ProductHistory) withPriceFormat(format string) ProductHistory {
+	h.priceFormat = format
+	return h
+}
+
+func (h ProductHistory) MarshalJSON() ([]byte, error) {
+	type history ProductHistory
+	if h.priceFormat != money.FormatString {
+		return json.Marshal(history(h))
+	}
+	return json.Marshal(struct {
+		history
+		Price json.RawMessage `json:"price"`
+	}{history(h), h.Price.JSON(h.priceFormat)})
 }
 
 // ProductCreateRequest represents the request to create a product
 type ProductCreateRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description *string `json:"description"`
-	Price       float64 `json:"price" binding:"required,gte=0"`
-	Stock       int     `json:"stock" binding:"required,gte=0"`
-	CategoryIDs []int   `json:"category_ids" binding:"required,min=1"` // At least one category
+	SKU         *string       `json:"sku" binding:"omitempty,sku" example:"ACME-1042"`
+	Name        string        `json:"name" binding:"required,product_name"`
+	Description *string       `json:"description"`
+	Price       *money.Amount `json:"price" swaggertype:"number" example:"19.99" binding:"required,gte=0,price_precision"`
+	Currency    string        `json:"currency" binding:"omitempty,currency" example:"ARS"` // Defaults to ARS
+	Stock       int           `json:"stock" binding:"required,gte=0"`
+	CategoryIDs []int         `json:"category_ids" binding:"required,min=1,unique_ids"` // At least one category
 }
 
 type ProductUpdateRequest struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Price       *float64 `json:"price" binding:"omitempty,gte=0"`
-	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
-	CategoryIDs []int    `json:"category_ids" binding:"omitempty,min=1"`
+	SKU         *string       `json:"sku" binding:"omitempty,sku" example:"ACME-1042"`
+	Name        *string       `json:"name" binding:"omitempty,product_name"`
+	Description *string       `json:"description"`
+	Price       *money.Amount `json:"price" swaggertype:"number" example:"19.99" binding:"omitempty,gte=0,price_precision"`
+	Currency    *string       `json:"currency" binding:"omitempty,currency" example:"USD"`
+	Stock       *int          `json:"stock" binding:"omitempty,gte=0"`
+	CategoryIDs []int         `json:"category_ids" binding:"omitempty,min=1,unique_ids"`
+
+	// Set to NULL by patches; a nil pointer above leaves the field unchanged
+	ClearSKU         bool `json:"-"`
+	ClearDescription bool `json:"-"`
+}
+
+// ProductPatchDocument is the representation of a product edited by merge
+// patches and JSON Patches: its writable fields, with categories as IDs
+type ProductPatchDocument struct {
+	SKU         *string       `json:"sku" binding:"omitempty,sku" example:"ACME-1042"`
+	Name        string        `json:"name" binding:"required,product_name"`
+	Description *string       `json:"description"`
+	Price       *money.Amount `json:"price" swaggertype:"number" example:"19.99" binding:"required,gte=0,price_precision"`
+	Currency    string        `json:"currency" binding:"required,currency" example:"ARS"`
+	Stock       *int          `json:"stock" binding:"required,gte=0"`
+	CategoryIDs []int         `json:"category_ids" binding:"required,min=1,unique_ids"`
 }
 
 type ProductListResponse struct {
@@ -47,8 +126,52 @@ type ProductListResponse struct {
 	TotalPages int       `json:"total_pages"`
 }
 
+func (r ProductListResponse) InPriceFormat(format string) interface{} {
+	r.Products = inPriceFormat(r.Products, format)
+	return r
+}
+
+// ProductBatchResponse holds the products asked for with ?ids=, in the
+// requested order, and the ids that match no product
+type ProductBatchResponse struct {
+	Products []Product `json:"products"`
+	NotFound []int     `json:"not_found"`
+}
+
+func (r ProductBatchResponse) InPriceFormat(format string) interface{} {
+	r.Products = inPriceFormat(r.Products, format)
+	return r
+}
+
+// ProductCursorResponse is a page of products read with ?cursor=
+type ProductCursorResponse struct {
+	Products []Product `json:"products"`
+	CursorPage
+}
+
+func (r ProductCursorResponse) InPriceFormat(format string) interface{} {
+	r.Products = inPriceFormat(r.Products, format)
+	return r
+}
+
 // ProductHistoryResponse represents a list of product history records
 type ProductHistoryResponse struct {
 	History []ProductHistory `json:"history"`
 	Total   int              `json:"total"`
 }
+
+func (r ProductHistoryResponse) InPriceFormat(format string) interface{} {
+	r.History = inPriceFormat(r.History, format)
+	return r
+}
+
+// ProductHistoryCursorResponse is a page of product history read with ?cursor=
+type ProductHistoryCursorResponse struct {
+	History []ProductHistory `json:"history"`
+	CursorPage
+}
+
+func (r ProductHistoryCursorResponse) InPriceFormat(format string) interface{} {
+	r.History = inPriceFormat(r.History, format)
+	return r
+}
@@ -1,6 +1,59 @@
 package models
 
-import "github.com/gin-gonic/gin"
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+)
+
+// priceFormatKey holds the money JSON format of the responses of a request
+const priceFormatKey = "price_format"
+
+// SetPriceFormat selects how the responses of a request encode amounts
+// (money.FormatNumber or money.FormatString)
+func SetPriceFormat(c *gin.Context, format string) {
+	c.Set(priceFormatKey, format)
+}
+
+// PriceFormat returns the money JSON format of the request's responses
+func PriceFormat(c *gin.Context) string {
+	return c.GetString(priceFormatKey)
+}
+
+// PriceFormatter is implemented by the responses that hold amounts. They
+// encode amounts as JSON numbers unless they are copies made for another
+// format.
+type PriceFormatter interface {
+	// InPriceFormat returns a copy that encodes its amounts in format
+	// (money.FormatNumber or money.FormatString)
+	InPriceFormat(format string) interface{}
+}
+
+// inPriceFormat copies the items of a response for InPriceFormat
+func inPriceFormat[T interface{ withPriceFormat(string) T }](items []T, format string) []T {
+	if items == nil {
+		return nil
+	}
+	formatted := make([]T, len(items))
+	for i, item := range items {
+		formatted[i] = item.withPriceFormat(format)
+	}
+	return formatted
+}
+
+// formatPrices applies the request's price format to data if it holds amounts
+func formatPrices(c *gin.Context, data interface{}) interface{} {
+	if formatter, ok := data.(PriceFormatter); ok {
+		return formatter.InPriceFormat(PriceFormat(c))
+	}
+	return data
+}
+
+// EncodeJSON encodes data as a response to the request, with its amounts in
+// the request's price format
+func EncodeJSON(c *gin.Context, data interface{}) ([]byte, error) {
+	return json.Marshal(formatPrices(c, data))
+}
 
 // ApiResponse is the standard response format for all API endpoints
 type ApiResponse struct {
@@ -11,9 +64,19 @@ type ApiResponse struct {
 
 // ApiError represents an error in the API response
 type ApiError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"` // Per-field validation errors
+}
+
+// CursorPage describes a page of a cursor-paginated listing. A null cursor
+// means there is no page in that direction.
+type CursorPage struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Total      *int    `json:"total,omitempty"` // Only with include_total=true
 }
 
 func SuccessResponse(data interface{}) ApiResponse {
@@ -47,17 +110,47 @@ func ErrorResponseWithDetails(code, message, details string) ApiResponse {
 
 // RespondJSON sends a JSON response with the given status code and data
 func RespondJSON(c *gin.Context, statusCode int, response ApiResponse) {
+	response.Data = formatPrices(c, response.Data)
 	c.JSON(statusCode, response)
 }
 
 func RespondSuccess(c *gin.Context, statusCode int, data interface{}) {
-	c.JSON(statusCode, SuccessResponse(data))
+	c.JSON(statusCode, SuccessResponse(formatPrices(c, data)))
 }
 
+// RespondError sends an error using the ApiResponse envelope, or an RFC 7807
+// problem document when the client asked for application/problem+json
 func RespondError(c *gin.Context, statusCode int, code, message string) {
+	if WantsProblem(c) {
+		RespondProblem(c, NewProblem(c, statusCode, code, message))
+		return
+	}
 	c.JSON(statusCode, ErrorResponse(code, message))
 }
 
+// RespondErrorWithData sends an error that also carries a representation,
+// e.g. the current resource when a conditional write fails
+func RespondErrorWithData(c *gin.Context, statusCode int, code, message string, data interface{}) {
+	data = formatPrices(c, data)
+	if WantsProblem(c) {
+		problem := NewProblem(c, statusCode, code, message)
+		problem.Current = data
+		RespondProblem(c, problem)
+		return
+	}
+	response := ErrorResponse(code, message)
+	response.Data = data
+	c.JSON(statusCode, response)
+}
+
 func RespondErrorWithDetails(c *gin.Context, statusCode int, code, message, details string) {
+	if WantsProblem(c) {
+		problem := NewProblem(c, statusCode, code, message)
+		if details != "" {
+			problem.Detail = message + ": " + details
+		}
+		RespondProblem(c, problem)
+		return
+	}
 	c.JSON(statusCode, ErrorResponseWithDetails(code, message, details))
 }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Reasons for a stock movement
+const (
+	StockReasonSale       = "sale"       // Units leave; quantity must be negative
+	StockReasonRestock    = "restock"    // Units arrive; quantity must be positive
+	StockReasonAdjustment = "adjustment" // Corrections after a count, either sign
+	StockReasonReturn     = "return"     // Units come back from a customer; positive
+	StockReasonDamage     = "damage"     // Units written off; negative
+)
+
+// StockMovement is an entry of the stock ledger of a product
+type StockMovement struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	Quantity   int       `json:"quantity" example:"-2"` // Signed: negative takes units out
+	Reason     string    `json:"reason" example:"sale"`
+	Reference  *string   `json:"reference,omitempty" example:"ORDER-1042"`
+	UserID     *int      `json:"user_id,omitempty"` // Who recorded it; empty once the user is deleted
+	StockAfter int       `json:"stock_after" example:"8"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type StockMovementCreateRequest struct {
+	Quantity  int     `json:"quantity" binding:"required" example:"-2"` // Non-zero; the sign must suit the reason
+	Reason    string  `json:"reason" binding:"required,oneof=sale restock adjustment return damage" example:"sale"`
+	Reference *string `json:"reference" binding:"omitempty,max=255" example:"ORDER-1042"` // e.g. an order or delivery note
+}
+
+// StockMovementListResponse is a page of the stock ledger of a product
+type StockMovementListResponse struct {
+	Movements  []StockMovement `json:"movements"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// StockMovementCursorResponse is a page of the stock ledger read with ?cursor=
+type StockMovementCursorResponse struct {
+	Movements []StockMovement `json:"movements"`
+	CursorPage
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// TrashPurgeResponse reports what a purge permanently removed
+type TrashPurgeResponse struct {
+	Products      int       `json:"products"`
+	Categories    int       `json:"categories"`
+	DeletedBefore time.Time `json:"deleted_before"` // Items deleted before this instant were purged
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/go-playground/validator/v10"
+)
+
+// Business rules enforced by the custom validators
+const (
+	ProductNameMinLength  = 2
+	ProductNameMaxLength  = 120
+	CategoryNameMinLength = 2
+	CategoryNameMaxLength = 60
+	PriceMaxDecimals      = 2
+	SKUMaxLength          = 64
+)
+
+// FieldError describes a validation problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// RegisterValidators makes v report JSON field names and registers the
+// custom product/category rules. Call it once per engine, before validating.
+func RegisterValidators(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// Amounts are compared as numbers by gte/lte; price_precision inspects the exact value
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if amount, ok := field.Interface().(money.Amount); ok {
+			return amount.Float64()
+		}
+		return nil
+	}, money.Amount{})
+
+	_ = v.RegisterValidation("product_name", nameLength(ProductNameMinLength, ProductNameMaxLength))
+	_ = v.RegisterValidation("category_name", nameLength(CategoryNameMinLength, CategoryNameMaxLength))
+	_ = v.RegisterValidation("price_precision", maxDecimals(PriceMaxDecimals))
+	_ = v.RegisterValidation("unique_ids", uniqueIDs)
+	_ = v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return IsCurrencyCode(fl.Field().String())
+	})
+	_ = v.RegisterValidation("rate_precision", maxDecimals(RateMaxDecimals))
+	_ = v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return IsSKU(fl.Field().String())
+	})
+}
+
+// nameLength checks the trimmed length of a name in characters
+func nameLength(min, max int) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		n := utf8.RuneCountInString(strings.TrimSpace(fl.Field().String()))
+		return n >= min && n <= max
+	}
+}
+
+// maxDecimals rejects decimal values with more than n decimal places
+func maxDecimals(n int) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		// Custom types reach validators already converted; read the original field
+		if parent := fl.Parent(); parent.Kind() == reflect.Struct {
+			if original := reflect.Indirect(parent.FieldByName(fl.StructFieldName())); original.IsValid() {
+				if amount, ok := original.Interface().(money.Amount); ok {
+					return amount.Scale() <= n
+				}
+			}
+		}
+
+		field := fl.Field()
+		if field.Kind() != reflect.Float32 && field.Kind() != reflect.Float64 {
+			return false
+		}
+
+		formatted := strconv.FormatFloat(field.Float(), 'f', -1, 64)
+		if dot := strings.IndexByte(formatted, '.'); dot >= 0 {
+			return len(formatted)-dot-1 <= n
+		}
+		return true
+	}
+}
+
+// IsSKU reports whether s is a valid product SKU
+func IsSKU(s string) bool {
+	if s == "" || len(s) > SKUMaxLength {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueIDs rejects int slices containing the same value twice
+func uniqueIDs(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.Slice {
+		return false
+	}
+
+	seen := make(map[int64]bool, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		id := field.Index(i).Int()
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+// FieldErrorsFromBinding extracts per-field problems from a gin binding error
+func FieldErrorsFromBinding(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   jsonTypeName(typeErr.Type),
+			Message: "must be of type " + jsonTypeName(typeErr.Type),
+		}}
+	}
+
+	return nil
+}
+
+// fieldPath strips the struct name from the namespace: "ProductCreateRequest.category_ids[1]" → "category_ids[1]"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	isCollection := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array || fe.Kind() == reflect.Map
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "min":
+		if isCollection {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isCollection {
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "product_name":
+		return fmt.Sprintf("must be between %d and %d characters long", ProductNameMinLength, ProductNameMaxLength)
+	case "category_name":
+		return fmt.Sprintf("must be between %d and %d characters long", CategoryNameMinLength, CategoryNameMaxLength)
+	case "price_precision":
+		return fmt.Sprintf("must have at most %d decimal places", PriceMaxDecimals)
+	case "unique_ids":
+		return "must not contain duplicate ids"
+	case "currency":
+		return "must be a 3-letter ISO 4217 currency code"
+	case "rate_precision":
+		return fmt.Sprintf("must have at most %d decimal places", RateMaxDecimals)
+	case "sku":
+		return fmt.Sprintf("must be 1 to %d letters, digits, '-', '_', '.' or '/'", SKUMaxLength)
+	default:
+		return fmt.Sprintf("failed the '%s' validation", fe.Tag())
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t == reflect.TypeOf(money.Amount{}) {
+		return "number"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
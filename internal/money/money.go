@@ -0,0 +1,335 @@
+// Package money provides an exact decimal type for prices. Values are kept as
+// an integer coefficient and a decimal scale, so 19.99 is stored as 1999×10⁻²
+// and never goes through float64.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// JSON formats for amounts
+const (
+	FormatNumber = "number" // 19.99 (fixed precision number, backward compatible)
+	FormatString = "string" // "19.99"
+)
+
+// DisplayDecimals is the minimum number of decimals used when formatting
+const DisplayDecimals = 2
+
+// maxScale bounds the accepted precision so the coefficient fits in an int64
+const maxScale = 18
+
+// ErrInvalidAmount is returned when a value cannot be parsed as an amount
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is an exact decimal value: coef × 10^-scale.
+// It is always normalized (no trailing zeros), so == compares values.
+type Amount struct {
+	coef  int64
+	scale int32
+}
+
+// New builds coef × 10^-scale, e.g. New(1999, 2) is 19.99
+func New(coef int64, scale int32) Amount {
+	return normalize(coef, scale)
+}
+
+// FromCents builds an amount with two decimals
+func FromCents(cents int64) Amount {
+	return New(cents, 2)
+}
+
+// Parse reads a plain decimal such as "19.99", "-3" or "0.125". Exponents
+// ("1.5e2"), NaN and infinities are rejected.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, fmt.Errorf("%w: empty value", ErrInvalidAmount)
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	sign := ""
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	coef, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return fromBig(coef, int64(len(fracPart)))
+}
+
+// MustParse is like Parse but panics on error; meant for constants
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// fromBig converts coef × 10^-scale, normalizing and checking the int64 range
+func fromBig(coef *big.Int, scale int64) (Amount, error) {
+	ten := big.NewInt(10)
+	rem := new(big.Int)
+	for scale > 0 && coef.Sign() != 0 {
+		q, r := new(big.Int).QuoRem(coef, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		coef, scale = q, scale-1
+	}
+	if coef.Sign() == 0 {
+		return Amount{}, nil
+	}
+	if scale < -maxScale {
+		return Amount{}, fmt.Errorf("%w: value out of range", ErrInvalidAmount)
+	}
+	for scale < 0 {
+		coef = new(big.Int).Mul(coef, ten)
+		scale++
+	}
+
+	if scale > maxScale || !coef.IsInt64() {
+		return Amount{}, fmt.Errorf("%w: value out of range", ErrInvalidAmount)
+	}
+	return Amount{coef: coef.Int64(), scale: int32(scale)}, nil
+}
+
+func normalize(coef int64, scale int32) Amount {
+	if coef == 0 {
+		return Amount{}
+	}
+	for scale > 0 && coef%10 == 0 {
+		coef /= 10
+		scale--
+	}
+	for scale < 0 {
+		coef *= 10
+		scale++
+	}
+	return Amount{coef: coef, scale: scale}
+}
+
+// Scale is the number of significant decimal places (19.90 → 1)
+func (a Amount) Scale() int {
+	return int(a.scale)
+}
+
+// IsZero reports whether the amount is 0
+func (a Amount) IsZero() bool {
+	return a.coef == 0
+}
+
+// Sign returns -1, 0 or 1
+func (a Amount) Sign() int {
+	switch {
+	case a.coef < 0:
+		return -1
+	case a.coef > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Cmp compares two amounts and returns -1, 0 or 1
+func (a Amount) Cmp(b Amount) int {
+	return a.big().Cmp(b.big())
+}
+
+// Add returns a + b; it panics if the result does not fit (far beyond any price)
+func (a Amount) Add(b Amount) Amount {
+	scale := max(a.scale, b.scale)
+	sum := new(big.Int).Add(
+		new(big.Int).Mul(big.NewInt(a.coef), pow10(int64(scale-a.scale))),
+		new(big.Int).Mul(big.NewInt(b.coef), pow10(int64(scale-b.scale))),
+	)
+	result, err := fromBig(sum, int64(scale))
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// Mul returns a × b rounded half away from zero to the given decimals
+func (a Amount) Mul(b Amount, decimals int) Amount {
+	return roundRat(new(big.Rat).Mul(a.big(), b.big()), decimals)
+}
+
+// Quo returns a ÷ b rounded half away from zero to the given decimals.
+// It panics when b is zero.
+func (a Amount) Quo(b Amount, decimals int) Amount {
+	if b.IsZero() {
+		panic("money: division by zero")
+	}
+	return roundRat(new(big.Rat).Quo(a.big(), b.big()), decimals)
+}
+
+// Float64 returns the nearest float64; use only for display or approximate math
+func (a Amount) Float64() float64 {
+	f, _ := strconv.ParseFloat(a.plain(), 64)
+	return f
+}
+
+// Round returns the amount rounded half away from zero to the given decimals
+func (a Amount) Round(decimals int) Amount {
+	if int(a.scale) <= decimals {
+		return a
+	}
+
+	r := new(big.Rat).SetFrac(big.NewInt(a.coef), pow10(int64(a.scale)))
+	return roundRat(r, decimals)
+}
+
+// roundRat rounds a rational number half away from zero
+func roundRat(r *big.Rat, decimals int) Amount {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(int64(decimals))))
+	num, den := scaled.Num(), scaled.Denom()
+
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	// |remainder| * 2 >= den → round away from zero
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	a, err := fromBig(q, int64(decimals))
+	if err != nil {
+		// Out of int64 range: saturate rather than wrap around
+		if q.Sign() < 0 {
+			return Amount{coef: math.MinInt64}
+		}
+		return Amount{coef: math.MaxInt64}
+	}
+	return a
+}
+
+func (a Amount) big() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(a.coef), pow10(int64(a.scale)))
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+// String formats with at least DisplayDecimals decimals, e.g. "10.50"
+func (a Amount) String() string {
+	return a.format(max(int(a.scale), DisplayDecimals))
+}
+
+// plain formats with exactly the significant decimals
+func (a Amount) plain() string {
+	return a.format(int(a.scale))
+}
+
+func (a Amount) format(decimals int) string {
+	digits := new(big.Int).Mul(big.NewInt(a.coef), pow10(int64(decimals)-int64(a.scale))).String()
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+}
+
+// MarshalJSON writes the amount as a fixed precision number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// JSON encodes the amount in the given JSON format: a fixed precision
+// number, or a string with FormatString
+func (a Amount) JSON(format string) json.RawMessage {
+	if format == FormatString {
+		return json.RawMessage(`"` + a.String() + `"`)
+	}
+	return json.RawMessage(a.String())
+}
+
+// UnmarshalJSON accepts both numbers (19.99) and strings ("19.99").
+// Invalid values are reported as *json.UnmarshalTypeError so the decoder
+// adds the field name.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	text, kind := string(data), "number"
+	if len(data) > 0 && data[0] == '"' {
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return &json.UnmarshalTypeError{Value: "string", Type: reflect.TypeOf(Amount{})}
+		}
+		text, kind = unquoted, "string"
+	} else if len(data) > 0 && (data[0] == '{' || data[0] == '[' || data[0] == 't' || data[0] == 'f') {
+		kind = "value"
+	}
+
+	parsed, err := Parse(text)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: kind, Type: reflect.TypeOf(Amount{})}
+	}
+	*a = parsed
+	return nil
+}
+
+// MarshalText and UnmarshalText let amounts be used in query strings, CSV and config files
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.plain()), nil
+}
+
+func (a *Amount) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner
+func (a *Amount) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return errors.New("cannot scan NULL into money.Amount")
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite || n.Int == nil {
+		return fmt.Errorf("%w: NaN or infinite numeric", ErrInvalidAmount)
+	}
+
+	parsed, err := fromBig(new(big.Int).Set(n.Int), -int64(n.Exp))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer
+func (a Amount) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(a.coef), Exp: -a.scale, Valid: true}, nil
+}
@@ -0,0 +1,272 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // String(); empty when Parse must fail
+		scale int
+	}{
+		{"19.99", "19.99", 2},
+		{"19.90", "19.90", 1},
+		{"19.999", "19.999", 3},
+		{"0.125", "0.125", 3},
+		{"7", "7.00", 0},
+		{"007", "7.00", 0},
+		{"+1", "1.00", 0},
+		{" 2.50 ", "2.50", 1},
+		{".5", "0.50", 1},
+		{"5.", "5.00", 0},
+		{"-3", "-3.00", 0},
+		{"-0.05", "-0.05", 2},
+		{"-0", "0.00", 0},
+		{"0.000000000000000001", "0.000000000000000001", 18},
+		{"9223372036854775807", "9223372036854775807.00", 0},
+
+		{"", "", 0},
+		{"1e2", "", 0},
+		{"1.5E2", "", 0},
+		{"2e-2", "", 0},
+		{"NaN", "", 0},
+		{"Infinity", "", 0},
+		{"-Inf", "", 0},
+		{"-", "", 0},
+		{".", "", 0},
+		{"--1", "", 0},
+		{"1.2.3", "", 0},
+		{"1,5", "", 0},
+		{"0x10", "", 0},
+		{"1 000", "", 0},
+		{"0.0000000000000000001", "", 0},
+		{"9223372036854775808", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := money.Parse(tt.input)
+			if tt.want == "" {
+				if !errors.Is(err, money.ErrInvalidAmount) {
+					t.Fatalf("Parse(%q) = %v, %v; want %v", tt.input, got, err, money.ErrInvalidAmount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) returned %v", tt.input, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+			if got.Scale() != tt.scale {
+				t.Errorf("Parse(%q).Scale() = %d, want %d", tt.input, got.Scale(), tt.scale)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		number string
+		str    string
+	}{
+		{"number", `19.99`, `19.99`, `"19.99"`},
+		{"string", `"19.99"`, `19.99`, `"19.99"`},
+		{"integer", `5`, `5.00`, `"5.00"`},
+		{"trailing zeros", `"10.500"`, `10.50`, `"10.50"`},
+		{"more decimals than displayed", `0.125`, `0.125`, `"0.125"`},
+		{"negative number", `-3.5`, `-3.50`, `"-3.50"`},
+		{"negative string", `"-0.01"`, `-0.01`, `"-0.01"`},
+		{"large", `1234567890.12`, `1234567890.12`, `"1234567890.12"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a money.Amount
+			if err := json.Unmarshal([]byte(tt.input), &a); err != nil {
+				t.Fatalf("Unmarshal(%s) returned %v", tt.input, err)
+			}
+
+			out, err := json.Marshal(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.number {
+				t.Errorf("Marshal = %s, want %s", out, tt.number)
+			}
+			if got := string(a.JSON(money.FormatNumber)); got != tt.number {
+				t.Errorf("JSON(number) = %s, want %s", got, tt.number)
+			}
+			if got := string(a.JSON(money.FormatString)); got != tt.str {
+				t.Errorf("JSON(string) = %s, want %s", got, tt.str)
+			}
+
+			// Both encodings read back as the same value
+			for _, encoded := range []string{tt.number, tt.str} {
+				var back money.Amount
+				if err := json.Unmarshal([]byte(encoded), &back); err != nil {
+					t.Fatalf("Unmarshal(%s) returned %v", encoded, err)
+				}
+				if back != a {
+					t.Errorf("Unmarshal(%s) = %s, want %s", encoded, back, a)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONRejects(t *testing.T) {
+	for _, input := range []string{`1e2`, `1.5E-1`, `"1e2"`, `"NaN"`, `"Infinity"`, `""`, `"abc"`, `true`, `{}`, `[1]`} {
+		t.Run(input, func(t *testing.T) {
+			var a money.Amount
+			err := json.Unmarshal([]byte(input), &a)
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				t.Fatalf("Unmarshal(%s) = %s, %v; want a *json.UnmarshalTypeError", input, a, err)
+			}
+		})
+	}
+}
+
+func TestJSONNull(t *testing.T) {
+	var body struct {
+		Price *money.Amount `json:"price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price": null}`), &body); err != nil || body.Price != nil {
+		t.Errorf("null price: got %v, %v; want nil", body.Price, err)
+	}
+	if err := json.Unmarshal([]byte(`{"price": "1.50"}`), &body); err != nil || body.Price == nil || *body.Price != money.MustParse("1.5") {
+		t.Errorf("string price: got %v, %v; want 1.50", body.Price, err)
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"10.50", "10.5"},
+		{"-3.00", "-3"},
+		{"0.125", "0.125"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		a := money.MustParse(tt.input)
+		out, err := a.MarshalText()
+		if err != nil || string(out) != tt.want {
+			t.Errorf("MarshalText(%s) = %s, %v; want %s", tt.input, out, err, tt.want)
+		}
+		var back money.Amount
+		if err := back.UnmarshalText(out); err != nil || back != a {
+			t.Errorf("UnmarshalText(%s) = %s, %v; want %s", out, back, err, a)
+		}
+	}
+
+	var a money.Amount
+	if err := a.UnmarshalText([]byte("1e3")); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("UnmarshalText(1e3) returned %v, want %v", err, money.ErrInvalidAmount)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  money.Amount
+		want string
+	}{
+		{"add", money.MustParse("0.10").Add(money.MustParse("0.20")), "0.30"},
+		{"add negative", money.MustParse("5.25").Add(money.MustParse("-7.5")), "-2.25"},
+		{"add to zero", money.MustParse("-1.99").Add(money.MustParse("1.99")), "0.00"},
+		{"round down", money.MustParse("1.004").Round(2), "1.00"},
+		{"round half up", money.MustParse("1.005").Round(2), "1.01"},
+		{"round half away from zero", money.MustParse("-1.005").Round(2), "-1.01"},
+		{"round negative down", money.MustParse("-1.004").Round(2), "-1.00"},
+		{"round to integer", money.MustParse("2.5").Round(0), "3.00"},
+		{"round negative to integer", money.MustParse("-2.5").Round(0), "-3.00"},
+		{"round with fewer decimals", money.MustParse("1.5").Round(2), "1.50"},
+		{"convert", money.MustParse("1000").Mul(money.MustParse("0.00083333"), 2), "0.83"},
+		{"convert half", money.MustParse("10.01").Mul(money.MustParse("0.5"), 2), "5.01"},
+		{"convert negative", money.MustParse("-10.01").Mul(money.MustParse("0.5"), 2), "-5.01"},
+		{"invert rate", money.MustParse("1").Quo(money.MustParse("1050"), 8), "0.00095238"},
+		{"divide", money.MustParse("10").Quo(money.MustParse("3"), 2), "3.33"},
+		{"divide up", money.MustParse("2").Quo(money.MustParse("3"), 2), "0.67"},
+		{"divide negative", money.MustParse("-2").Quo(money.MustParse("3"), 2), "-0.67"},
+	}
+
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ordered := []string{"-10", "-1.5", "-0.01", "0", "0.001", "0.01", "1.5", "10"}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := money.MustParse(a).Cmp(money.MustParse(b)); got != want {
+				t.Errorf("Cmp(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	if money.MustParse("10.50") != money.MustParse("10.5") || money.New(1050, 2) != money.FromCents(1050) {
+		t.Error("equal values must compare equal with ==")
+	}
+	for _, tt := range []struct {
+		input string
+		sign  int
+	}{{"-0.01", -1}, {"0", 0}, {"-0", 0}, {"0.01", 1}} {
+		a := money.MustParse(tt.input)
+		if a.Sign() != tt.sign || a.IsZero() != (tt.sign == 0) {
+			t.Errorf("%s: Sign() = %d, IsZero() = %t", tt.input, a.Sign(), a.IsZero())
+		}
+	}
+}
+
+func TestNumeric(t *testing.T) {
+	for _, input := range []string{"19.99", "-3.5", "0", "1234567890.12"} {
+		a := money.MustParse(input)
+		n, err := a.NumericValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back money.Amount
+		if err := back.ScanNumeric(n); err != nil || back != a {
+			t.Errorf("%s: scanned %s, %v", input, back, err)
+		}
+	}
+
+	// NUMERIC(12,2) columns return trailing zeros
+	var a money.Amount
+	if err := a.ScanNumeric(pgtype.Numeric{Int: big.NewInt(1050), Exp: -2, Valid: true}); err != nil || a.String() != "10.50" || a.Scale() != 1 {
+		t.Errorf("scanned 1050e-2 as %s (scale %d), %v", a, a.Scale(), err)
+	}
+
+	for name, n := range map[string]pgtype.Numeric{
+		"NaN":      {NaN: true, Valid: true},
+		"infinity": {InfinityModifier: pgtype.Infinity, Valid: true},
+	} {
+		if err := a.ScanNumeric(n); !errors.Is(err, money.ErrInvalidAmount) {
+			t.Errorf("%s: got %v, want %v", name, err, money.ErrInvalidAmount)
+		}
+	}
+	if err := a.ScanNumeric(pgtype.Numeric{}); err == nil {
+		t.Error("NULL: expected an error")
+	}
+}
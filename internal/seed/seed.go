@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
 )
 
-func SeedAll(database *db.DB) error {
+// SeedAll seeds every table; bcryptCost is used to hash the test users' passwords
+func SeedAll(database db.Store, bcryptCost int) error {
 	ctx := context.Background()
 
 	log.Println("Starting database seeding...")
@@ -20,7 +23,7 @@ func SeedAll(database *db.DB) error {
 		return fmt.Errorf("failed to seed roles: %w", err)
 	}
 
-	if err := SeedUsers(ctx, database); err != nil {
+	if err := SeedUsers(ctx, database, bcryptCost); err != nil {
 		return fmt.Errorf("failed to seed users: %w", err)
 	}
 
@@ -33,12 +36,16 @@ func SeedAll(database *db.DB) error {
 		return fmt.Errorf("failed to seed products: %w", err)
 	}
 
+	if err := SeedExchangeRates(ctx, database); err != nil {
+		return fmt.Errorf("failed to seed exchange rates: %w", err)
+	}
+
 	log.Println("Database seeding completed successfully!")
 	return nil
 }
 
 // SeedRoles creates admin and client roles
-func SeedRoles(ctx context.Context, database *db.DB) error {
+func SeedRoles(ctx context.Context, database db.Store) error {
 	log.Println("Seeding roles...")
 
 	roles := []string{"admin", "client"}
@@ -64,7 +71,7 @@ func SeedRoles(ctx context.Context, database *db.DB) error {
 }
 
 // SeedUsers creates test users
-func SeedUsers(ctx context.Context, database *db.DB) error {
+func SeedUsers(ctx context.Context, database db.Store, bcryptCost int) error {
 	log.Println("Seeding users...")
 
 	// Get roles
@@ -104,7 +111,7 @@ func SeedUsers(ctx context.Context, database *db.DB) error {
 		}
 
 		// Hash password
-		passwordHash, err := auth.HashPassword(u.password)
+		passwordHash, err := auth.HashPassword(u.password, bcryptCost)
 		if err != nil {
 			return fmt.Errorf("failed to hash password: %w", err)
 		}
@@ -120,7 +127,7 @@ func SeedUsers(ctx context.Context, database *db.DB) error {
 	return nil
 }
 
-func SeedCategories(ctx context.Context, database *db.DB) error {
+func SeedCategories(ctx context.Context, database db.Store) error {
 	log.Println("Seeding categories...")
 
 	categories := []struct {
@@ -139,7 +146,7 @@ func SeedCategories(ctx context.Context, database *db.DB) error {
 
 	for _, c := range categories {
 		// Check if category already exists
-		existingCategories, err := database.ListCategories(ctx, c.name)
+		existingCategories, _, err := database.ListCategories(ctx, c.name, nil, nil)
 		if err != nil {
 			return fmt.Errorf("failed to check if category exists: %w", err)
 		}
@@ -165,11 +172,11 @@ func SeedCategories(ctx context.Context, database *db.DB) error {
 	return nil
 }
 
-func SeedProducts(ctx context.Context, database *db.DB) error {
+func SeedProducts(ctx context.Context, database db.Store) error {
 	log.Println("Seeding products...")
 
 	// Get all categories
-	allCategories, err := database.ListCategories(ctx, "")
+	allCategories, _, err := database.ListCategories(ctx, "", nil, nil)
 	if err != nil {
 		return fmt.Errorf("failed to get categories: %w", err)
 	}
@@ -188,30 +195,30 @@ func SeedProducts(ctx context.Context, database *db.DB) error {
 	products := []struct {
 		name        string
 		description string
-		price       float64
+		price       string
 		stock       int
 		categories  []string
 	}{
-		{"Laptop Dell XPS 13", "High-performance ultrabook with 11th Gen Intel Core i7", 1299.99, 15, []string{"Electronics"}},
-		{"iPhone 14 Pro", "Latest Apple smartphone with A16 Bionic chip", 999.99, 25, []string{"Electronics"}},
-		{"Wireless Mouse", "Ergonomic wireless mouse with USB receiver", 29.99, 100, []string{"Electronics"}},
-		{"Men's T-Shirt", "Cotton crew neck t-shirt in various colors", 19.99, 200, []string{"Clothing"}},
-		{"Women's Jeans", "Slim fit denim jeans", 49.99, 80, []string{"Clothing"}},
-		{"Running Shoes", "Lightweight running shoes with cushioning", 89.99, 50, []string{"Clothing", "Sports"}},
-		{"The Great Gatsby", "Classic American novel by F. Scott Fitzgerald", 12.99, 30, []string{"Books"}},
-		{"Clean Code", "A Handbook of Agile Software Craftsmanship", 35.99, 40, []string{"Books"}},
-		{"Garden Tools Set", "Complete set of essential gardening tools", 45.99, 25, []string{"Home & Garden"}},
-		{"LED Desk Lamp", "Adjustable LED lamp with USB charging port", 34.99, 60, []string{"Electronics", "Home & Garden"}},
-		{"Yoga Mat", "Non-slip exercise yoga mat", 24.99, 75, []string{"Sports"}},
-		{"Tennis Racket", "Professional tennis racket for adults", 79.99, 20, []string{"Sports"}},
-		{"LEGO Star Wars Set", "Building blocks set with 500 pieces", 59.99, 35, []string{"Toys"}},
-		{"Board Game - Catan", "Strategy board game for 3-4 players", 44.99, 45, []string{"Toys"}},
-		{"Organic Coffee Beans", "Premium arabica coffee beans, 1kg", 18.99, 120, []string{"Food & Beverage"}},
-		{"Green Tea", "Organic green tea, 100 bags", 14.99, 90, []string{"Food & Beverage"}},
-		{"Face Cream", "Anti-aging face cream with vitamin C", 29.99, 65, []string{"Health & Beauty"}},
-		{"Shampoo & Conditioner Set", "Natural hair care set", 24.99, 80, []string{"Health & Beauty"}},
-		{"Bluetooth Speaker", "Portable waterproof speaker with 12h battery", 49.99, 55, []string{"Electronics"}},
-		{"Mechanical Keyboard", "RGB mechanical gaming keyboard", 119.99, 30, []string{"Electronics"}},
+		{"Laptop Dell XPS 13", "High-performance ultrabook with 11th Gen Intel Core i7", "1299.99", 15, []string{"Electronics"}},
+		{"iPhone 14 Pro", "Latest Apple smartphone with A16 Bionic chip", "999.99", 25, []string{"Electronics"}},
+		{"Wireless Mouse", "Ergonomic wireless mouse with USB receiver", "29.99", 100, []string{"Electronics"}},
+		{"Men's T-Shirt", "Cotton crew neck t-shirt in various colors", "19.99", 200, []string{"Clothing"}},
+		{"Women's Jeans", "Slim fit denim jeans", "49.99", 80, []string{"Clothing"}},
+		{"Running Shoes", "Lightweight running shoes with cushioning", "89.99", 50, []string{"Clothing", "Sports"}},
+		{"The Great Gatsby", "Classic American novel by F. Scott Fitzgerald", "12.99", 30, []string{"Books"}},
+		{"Clean Code", "A Handbook of Agile Software Craftsmanship", "35.99", 40, []string{"Books"}},
+		{"Garden Tools Set", "Complete set of essential gardening tools", "45.99", 25, []string{"Home & Garden"}},
+		{"LED Desk Lamp", "Adjustable LED lamp with USB charging port", "34.99", 60, []string{"Electronics", "Home & Garden"}},
+		{"Yoga Mat", "Non-slip exercise yoga mat", "24.99", 75, []string{"Sports"}},
+		{"Tennis Racket", "Professional tennis racket for adults", "79.99", 20, []string{"Sports"}},
+		{"LEGO Star Wars Set", "Building blocks set with 500 pieces", "59.99", 35, []string{"Toys"}},
+		{"Board Game - Catan", "Strategy board game for 3-4 players", "44.99", 45, []string{"Toys"}},
+		{"Organic Coffee Beans", "Premium arabica coffee beans, 1kg", "18.99", 120, []string{"Food & Beverage"}},
+		{"Green Tea", "Organic green tea, 100 bags", "14.99", 90, []string{"Food & Beverage"}},
+		{"Face Cream", "Anti-aging face cream with vitamin C", "29.99", 65, []string{"Health & Beauty"}},
+		{"Shampoo & Conditioner Set", "Natural hair care set", "24.99", 80, []string{"Health & Beauty"}},
+		{"Bluetooth Speaker", "Portable waterproof speaker with 12h battery", "49.99", 55, []string{"Electronics"}},
+		{"Mechanical Keyboard", "RGB mechanical gaming keyboard", "119.99", 30, []string{"Electronics"}},
 	}
 
 	for _, p := range products {
@@ -249,10 +256,11 @@ func SeedProducts(ctx context.Context, database *db.DB) error {
 		}
 
 		desc := p.description
+		price := money.MustParse(p.price)
 		product, err := database.CreateProduct(ctx, &models.ProductCreateRequest{
 			Name:        p.name,
 			Description: &desc,
-			Price:       p.price,
+			Price:       &price,
 			Stock:       p.stock,
 			CategoryIDs: categoryIDs,
 		})
@@ -260,8 +268,39 @@ func SeedProducts(ctx context.Context, database *db.DB) error {
 			return fmt.Errorf("failed to create product '%s': %w", p.name, err)
 		}
 
-		log.Printf("  Created product: %s (ID: %d, Price: $%.2f, Stock: %d)", product.Name, product.ID, product.Price, product.Stock)
+		log.Printf("  Created product: %s (ID: %d, Price: $%s, Stock: %d)", product.Name, product.ID, product.Price, product.Stock)
 	}
 
 	return nil
 }
+
+// SeedExchangeRates loads a starting USD → ARS rate so price conversion works out of the box
+func SeedExchangeRates(ctx context.Context, database db.Store) error {
+	log.Println("Seeding exchange rates...")
+
+	existing, err := database.ListExchangeRates(ctx, models.CurrencyUSD, models.CurrencyARS)
+	if err != nil {
+		return fmt.Errorf("failed to check if exchange rates exist: %w", err)
+	}
+
+	if len(existing) > 0 {
+		log.Printf("  Exchange rate %s → %s already exists, skipping", models.CurrencyUSD, models.CurrencyARS)
+		return nil
+	}
+
+	rate := money.MustParse("1000")
+	effectiveAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	created, err := database.CreateExchangeRate(ctx, &models.ExchangeRateCreateRequest{
+		BaseCurrency:  models.CurrencyUSD,
+		QuoteCurrency: models.CurrencyARS,
+		Rate:          &rate,
+		EffectiveAt:   &effectiveAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	log.Printf("  Created exchange rate: 1 %s = %s %s (ID: %d)", created.BaseCurrency, created.Rate, created.QuoteCurrency, created.ID)
+
+	return nil
+}
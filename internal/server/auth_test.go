@@ -0,0 +1,29 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	h := testharness.New(t)
+
+	body := map[string]string{"email": "new@bsmart.com", "password": "secret123"}
+	h.Do(http.MethodPost, "/api/auth/register", "", body).Expect(t, http.StatusCreated)
+
+	apiErr := h.Do(http.MethodPost, "/api/auth/register", "", body).Expect(t, http.StatusConflict).Error(t)
+	if apiErr.Code != "EMAIL_EXISTS" {
+		t.Fatalf("expected EMAIL_EXISTS, got %s", apiErr.Code)
+	}
+
+	// A duplicate category must not echo PostgreSQL's "Key (name)=(...)" detail
+	admin := h.AdminToken()
+	apiErr = h.Do(http.MethodPost, "/api/categories", admin, map[string]string{"name": "Electronics"}).
+		Expect(t, http.StatusConflict).Error(t)
+	if apiErr.Code != "CONFLICT" || strings.Contains(apiErr.Message, "Key (") {
+		t.Fatalf("expected a fixed CONFLICT message, got %s: %s", apiErr.Code, apiErr.Message)
+	}
+}
@@ -0,0 +1,160 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestBulkUpdateProducts(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var books models.ProductListResponse
+	h.Get("/api/products?category_ids=3&limit=100", admin).Expect(t, http.StatusOK).Data(t, &books)
+	if books.Total == 0 {
+		t.Fatal("expected seeded books")
+	}
+
+	// Reprice a category with a filter: one event for the whole batch
+	ws := h.DialWS()
+	var result models.ProductBulkResult
+	h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{
+		"filter": "category IN (3)", "adjust_price_percent": 10,
+	}).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Affected != books.Total || len(result.ProductIDs) != books.Total {
+		t.Fatalf("expected %d products updated, got %+v", books.Total, result)
+	}
+
+	var event models.ProductBulkEvent
+	if err := json.Unmarshal(ws.Expect(service.EventProductBulkUpdated, 2*time.Second).Data, &event); err != nil || len(event.ProductIDs) != books.Total {
+		t.Fatalf("unexpected product:bulk_updated payload %+v (%v)", event, err)
+	}
+
+	for _, before := range books.Products {
+		var after models.Product
+		h.Get(fmt.Sprintf("/api/products/%d", before.ID), admin).Expect(t, http.StatusOK).Data(t, &after)
+		want := before.Price.Mul(money.MustParse("1.1"), 2)
+		if after.Price != want || after.Version != before.Version+1 {
+			t.Fatalf("product %d: expected price %s at version %d, got %s at %d", before.ID, want, before.Version+1, after.Price, after.Version)
+		}
+
+		var history models.ProductHistoryResponse
+		h.Get(fmt.Sprintf("/api/products/%d/history", before.ID), admin).Expect(t, http.StatusOK).Data(t, &history)
+		if history.Total == 0 || history.History[0].Price != want {
+			t.Fatalf("expected a history row for product %d, got %+v", before.ID, history)
+		}
+	}
+
+	// IDs: unknown ones are reported, stock and categories change together
+	id := books.Products[0].ID
+	h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{
+		"ids": []int{id, 99999}, "adjust_stock": -1, "add_category_ids": []int{5}, "remove_category_ids": []int{3},
+	}).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Affected != 1 || !slices.Equal(result.NotFound, []int{99999}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	var product models.Product
+	h.Get(fmt.Sprintf("/api/products/%d", id), admin).Expect(t, http.StatusOK).Data(t, &product)
+	if product.Stock != books.Products[0].Stock-1 || len(product.Categories) != 1 || product.Categories[0].ID != 5 {
+		t.Fatalf("unexpected product after bulk update: %+v", product)
+	}
+}
+
+func TestBulkUpdateIsAllOrNothing(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var before models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &before)
+
+	// The product with the least stock would go negative, so nothing changes
+	resp := h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{
+		"filter": "id <= 20", "set_price": 1, "adjust_stock": -100,
+	}).Expect(t, http.StatusBadRequest)
+	if apiErr := resp.Error(t); apiErr.Code != "VALIDATION_ERROR" {
+		t.Fatalf("expected VALIDATION_ERROR, got %s", apiErr.Code)
+	}
+
+	var after models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &after)
+	if after.Price != before.Price || after.Version != before.Version {
+		t.Fatalf("a failed bulk update must not change anything: %+v", after)
+	}
+
+	h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{
+		"ids": []int{1}, "add_category_ids": []int{404},
+	}).Expect(t, http.StatusUnprocessableEntity)
+}
+
+func TestBulkDeleteProducts(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	before := productTotal(t, h, admin)
+
+	ws := h.DialWS()
+	var result models.ProductBulkResult
+	h.DoWithHeaders(http.MethodDelete, "/api/products/bulk", admin, map[string]any{"ids": []int{1, 2, 3}}, nil).
+		Expect(t, http.StatusOK).Data(t, &result)
+	if result.Affected != 3 {
+		t.Fatalf("expected 3 products deleted, got %+v", result)
+	}
+	ws.Expect(service.EventProductBulkDeleted, 2*time.Second)
+
+	if productTotal(t, h, admin) != before-3 {
+		t.Fatal("expected the products to leave the listing")
+	}
+	h.Get("/api/products/2", admin).Expect(t, http.StatusNotFound)
+
+	// Deleting them again finds nothing
+	h.DoWithHeaders(http.MethodDelete, "/api/products/bulk", admin, map[string]any{"ids": []int{1, 2, 3}}, nil).
+		Expect(t, http.StatusOK).Data(t, &result)
+	if result.Affected != 0 || len(result.NotFound) != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestBulkRejectsBadRequests(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	cases := []struct {
+		body map[string]any
+		code string
+	}{
+		{map[string]any{"set_price": 1}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1}, "filter": "id = 1", "set_price": 1}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1}}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1}, "set_price": 1, "adjust_price_percent": 5}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1}, "set_stock": 1, "adjust_stock": 5}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1}, "add_category_ids": []int{2}, "remove_category_ids": []int{2}}, "VALIDATION_ERROR"},
+		{map[string]any{"filter": "colour = 'red'", "set_stock": 1}, "VALIDATION_ERROR"},
+		{map[string]any{"ids": []int{1, 1}, "set_stock": 1}, "INVALID_INPUT"},
+		{map[string]any{"ids": []int{1}, "adjust_price_percent": -101}, "INVALID_INPUT"},
+	}
+	for _, tc := range cases {
+		resp := h.Do(http.MethodPatch, "/api/products/bulk", admin, tc.body).Expect(t, http.StatusBadRequest)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%v: expected %s, got %s (%s)", tc.body, tc.code, apiErr.Code, apiErr.Message)
+		}
+	}
+
+	apiErr := h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{"filter": "price >", "set_stock": 1}).
+		Expect(t, http.StatusBadRequest).Error(t)
+	if !strings.HasPrefix(apiErr.Message, "Invalid filter: ") {
+		t.Errorf("expected an \"Invalid filter: \" message, got %q", apiErr.Message)
+	}
+
+	h.Do(http.MethodPatch, "/api/products/bulk", h.ClientToken(), map[string]any{"ids": []int{1}, "set_stock": 1}).
+		Expect(t, http.StatusForbidden)
+}
@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestReadCache(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var product models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK)
+	h.Get("/api/products/1?currency=USD", admin).Expect(t, http.StatusOK)
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &product)
+	if product.Currency != "ARS" || product.Conversion != nil {
+		t.Fatalf("a converted read leaked into the cache: %+v", product)
+	}
+
+	// Writes are visible right away
+	h.Do(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": 42}).Expect(t, http.StatusOK)
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &product)
+	if product.Stock != 42 {
+		t.Fatalf("expected the updated stock, got %d", product.Stock)
+	}
+
+	var categories models.CategoryListResponse
+	h.Get("/api/categories", admin).Expect(t, http.StatusOK)
+	h.Do(http.MethodPut, "/api/categories/3", admin, map[string]any{"name": "Novels"}).Expect(t, http.StatusOK)
+	h.Get("/api/categories", admin).Expect(t, http.StatusOK).Data(t, &categories)
+	if !containsCategory(categories.Categories, "Novels") {
+		t.Fatalf("renamed category missing from %+v", categories.Categories)
+	}
+	h.Do(http.MethodDelete, "/api/products/1", admin, nil).Expect(t, http.StatusOK)
+	h.Get("/api/products/1", admin).Expect(t, http.StatusNotFound)
+
+	h.Get("/api/cache/stats", h.ClientToken()).Expect(t, http.StatusForbidden)
+	var stats models.CacheStats
+	h.Get("/api/cache/stats", admin).Expect(t, http.StatusOK).Data(t, &stats)
+	if !stats.Enabled || stats.Backend != "memory" || stats.Entries == 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if product := stats.Resources["product"]; product.Hits == 0 || product.Misses == 0 {
+		t.Fatalf("expected product hits and misses, got %+v", stats.Resources)
+	}
+	if stats.Hits+stats.Misses == 0 || stats.HitRatio <= 0 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+}
+
+func TestReadCacheDisabled(t *testing.T) {
+	h := testharness.New(t, func(cfg *config.Config) {
+		cfg.Cache.Backend = "none"
+	})
+
+	var stats models.CacheStats
+	h.Get("/api/cache/stats", h.AdminToken()).Expect(t, http.StatusOK).Data(t, &stats)
+	if stats.Enabled || stats.Backend != "none" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func containsCategory(categories []models.Category, name string) bool {
+	for _, c := range categories {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestProductConditionalGet(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	resp := h.Get("/api/products/1", admin).Expect(t, http.StatusOK)
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if !strings.HasPrefix(etag, `"1.`) || lastModified == "" || resp.Header.Get("Cache-Control") != "private, no-cache" {
+		t.Fatalf("unexpected cache headers: %v", resp.Header)
+	}
+
+	resp = h.DoWithHeaders(http.MethodGet, "/api/products/1", admin, nil, http.Header{"If-None-Match": {etag}}).
+		Expect(t, http.StatusNotModified)
+	if len(resp.Body) != 0 || resp.Header.Get("ETag") != etag {
+		t.Fatalf("unexpected 304 response: %v %s", resp.Header, resp.Body)
+	}
+	h.DoWithHeaders(http.MethodGet, "/api/products/1", admin, nil, http.Header{"If-Modified-Since": {lastModified}}).
+		Expect(t, http.StatusNotModified)
+
+	// Renaming an embedded category changes the product representation
+	h.Do(http.MethodPut, "/api/categories/1", admin, map[string]any{"name": "Gadgets"}).Expect(t, http.StatusOK)
+	var product models.Product
+	resp = h.DoWithHeaders(http.MethodGet, "/api/products/1", admin, nil, http.Header{"If-None-Match": {etag}}).
+		Expect(t, http.StatusOK)
+	resp.Data(t, &product)
+	if product.Categories[0].Name != "Gadgets" || resp.Header.Get("ETag") == etag || resp.Header.Get("ETag") != models.ProductETag(&product) {
+		t.Fatalf("expected a fresh product, got %+v (ETag %q)", product, resp.Header.Get("ETag"))
+	}
+
+	// Converted prices depend on the exchange rate, not just the version
+	resp = h.Get("/api/products/1?currency=USD", admin).Expect(t, http.StatusOK)
+	converted := resp.Header.Get("ETag")
+	if !strings.HasPrefix(converted, `W/"`) || resp.Header.Get("Last-Modified") != "" {
+		t.Fatalf("unexpected cache headers for a converted price: %v", resp.Header)
+	}
+	h.DoWithHeaders(http.MethodGet, "/api/products/1?currency=USD", admin, nil, http.Header{"If-None-Match": {converted}}).
+		Expect(t, http.StatusNotModified)
+}
+
+func TestListConditionalGet(t *testing.T) {
+	h := testharness.New(t, func(cfg *config.Config) {
+		cfg.HTTPCache.CategoriesMaxAge = config.Duration(90 * time.Second)
+	})
+	admin := h.AdminToken()
+
+	resp := h.Get("/api/categories", admin).Expect(t, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Cache-Control") != "private, max-age=90" {
+		t.Fatalf("unexpected cache headers: %v", resp.Header)
+	}
+	h.DoWithHeaders(http.MethodGet, "/api/categories", h.ClientToken(), nil, http.Header{"If-None-Match": {`"other", ` + etag}}).
+		Expect(t, http.StatusNotModified)
+
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Garden"}).Expect(t, http.StatusCreated)
+	h.DoWithHeaders(http.MethodGet, "/api/categories", admin, nil, http.Header{"If-None-Match": {etag}}).
+		Expect(t, http.StatusOK)
+
+	page := h.Get("/api/products?limit=5", admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	h.DoWithHeaders(http.MethodGet, "/api/products?limit=5", admin, nil, http.Header{"If-None-Match": {page}}).
+		Expect(t, http.StatusNotModified)
+
+	// Any change to a listed product invalidates the page
+	var first models.ProductListResponse
+	h.Get("/api/products?limit=5", admin).Data(t, &first)
+	h.Do(http.MethodPut, fmt.Sprintf("/api/products/%d", first.Products[0].ID), admin, map[string]any{"stock": 99}).Expect(t, http.StatusOK)
+	h.DoWithHeaders(http.MethodGet, "/api/products?limit=5", admin, nil, http.Header{"If-None-Match": {page}}).
+		Expect(t, http.StatusOK)
+
+	// The tag is known before the page is read: it covers the query, and
+	// category, trash and conversion changes the page embeds
+	page = h.Get("/api/products?limit=5", admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	other := h.Get("/api/products?limit=6", admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	if other == page {
+		t.Fatalf("expected each query to have its own ETag, got %s", page)
+	}
+	h.Do(http.MethodPut, "/api/categories/1", admin, map[string]any{"name": "Gadgets"}).Expect(t, http.StatusOK)
+	h.DoWithHeaders(http.MethodGet, "/api/products?limit=5", admin, nil, http.Header{"If-None-Match": {page}}).
+		Expect(t, http.StatusOK)
+
+	page = h.Get("/api/products?limit=5", admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	h.Do(http.MethodDelete, fmt.Sprintf("/api/products/%d", first.Products[0].ID), admin, nil).Expect(t, http.StatusOK)
+	h.DoWithHeaders(http.MethodGet, "/api/products?limit=5", admin, nil, http.Header{"If-None-Match": {page}}).
+		Expect(t, http.StatusOK)
+
+	converted := h.Get("/api/products?limit=5&currency=USD", admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	h.DoWithHeaders(http.MethodGet, "/api/products?limit=5&currency=USD", admin, nil, http.Header{"If-None-Match": {converted}}).
+		Expect(t, http.StatusNotModified)
+}
+
+// The product ETag covers its embedded categories, not just the product row
+func TestProductETagTracksCategories(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var product models.Product
+	resp := h.Get("/api/products/1", admin).Expect(t, http.StatusOK)
+	resp.Data(t, &product)
+	etag := resp.Header.Get("ETag")
+
+	category := product.Categories[0]
+	h.DoWithHeaders(http.MethodPut, fmt.Sprintf("/api/categories/%d", category.ID), admin,
+		map[string]any{"name": "Renamed " + category.Name}, nil).Expect(t, http.StatusOK)
+
+	resp = h.DoWithHeaders(http.MethodGet, "/api/products/1", admin, nil, http.Header{"If-None-Match": {etag}}).
+		Expect(t, http.StatusOK)
+	resp.Data(t, &product)
+	if product.Categories[0].Name != "Renamed "+category.Name || resp.Header.Get("ETag") == etag {
+		t.Fatalf("expected the renamed category under a new ETag, got %+v (ETag %q)", product.Categories, resp.Header.Get("ETag"))
+	}
+
+	// Category edits leave the product version alone, so the tag read
+	// before the rename still passes a conditional write
+	h.DoWithHeaders(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": 3}, ifMatch(etag)).
+		Expect(t, http.StatusOK)
+}
@@ -0,0 +1,61 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestCategoryLifecycle(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Category
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Garage", "description": "Tools"}).
+		Expect(t, http.StatusCreated).Data(t, &created)
+
+	// Names are unique
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Garage"}).Expect(t, http.StatusConflict)
+
+	path := fmt.Sprintf("/api/categories/%d", created.ID)
+
+	var updated models.Category
+	h.Do(http.MethodPut, path, admin, map[string]any{"name": "Garage & Tools"}).
+		Expect(t, http.StatusOK).Data(t, &updated)
+	if updated.Name != "Garage & Tools" {
+		t.Fatalf("unexpected updated category: %+v", updated)
+	}
+
+	var list models.CategoryListResponse
+	h.Get("/api/categories?search=garage", h.ClientToken()).Expect(t, http.StatusOK).Data(t, &list)
+	if list.Total != 1 || list.Categories[0].ID != created.ID {
+		t.Fatalf("unexpected category search: %+v", list)
+	}
+
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusOK)
+	h.Get(path, admin).Expect(t, http.StatusNotFound)
+}
+
+func TestDeletingCategoryUnlinksProducts(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	// Running Shoes belongs to Clothing (2) and Sports (5)
+	var shoes models.ProductListResponse
+	h.Get("/api/search?q=running", admin).Expect(t, http.StatusOK).Data(t, &shoes)
+	if shoes.Total != 1 {
+		t.Fatalf("expected to find Running Shoes, got %+v", shoes)
+	}
+	productPath := fmt.Sprintf("/api/products/%d", shoes.Products[0].ID)
+
+	h.Do(http.MethodDelete, "/api/categories/5", admin, nil).Expect(t, http.StatusOK)
+
+	var product models.Product
+	h.Get(productPath, admin).Expect(t, http.StatusOK).Data(t, &product)
+	if len(product.Categories) != 1 || product.Categories[0].ID != 2 {
+		t.Fatalf("expected only Clothing to remain, got %+v", product.Categories)
+	}
+}
@@ -0,0 +1,109 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func ifMatch(etag string) http.Header {
+	return http.Header{"If-Match": {etag}}
+}
+
+func TestProductIfMatch(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Product
+	resp := h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name": "Versioned Widget", "price": 10, "stock": 1, "category_ids": []int{1},
+	}).Expect(t, http.StatusCreated)
+	resp.Data(t, &created)
+	if created.Version != 1 || resp.Header.Get("ETag") != `"1"` {
+		t.Fatalf("expected version 1, got %d (ETag %q)", created.Version, resp.Header.Get("ETag"))
+	}
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+
+	etag := h.Get(path, admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	if versions := models.ParseIfMatch(etag); len(versions) != 1 || versions[0] != 1 {
+		t.Fatalf("unexpected ETag on GET: %q", etag)
+	}
+
+	// First writer wins and bumps the version
+	var updated models.Product
+	resp = h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 5}, ifMatch(etag)).
+		Expect(t, http.StatusOK)
+	resp.Data(t, &updated)
+	if updated.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("expected version 2, got %d (ETag %q)", updated.Version, resp.Header.Get("ETag"))
+	}
+
+	// A writer holding the old ETag gets 412 with the current representation
+	resp = h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 9}, ifMatch(etag)).
+		Expect(t, http.StatusPreconditionFailed)
+	if code := resp.Error(t).Code; code != "PRECONDITION_FAILED" {
+		t.Fatalf("unexpected error code %q", code)
+	}
+	var current models.Product
+	resp.Data(t, &current)
+	if current.Stock != 5 || current.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("unexpected current product: %+v (ETag %q)", current, resp.Header.Get("ETag"))
+	}
+
+	// Any listed ETag and "*" match; weak tags never do
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 6}, ifMatch(`"7", "2"`)).Expect(t, http.StatusOK)
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 7}, ifMatch(`W/"3"`)).Expect(t, http.StatusPreconditionFailed)
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 7}, ifMatch("*")).Expect(t, http.StatusOK)
+
+	// Without If-Match the write is unconditional
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 8}).Expect(t, http.StatusOK)
+
+	h.DoWithHeaders(http.MethodDelete, path, admin, nil, ifMatch(etag)).Expect(t, http.StatusPreconditionFailed)
+	h.DoWithHeaders(http.MethodDelete, path, admin, nil, ifMatch(`"5"`)).Expect(t, http.StatusOK)
+
+	// Once deleted the product is gone, whatever the precondition says
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 1}, ifMatch(`"6"`)).Expect(t, http.StatusNotFound)
+}
+
+func TestCategoryIfMatch(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Category
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Versioned"}).
+		Expect(t, http.StatusCreated).Data(t, &created)
+	path := fmt.Sprintf("/api/categories/%d", created.ID)
+
+	etag := h.Get(path, admin).Expect(t, http.StatusOK).Header.Get("ETag")
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"name": "Versioned 2"}, ifMatch(etag)).Expect(t, http.StatusOK)
+
+	var current models.Category
+	h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"name": "Versioned 3"}, ifMatch(etag)).
+		Expect(t, http.StatusPreconditionFailed).Data(t, &current)
+	if current.Name != "Versioned 2" || current.Version != 2 {
+		t.Fatalf("unexpected current category: %+v", current)
+	}
+
+	h.DoWithHeaders(http.MethodDelete, path, admin, nil, ifMatch(etag)).Expect(t, http.StatusPreconditionFailed)
+	h.DoWithHeaders(http.MethodDelete, path, admin, nil, ifMatch(models.ETag(current.Version))).Expect(t, http.StatusOK)
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	h := testharness.New(t, func(cfg *config.Config) {
+		cfg.Concurrency.RequireIfMatch = true
+	})
+	admin := h.AdminToken()
+
+	resp := h.Do(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": 1}).
+		Expect(t, http.StatusPreconditionRequired)
+	if code := resp.Error(t).Code; code != "PRECONDITION_REQUIRED" {
+		t.Fatalf("unexpected error code %q", code)
+	}
+	h.Do(http.MethodDelete, "/api/categories/1", admin, nil).Expect(t, http.StatusPreconditionRequired)
+
+	h.DoWithHeaders(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": 1}, ifMatch(`"1"`)).Expect(t, http.StatusOK)
+}
@@ -0,0 +1,112 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestExchangeRateConversion(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	// Seeded rate: 1 USD = 1000 ARS
+	var rates models.ExchangeRateListResponse
+	h.Get("/api/exchange-rates?base=usd&quote=ars", h.ClientToken()).Expect(t, http.StatusOK).Data(t, &rates)
+	if rates.Total != 1 || rates.Rates[0].Rate != money.MustParse("1000") {
+		t.Fatalf("unexpected seeded rates: %+v", rates)
+	}
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name":         "Imported Widget",
+		"price":        "25.50",
+		"currency":     "USD",
+		"stock":        1,
+		"category_ids": []int{1},
+	}).Expect(t, http.StatusCreated).Data(t, &created)
+	if created.Currency != models.CurrencyUSD || created.Conversion != nil {
+		t.Fatalf("unexpected created product: %+v", created)
+	}
+
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+
+	// USD → ARS uses the rate as stored
+	var inARS models.Product
+	h.Get(path+"?currency=ARS", admin).Expect(t, http.StatusOK).Data(t, &inARS)
+	if inARS.Price != money.MustParse("25500") || inARS.Currency != models.CurrencyARS {
+		t.Fatalf("unexpected converted product: %+v", inARS)
+	}
+	if c := inARS.Conversion; c == nil || c.OriginalPrice != money.MustParse("25.5") || c.OriginalCurrency != "USD" || c.Inverted {
+		t.Fatalf("unexpected conversion: %+v", inARS.Conversion)
+	}
+
+	// ARS → USD falls back to the inverse of the USD → ARS rate
+	var products models.ProductListResponse
+	h.Get("/api/products?currency=usd&search=Clean", admin).Expect(t, http.StatusOK).Data(t, &products)
+	if len(products.Products) != 1 {
+		t.Fatalf("expected 1 product, got %+v", products)
+	}
+	book := products.Products[0]
+	if book.Price != money.MustParse("0.04") || book.Currency != "USD" || book.Conversion == nil || !book.Conversion.Inverted {
+		t.Fatalf("unexpected inverse conversion: %+v", book)
+	}
+	if book.Conversion.Rate != money.MustParse("0.001") {
+		t.Fatalf("expected rate 0.001, got %s", book.Conversion.Rate)
+	}
+
+	// A newer rate takes over
+	h.Do(http.MethodPost, "/api/exchange-rates", admin, map[string]any{
+		"base_currency": "USD", "quote_currency": "ARS", "rate": "1200",
+	}).Expect(t, http.StatusCreated)
+	h.Get(path+"?currency=ARS", admin).Expect(t, http.StatusOK).Data(t, &inARS)
+	if inARS.Price != money.MustParse("30600") {
+		t.Fatalf("expected the newest rate to apply, got %s", inARS.Price)
+	}
+
+	// No rate between the currencies
+	if code := h.Get(path+"?currency=EUR", admin).Expect(t, http.StatusUnprocessableEntity).Error(t).Code; code != "INVALID_REFERENCE" {
+		t.Fatalf("expected INVALID_REFERENCE, got %s", code)
+	}
+	if code := h.Get(path+"?currency=dollars", admin).Expect(t, http.StatusBadRequest).Error(t).Code; code != "INVALID_CURRENCY" {
+		t.Fatalf("expected INVALID_CURRENCY, got %s", code)
+	}
+
+	// Changing the currency is recorded in the history
+	h.Do(http.MethodPut, path, admin, map[string]any{"currency": "ARS", "price": 30600}).Expect(t, http.StatusOK)
+	var history models.ProductHistoryResponse
+	h.Get(path+"/history", admin).Expect(t, http.StatusOK).Data(t, &history)
+	if history.Total != 1 || history.History[0].Currency != "ARS" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+func TestExchangeRateManagement(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	rate := map[string]any{"base_currency": "EUR", "quote_currency": "USD", "rate": 1.08, "effective_at": "2025-01-01T00:00:00Z"}
+	h.Do(http.MethodPost, "/api/exchange-rates", h.ClientToken(), rate).Expect(t, http.StatusForbidden)
+
+	var created models.ExchangeRate
+	h.Do(http.MethodPost, "/api/exchange-rates", admin, rate).Expect(t, http.StatusCreated).Data(t, &created)
+	h.Do(http.MethodPost, "/api/exchange-rates", admin, rate).Expect(t, http.StatusConflict)
+
+	invalid := []map[string]any{
+		{"base_currency": "EUR", "quote_currency": "EUR", "rate": 1},
+		{"base_currency": "EUR", "quote_currency": "USD", "rate": 0},
+		{"base_currency": "EUR", "quote_currency": "USD", "rate": "1.123456789"},
+		{"base_currency": "euro", "quote_currency": "USD", "rate": 1},
+	}
+	for _, body := range invalid {
+		h.Do(http.MethodPost, "/api/exchange-rates", admin, body).Expect(t, http.StatusBadRequest)
+	}
+
+	path := fmt.Sprintf("/api/exchange-rates/%d", created.ID)
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusOK)
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusNotFound)
+}
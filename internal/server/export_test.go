@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestExportProductsCSV(t *testing.T) {
+	h := testharness.New(t)
+	token := h.ClientToken()
+
+	var page models.ProductListResponse
+	h.Get("/api/products?category_ids=1&sort=price:desc&limit=100", token).Expect(t, http.StatusOK).Data(t, &page)
+
+	resp := h.Get("/api/products/export?category_ids=1&sort=price:desc", token).Expect(t, http.StatusOK)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="products.csv"` {
+		t.Fatalf("unexpected Content-Disposition %q", cd)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(resp.Body)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if got := strings.Join(records[0], ","); got != "id,sku,name,description,price,currency,stock,categories,created_at,updated_at,version" {
+		t.Fatalf("unexpected header %s", got)
+	}
+	if len(records)-1 != page.Total {
+		t.Fatalf("expected %d rows like the listing, got %d", page.Total, len(records)-1)
+	}
+	for i, product := range page.Products {
+		row := records[i+1]
+		if row[0] != fmt.Sprint(product.ID) || row[4] != product.Price.String() || !strings.Contains(row[7], "Electronics") {
+			t.Fatalf("row %d does not match product %+v: %v", i+1, product, row)
+		}
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	h := testharness.New(t)
+	token := h.ClientToken()
+
+	// NDJSON with a subset of columns, categories as an array
+	resp := h.Get("/api/products/export?format=ndjson&fields=id,price&include=categories&category_ids=3", token).Expect(t, http.StatusOK)
+	scanner := bufio.NewScanner(bytes.NewReader(resp.Body))
+	lines := 0
+	for scanner.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		if len(row) != 3 || !strings.Contains(fmt.Sprint(row["categories"]), "Books") {
+			t.Fatalf("unexpected NDJSON row %v", row)
+		}
+		lines++
+	}
+	if lines == 0 {
+		t.Fatal("expected products in the Books category")
+	}
+
+	// XLSX is a zip with a single sheet of inline strings and numbers
+	resp = h.Get("/api/categories/export?format=xlsx", token).Expect(t, http.StatusOK)
+	archive, err := zip.NewReader(bytes.NewReader(resp.Body), int64(len(resp.Body)))
+	if err != nil {
+		t.Fatalf("invalid XLSX: %v", err)
+	}
+	var sheet []byte
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, _ := f.Open()
+			sheet, _ = io.ReadAll(r)
+			r.Close()
+		}
+	}
+	if !bytes.Contains(sheet, []byte(`<t xml:space="preserve">Home &amp; Garden</t>`)) || bytes.Count(sheet, []byte("<row ")) != 9 {
+		t.Fatalf("unexpected sheet: %s", sheet)
+	}
+
+	// History of a product after a price change
+	admin := h.AdminToken()
+	h.DoWithHeaders(http.MethodPut, "/api/products/1", admin, map[string]any{"price": 1234.5}, nil).Expect(t, http.StatusOK)
+	resp = h.Get("/api/products/1/history/export?fields=price,changed_at", token).Expect(t, http.StatusOK)
+	records, err := csv.NewReader(bytes.NewReader(resp.Body)).ReadAll()
+	if err != nil || len(records) < 2 || records[0][0] != "price" || records[1][0] != "1234.50" {
+		t.Fatalf("unexpected history export %q (%v)", resp.Body, err)
+	}
+}
+
+func TestExportRejectsBadRequests(t *testing.T) {
+	h := testharness.New(t)
+	token := h.ClientToken()
+
+	cases := []struct {
+		path string
+		code string
+	}{
+		{"/api/products/export?format=pdf", "INVALID_FORMAT"},
+		{"/api/products/export?min_price=abc", "INVALID_FILTER"},
+		{"/api/products/export?fields=colour", "INVALID_FIELDS"},
+		{"/api/products/abc/history/export", "INVALID_ID"},
+	}
+	for _, tc := range cases {
+		resp := h.Get(tc.path, token).Expect(t, http.StatusBadRequest)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%s: expected %s, got %s", tc.path, tc.code, apiErr.Code)
+		}
+	}
+
+	h.Get("/api/products/export", "").Expect(t, http.StatusUnauthorized)
+}
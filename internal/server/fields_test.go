@@ -0,0 +1,103 @@
+package server_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+// keys returns the sorted keys of each object listed under list in body
+func keys(t *testing.T, body map[string]any, list string) [][]string {
+	t.Helper()
+	items, ok := body[list].([]any)
+	if !ok || len(items) == 0 {
+		t.Fatalf("expected a non-empty %q list, got %v", list, body[list])
+	}
+	out := make([][]string, len(items))
+	for i, item := range items {
+		for key := range item.(map[string]any) {
+			out[i] = append(out[i], key)
+		}
+		slices.Sort(out[i])
+	}
+	return out
+}
+
+func TestSparseFieldsets(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	get := func(path string) map[string]any {
+		t.Helper()
+		var body map[string]any
+		h.Get(path, client).Expect(t, http.StatusOK).Data(t, &body)
+		return body
+	}
+	expectKeys := func(path, list string, want ...string) map[string]any {
+		t.Helper()
+		body := get(path)
+		for _, got := range keys(t, body, list) {
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s: expected keys %v, got %v", path, want, got)
+			}
+		}
+		return body
+	}
+
+	// Without fields every product embeds its categories
+	all := get("/api/products?limit=3")
+	for _, got := range keys(t, all, "products") {
+		if !slices.Contains(got, "categories") || !slices.Contains(got, "description") {
+			t.Fatalf("expected full products, got keys %v", got)
+		}
+	}
+
+	// fields selects columns and leaves categories out unless included
+	body := expectKeys("/api/products?limit=3&fields=id,name,price", "products", "id", "name", "price")
+	if body["total"] != float64(20) {
+		t.Fatalf("expected the page metadata to stay, got %v", body)
+	}
+	expectKeys("/api/products?limit=3&fields=name&include=categories", "products", "categories", "name")
+	expectKeys("/api/search?q=laptop&fields=id,stock", "products", "id", "stock")
+
+	// include alone keeps every column
+	got := keys(t, get("/api/products?limit=3&include=categories"), "products")
+	if !slices.Contains(got[0], "version") || !slices.Contains(got[0], "categories") {
+		t.Fatalf("expected every field with include alone, got %v", got[0])
+	}
+
+	// Cursors still work when the sort fields are not requested
+	first := expectKeys("/api/products?cursor=&limit=5&sort=price:desc&fields=name", "products", "name")
+	next, ok := first["next_cursor"].(string)
+	if !ok {
+		t.Fatalf("expected a next cursor, got %v", first)
+	}
+	expectKeys("/api/products?cursor="+next+"&limit=5&sort=price:desc&fields=name", "products", "name")
+
+	// A converted price keeps its conversion
+	expectKeys("/api/products?limit=3&fields=price&currency=USD", "products", "conversion", "price")
+
+	// Categories and history
+	expectKeys("/api/categories?fields=name", "categories", "name")
+	expectKeys("/api/categories?cursor=&limit=2&fields=id", "categories", "id")
+	expectKeys("/api/search?type=category&q=o&fields=id,name", "categories", "id", "name")
+
+	admin := h.AdminToken()
+	h.Do(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": 3}).Expect(t, http.StatusOK)
+	expectKeys("/api/products/1/history?fields=stock,changed_at", "history", "changed_at", "stock")
+
+	for path, code := range map[string]string{
+		"/api/products?fields=id,cost":         "INVALID_FIELDS",
+		"/api/products?include=history":        "INVALID_FIELDS",
+		"/api/categories?fields=categories":    "INVALID_FIELDS",
+		"/api/categories?include=products":     "INVALID_FIELDS",
+		"/api/products/1/history?fields=name":  "INVALID_FIELDS",
+		"/api/search?type=category&fields=foo": "INVALID_FIELDS",
+	} {
+		if apiErr := h.Get(path, client).Expect(t, http.StatusBadRequest).Error(t); apiErr.Code != code {
+			t.Errorf("%s: expected %s, got %s", path, code, apiErr.Code)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func importFile(h *testharness.Harness, token, query, contentType, body string) *testharness.Response {
+	h.T.Helper()
+	return h.DoWithHeaders(http.MethodPost, "/api/products/import"+query, token, body, http.Header{"Content-Type": {contentType}})
+}
+
+func productTotal(t *testing.T, h *testharness.Harness, token string) int {
+	t.Helper()
+	var page models.ProductListResponse
+	h.Get("/api/products?limit=1", token).Expect(t, http.StatusOK).Data(t, &page)
+	return page.Total
+}
+
+func TestImportProductsCSV(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var existing models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"sku": "ACME-1", "name": "Old Name", "price": 10, "stock": 1, "category_ids": []int{1},
+	}).Expect(t, http.StatusCreated).Data(t, &existing)
+
+	ws := h.DialWS()
+	csv := "SKU,name,price,stock,categories,description\n" +
+		"ACME-1,New Name,12.50,7,Electronics|3,\n" +
+		"ACME-2,Drill,89.90,4,home & garden,\"Cordless, 18V\"\n" +
+		",No SKU,5,2,6,\n"
+
+	var report models.ProductImportReport
+	importFile(h, admin, "", "text/csv", csv).Expect(t, http.StatusOK).Data(t, &report)
+	if !report.Applied || report.Created != 2 || report.Updated != 1 || report.Failed != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if got := report.Rows[0]; got.Line != 2 || got.Action != models.ImportActionUpdate || got.ProductID != existing.ID {
+		t.Fatalf("expected line 2 to update product %d, got %+v", existing.ID, got)
+	}
+
+	// One aggregated event for the whole import
+	var event models.ProductImportEvent
+	if err := json.Unmarshal(ws.Expect(service.EventProductImported, 2*time.Second).Data, &event); err != nil || len(event.ProductIDs) != 3 {
+		t.Fatalf("unexpected product:imported payload %+v (%v)", event, err)
+	}
+
+	var updated models.Product
+	h.Get(fmt.Sprintf("/api/products/%d", existing.ID), admin).Expect(t, http.StatusOK).Data(t, &updated)
+	if updated.Name != "New Name" || updated.Price.String() != "12.50" || updated.Stock != 7 || len(updated.Categories) != 2 {
+		t.Fatalf("product was not updated from the import: %+v", updated)
+	}
+
+	var drill models.Product
+	h.Get(fmt.Sprintf("/api/products/%d", report.Rows[1].ProductID), admin).Expect(t, http.StatusOK).Data(t, &drill)
+	if drill.SKU == nil || *drill.SKU != "ACME-2" || drill.Description == nil || *drill.Description != "Cordless, 18V" || drill.Categories[0].ID != 4 {
+		t.Fatalf("unexpected imported product: %+v", drill)
+	}
+
+	// Importing again only updates
+	importFile(h, admin, "", "text/csv", csv).Expect(t, http.StatusOK).Data(t, &report)
+	if report.Created != 1 || report.Updated != 2 {
+		t.Fatalf("expected SKUs to be matched on a second import, got %+v", report)
+	}
+}
+
+func TestImportProductsModes(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	before := productTotal(t, h, admin)
+
+	ndjson := `{"sku": "N-1", "name": "Valid", "price": 3, "stock": 1, "categories": [1, "Books"]}
+
+{"sku": "N-2", "name": "X", "price": 1.234, "stock": 1, "categories": ["Nope"]}
+{"sku": "N-1", "name": "Duplicate", "price": 3, "stock": 1, "categories": [1]}
+{"name": "Typo", "prize": 3}
+{"name": "Also valid", "price": 7, "stock": 2, "categories": [5]}
+`
+
+	// Atomic: one bad row rejects everything
+	resp := importFile(h, admin, "", "application/x-ndjson", ndjson).Expect(t, http.StatusUnprocessableEntity)
+	if apiErr := resp.Error(t); apiErr.Code != "IMPORT_REJECTED" {
+		t.Fatalf("expected IMPORT_REJECTED, got %s", apiErr.Code)
+	}
+	var report models.ProductImportReport
+	resp.Data(t, &report)
+	if report.Applied || report.Failed != 3 || report.Total != 5 || report.Created != 0 || report.Updated != 0 {
+		t.Fatalf("unexpected rejected report: %+v", report)
+	}
+	// A dry run answers as the real import would
+	importFile(h, admin, "?dry_run=true", "application/x-ndjson", ndjson).Expect(t, http.StatusUnprocessableEntity)
+
+	bad := report.Rows[1]
+	if bad.Line != 3 || len(bad.Errors) != 3 {
+		t.Fatalf("expected name, price and category errors on line 3, got %+v", bad)
+	}
+	if dup := report.Rows[2]; dup.Errors[0].Field != "sku" || !strings.Contains(dup.Errors[0].Message, "line 1") {
+		t.Fatalf("expected a duplicate SKU error, got %+v", dup)
+	}
+	if productTotal(t, h, admin) != before {
+		t.Fatal("a rejected import must not write anything")
+	}
+
+	// Dry run reports what best effort would do without writing
+	importFile(h, admin, "?mode=best_effort&dry_run=true", "application/x-ndjson", ndjson).Expect(t, http.StatusOK).Data(t, &report)
+	if report.Applied || !report.DryRun || report.Created != 2 || report.Rows[0].ProductID != 0 {
+		t.Fatalf("unexpected dry run report: %+v", report)
+	}
+	if productTotal(t, h, admin) != before {
+		t.Fatal("a dry run must not write anything")
+	}
+
+	// Best effort writes the valid rows
+	importFile(h, admin, "?mode=best_effort", "application/x-ndjson", ndjson).Expect(t, http.StatusOK).Data(t, &report)
+	if !report.Applied || report.Created != 2 || report.Failed != 3 {
+		t.Fatalf("unexpected best effort report: %+v", report)
+	}
+	if productTotal(t, h, admin) != before+2 {
+		t.Fatal("expected the two valid rows to be created")
+	}
+}
+
+func TestImportProductsRejectsBadRequests(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	cases := []struct {
+		query, contentType, body string
+		status                   int
+		code                     string
+	}{
+		{"", "application/json", `{}`, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"", "text/csv", "name,price,stock,colour\n", http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"", "text/csv", "name,price\n", http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"", "text/csv", "name,price,stock,categories\n", http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"?mode=some", "text/csv", "name,price,stock,categories\nA b,1,1,1\n", http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"?dry_run=maybe", "text/csv", "", http.StatusBadRequest, "INVALID_DRY_RUN"},
+	}
+	for _, tc := range cases {
+		resp := importFile(h, admin, tc.query, tc.contentType, tc.body).Expect(t, tc.status)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%s %q: expected %s, got %s (%s)", tc.query, tc.body, tc.code, apiErr.Code, apiErr.Message)
+		}
+	}
+
+	importFile(h, h.ClientToken(), "", "text/csv", "name,price,stock,categories\n").Expect(t, http.StatusForbidden)
+}
+
+func TestImportCategoryNameBeforeID(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	// A category whose name reads as the ID of another one
+	var other, numeric models.Category
+	for other.ID < 10 {
+		h.Do(http.MethodPost, "/api/categories", admin, map[string]string{"name": fmt.Sprintf("Filler %d", other.ID)}).
+			Expect(t, http.StatusCreated).Data(t, &other)
+	}
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]string{"name": strconv.Itoa(other.ID)}).
+		Expect(t, http.StatusCreated).Data(t, &numeric)
+
+	csv := "name,price,stock,categories\n" +
+		fmt.Sprintf("By name,1,1,%d\n", other.ID) +
+		fmt.Sprintf("By ID,1,1,%d\n", numeric.ID)
+
+	var report models.ProductImportReport
+	importFile(h, admin, "", "text/csv", csv).Expect(t, http.StatusOK).Data(t, &report)
+	if report.Created != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	want := []int{numeric.ID, numeric.ID}
+	for i, row := range report.Rows {
+		var product models.Product
+		h.Get(fmt.Sprintf("/api/products/%d", row.ProductID), admin).Expect(t, http.StatusOK).Data(t, &product)
+		if len(product.Categories) != 1 || product.Categories[0].ID != want[i] {
+			t.Fatalf("line %d: expected category %d, got %+v", row.Line, want[i], product.Categories)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestMain(m *testing.M) {
+	testharness.Main(m)
+}
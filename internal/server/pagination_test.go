@@ -0,0 +1,245 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func productIDs(products []models.Product) []int {
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// walkProducts follows next_cursor (or prev_cursor when backward) from the
+// given cursor until the listing ends, returning the ids in listing order
+func walkProducts(t *testing.T, h *testharness.Harness, token, query, cursor string, backward bool) []int {
+	t.Helper()
+	var ids []int
+	for pages := 0; ; pages++ {
+		if pages > 50 {
+			t.Fatalf("cursor walk did not end")
+		}
+		var page models.ProductCursorResponse
+		h.Get("/api/products?"+query+"&cursor="+url.QueryEscape(cursor), token).Expect(t, http.StatusOK).Data(t, &page)
+		if backward {
+			ids = append(productIDs(page.Products), ids...)
+			if page.PrevCursor == nil {
+				return ids
+			}
+			cursor = *page.PrevCursor
+		} else {
+			ids = append(ids, productIDs(page.Products)...)
+			if page.NextCursor == nil {
+				return ids
+			}
+			cursor = *page.NextCursor
+		}
+	}
+}
+
+func TestProductCursorPagination(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	for _, sortBy := range []string{"name", "price", "stock", "created_at"} {
+		for _, order := range []string{"asc", "desc"} {
+			query := fmt.Sprintf("sort_by=%s&sort_order=%s", sortBy, order)
+			t.Run(sortBy+"_"+order, func(t *testing.T) {
+				var all models.ProductListResponse
+				h.Get("/api/products?limit=100&"+query, client).Expect(t, http.StatusOK).Data(t, &all)
+				want := productIDs(all.Products)
+
+				forward := walkProducts(t, h, client, "limit=3&"+query, "", false)
+				if !slices.Equal(forward, want) {
+					t.Fatalf("forward walk %v, want %v", forward, want)
+				}
+
+				// Walking back from the last page yields the same listing
+				var first models.ProductCursorResponse
+				h.Get("/api/products?limit=3&cursor=&"+query, client).Data(t, &first)
+				if first.PrevCursor != nil {
+					t.Fatalf("the first page should have no prev_cursor")
+				}
+				last := walkLastCursor(t, h, client, query)
+				backward := walkProducts(t, h, client, "limit=3&"+query, last, true)
+				if !slices.Equal(backward, want[:len(want)-1]) {
+					t.Fatalf("backward walk %v, want %v", backward, want[:len(want)-1])
+				}
+			})
+		}
+	}
+}
+
+// walkLastCursor returns a prev_cursor pointing before the last product,
+// taken from a page holding only that product
+func walkLastCursor(t *testing.T, h *testharness.Harness, token, query string) string {
+	t.Helper()
+	cursor := ""
+	for {
+		var page models.ProductCursorResponse
+		h.Get("/api/products?limit=1&"+query+"&cursor="+url.QueryEscape(cursor), token).Data(t, &page)
+		if page.NextCursor == nil {
+			return *page.PrevCursor
+		}
+		cursor = *page.NextCursor
+	}
+}
+
+func TestProductCursorStableUnderInserts(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var all models.ProductListResponse
+	h.Get("/api/products?limit=100&sort_by=price&sort_order=asc", admin).Data(t, &all)
+
+	var page models.ProductCursorResponse
+	h.Get("/api/products?limit=5&sort_by=price&sort_order=asc&cursor=", admin).Expect(t, http.StatusOK).Data(t, &page)
+	seen := productIDs(page.Products)
+
+	// A cheaper product lands before the cursor and a pricier one after it
+	var inserted []int
+	for _, price := range []float64{0.01, 999999} {
+		var created models.Product
+		h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+			"name": fmt.Sprintf("Inserted %v", price), "price": price, "stock": 1, "category_ids": []int{1},
+		}).Expect(t, http.StatusCreated).Data(t, &created)
+		inserted = append(inserted, created.ID)
+	}
+
+	rest := walkProducts(t, h, admin, "limit=5&sort_by=price&sort_order=asc", *page.NextCursor, false)
+	seen = append(seen, rest...)
+
+	want := append(productIDs(all.Products), inserted[1])
+	if !slices.Equal(seen, want) {
+		t.Fatalf("walk across inserts returned %v, want %v", seen, want)
+	}
+}
+
+func TestCursorErrorsAndTotal(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	var page models.ProductCursorResponse
+	h.Get("/api/products?limit=5&sort_by=price&cursor=", client).Expect(t, http.StatusOK).Data(t, &page)
+	if page.Total != nil || page.Limit != 5 || len(page.Products) != 5 {
+		t.Fatalf("unexpected first page: %+v", page.CursorPage)
+	}
+
+	// A cursor only works with the sort it was issued for
+	resp := h.Get("/api/products?sort_by=name&cursor="+url.QueryEscape(*page.NextCursor), client).Expect(t, http.StatusBadRequest)
+	if code := resp.Error(t).Code; code != "INVALID_CURSOR" {
+		t.Fatalf("unexpected error code %q", code)
+	}
+	h.Get("/api/products?cursor=not-a-cursor", client).Expect(t, http.StatusBadRequest)
+	h.Get("/api/categories?cursor=not-a-cursor", client).Expect(t, http.StatusBadRequest)
+
+	h.Get("/api/products?limit=5&sort_by=price&include_total=true&cursor="+url.QueryEscape(*page.NextCursor), client).
+		Expect(t, http.StatusOK).Data(t, &page)
+	if page.Total == nil || *page.Total != 20 {
+		t.Fatalf("expected total 20, got %v", page.Total)
+	}
+}
+
+func TestCategoryAndHistoryCursor(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var all models.CategoryListResponse
+	h.Get("/api/categories", admin).Expect(t, http.StatusOK).Data(t, &all)
+
+	var names []string
+	cursor := ""
+	for {
+		var page models.CategoryCursorResponse
+		h.Get("/api/categories?limit=3&cursor="+url.QueryEscape(cursor), admin).Expect(t, http.StatusOK).Data(t, &page)
+		for _, c := range page.Categories {
+			names = append(names, c.Name)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+	if len(names) != len(all.Categories) || !slices.IsSorted(names) {
+		t.Fatalf("unexpected category walk %v", names)
+	}
+
+	for stock := 1; stock <= 4; stock++ {
+		h.Do(http.MethodPut, "/api/products/1", admin, map[string]any{"stock": stock}).Expect(t, http.StatusOK)
+	}
+	var full models.ProductHistoryResponse
+	h.Get("/api/products/1/history", admin).Expect(t, http.StatusOK).Data(t, &full)
+
+	var stocks []int
+	cursor = ""
+	for {
+		var page models.ProductHistoryCursorResponse
+		h.Get("/api/products/1/history?limit=2&include_total=true&cursor="+url.QueryEscape(cursor), admin).
+			Expect(t, http.StatusOK).Data(t, &page)
+		if page.Total == nil || *page.Total != full.Total {
+			t.Fatalf("expected total %d, got %v", full.Total, page.Total)
+		}
+		for _, entry := range page.History {
+			stocks = append(stocks, entry.Stock)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+	want := make([]int, len(full.History))
+	for i, entry := range full.History {
+		want[i] = entry.Stock
+	}
+	if len(want) < 4 || !slices.Equal(stocks, want) {
+		t.Fatalf("history walk %v, want %v", stocks, want)
+	}
+}
+
+func TestMultiColumnSort(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	var all models.ProductListResponse
+	h.Get("/api/products?limit=100&sort=price:desc,name", client).Expect(t, http.StatusOK).Data(t, &all)
+	for i := 1; i < len(all.Products); i++ {
+		a, b := all.Products[i-1], all.Products[i]
+		if c := a.Price.Cmp(b.Price); c < 0 || (c == 0 && a.Name > b.Name) {
+			t.Fatalf("%q (%s) listed before %q (%s)", a.Name, a.Price, b.Name, b.Price)
+		}
+	}
+
+	// Ties on price keep their order across offset and cursor pages
+	var offset []int
+	for page := 1; page <= 7; page++ {
+		var result models.ProductListResponse
+		h.Get(fmt.Sprintf("/api/products?limit=3&page=%d&sort=price:desc,name:asc", page), client).Data(t, &result)
+		offset = append(offset, productIDs(result.Products)...)
+	}
+	keyset := walkProducts(t, h, client, "limit=3&sort=price:desc,name:asc", "", false)
+	if want := productIDs(all.Products); !slices.Equal(offset, want) || !slices.Equal(keyset, want) {
+		t.Fatalf("pages differ from the full listing:\noffset %v\nkeyset %v\nwant   %v", offset, keyset, want)
+	}
+
+	var categories models.CategoryListResponse
+	h.Get("/api/search?type=category&sort=created_at:desc,name", client).Expect(t, http.StatusOK).Data(t, &categories)
+	if categories.Total != 8 {
+		t.Fatalf("unexpected category search: %+v", categories)
+	}
+
+	for _, path := range []string{"/api/products?sort=cost", "/api/products?sort=price:up", "/api/search?sort=price,name,price", "/api/search?type=category&sort=price"} {
+		resp := h.Get(path, client).Expect(t, http.StatusBadRequest)
+		if code := resp.Error(t).Code; code != "INVALID_SORT" {
+			t.Fatalf("%s: unexpected error code %q", path, code)
+		}
+	}
+}
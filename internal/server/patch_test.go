@@ -0,0 +1,151 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func patchHeaders(contentType string, extra http.Header) http.Header {
+	header := http.Header{"Content-Type": {contentType}}
+	for key, values := range extra {
+		header[key] = values
+	}
+	return header
+}
+
+func TestProductMergePatch(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	merge := patchHeaders("application/merge-patch+json", nil)
+
+	var before models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &before)
+	if before.Description == nil {
+		t.Fatal("expected the seeded product to have a description")
+	}
+
+	// null clears the description, which PUT cannot do
+	var patched models.Product
+	resp := h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"description": null, "stock": 7}`, merge).
+		Expect(t, http.StatusOK)
+	resp.Data(t, &patched)
+	if patched.Description != nil || patched.Stock != 7 || patched.Version != before.Version+1 || len(patched.Categories) != len(before.Categories) {
+		t.Fatalf("unexpected product after merge patch: %+v", patched)
+	}
+	if etag := resp.Header.Get("ETag"); etag != models.ETag(patched.Version) {
+		t.Fatalf("expected ETag %s, got %q", models.ETag(patched.Version), etag)
+	}
+
+	// A patch that changes nothing keeps the version
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"stock": 7}`, merge).Expect(t, http.StatusOK).Data(t, &patched)
+	if patched.Version != before.Version+1 {
+		t.Fatalf("expected version %d to be kept, got %d", before.Version+1, patched.Version)
+	}
+
+	// The result must still be a valid product, and read-only fields stay out
+	for _, tc := range []struct{ body, code string }{
+		{`{"price": null}`, "INVALID_INPUT"},
+		{`{"name": ""}`, "INVALID_INPUT"},
+		{`{"category_ids": []}`, "INVALID_INPUT"},
+		{`{"id": 5}`, "VALIDATION_ERROR"},
+		{`{"version": 9}`, "VALIDATION_ERROR"},
+	} {
+		resp := h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, tc.body, merge).Expect(t, http.StatusBadRequest)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%s: expected %s, got %s", tc.body, tc.code, apiErr.Code)
+		}
+	}
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"stock":`, merge).Expect(t, http.StatusBadRequest)
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"category_ids": [404]}`, merge).Expect(t, http.StatusUnprocessableEntity)
+
+	var after models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &after)
+	if after.Version != patched.Version || after.Price != before.Price {
+		t.Fatalf("a rejected patch must not change the product: %+v", after)
+	}
+
+	// Conditional and content negotiation
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"stock": 1}`,
+		patchHeaders("application/merge-patch+json", ifMatch(models.ETag(before.Version)))).Expect(t, http.StatusPreconditionFailed)
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, `{"stock": 1}`, patchHeaders("application/json", nil)).
+		Expect(t, http.StatusUnsupportedMediaType)
+	h.DoWithHeaders(http.MethodPatch, "/api/products/99999", admin, `{"stock": 1}`, merge).Expect(t, http.StatusNotFound)
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", h.ClientToken(), `{"stock": 1}`, merge).Expect(t, http.StatusForbidden)
+}
+
+func TestProductJSONPatch(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	jsonPatch := patchHeaders("application/json-patch+json", nil)
+
+	var before models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &before)
+
+	// Add a single category without resending the others
+	var patched models.Product
+	body := fmt.Sprintf(`[
+		{"op": "test", "path": "/stock", "value": %d},
+		{"op": "add", "path": "/category_ids/-", "value": 8},
+		{"op": "replace", "path": "/name", "value": "Patched Product"}
+	]`, before.Stock)
+	h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, body, jsonPatch).Expect(t, http.StatusOK).Data(t, &patched)
+	ids := []int{}
+	for _, category := range patched.Categories {
+		ids = append(ids, category.ID)
+	}
+	if patched.Name != "Patched Product" || len(ids) != len(before.Categories)+1 || !slices.Contains(ids, 8) {
+		t.Fatalf("unexpected product after JSON Patch: %+v", patched)
+	}
+
+	// Every operation applies or none does
+	for _, tc := range []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`[{"op": "replace", "path": "/name", "value": "Changed"}, {"op": "test", "path": "/stock", "value": -1}]`, http.StatusConflict, "PATCH_TEST_FAILED"},
+		{`[{"op": "replace", "path": "/name", "value": "Changed"}, {"op": "remove", "path": "/category_ids/9"}]`, http.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE"},
+		{`[{"op": "replace", "path": "/colour", "value": "red"}]`, http.StatusUnprocessableEntity, "PATCH_NOT_APPLICABLE"},
+		{`[{"op": "rename", "path": "/name"}]`, http.StatusBadRequest, "INVALID_PATCH"},
+		{`{"name": "Changed"}`, http.StatusBadRequest, "INVALID_PATCH"},
+		{`[{"op": "remove", "path": "/name"}]`, http.StatusBadRequest, "INVALID_INPUT"},
+	} {
+		resp := h.DoWithHeaders(http.MethodPatch, "/api/products/1", admin, tc.body, jsonPatch).Expect(t, tc.status)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%s: expected %s, got %s (%s)", tc.body, tc.code, apiErr.Code, apiErr.Message)
+		}
+	}
+
+	var after models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &after)
+	if after.Name != "Patched Product" || after.Version != patched.Version {
+		t.Fatalf("a failed patch must not change the product: %+v", after)
+	}
+}
+
+func TestCategoryPatch(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var category models.Category
+	h.DoWithHeaders(http.MethodPatch, "/api/categories/3", admin, `{"description": null}`,
+		patchHeaders("application/merge-patch+json", nil)).Expect(t, http.StatusOK).Data(t, &category)
+	if category.Description != nil {
+		t.Fatalf("expected the description to be cleared, got %q", *category.Description)
+	}
+
+	h.DoWithHeaders(http.MethodPatch, "/api/categories/3", admin, `[{"op": "replace", "path": "/name", "value": "Libros"}]`,
+		patchHeaders("application/json-patch+json", ifMatch(models.ETag(category.Version)))).Expect(t, http.StatusOK).Data(t, &category)
+	if category.Name != "Libros" {
+		t.Fatalf("expected the category to be renamed, got %q", category.Name)
+	}
+
+	// Names stay unique
+	h.DoWithHeaders(http.MethodPatch, "/api/categories/3", admin, `{"name": "Electronics"}`,
+		patchHeaders("application/merge-patch+json", nil)).Expect(t, http.StatusConflict)
+}
@@ -0,0 +1,129 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestProblemNegotiation(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	tests := []struct {
+		accept  string
+		problem bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/problem+json;q=0.5", true},
+		{"application/problem+json;q=0, */*", false},
+		{"application/problem+json;q=0", false},
+		{"application/json, application/problem+json", true},
+		{"application/json, application/problem+json;q=0.9", false},
+		{"application/json;q=0.5, application/problem+json;q=0.8", true},
+		{"application/problem+json; charset=utf-8", true},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.accept != "" {
+			header.Set("Accept", tt.accept)
+		}
+		resp := h.DoWithHeaders(http.MethodGet, "/api/products/99999", client, nil, header).Expect(t, http.StatusNotFound)
+
+		contentType := resp.Header.Get("Content-Type")
+		if got := strings.HasPrefix(contentType, models.ProblemContentType); got != tt.problem {
+			t.Errorf("Accept %q: expected problem=%v, got Content-Type %q", tt.accept, tt.problem, contentType)
+		}
+		if !tt.problem && resp.Error(t).Code != "NOT_FOUND" {
+			t.Errorf("Accept %q: expected the JSON envelope, got %s", tt.accept, resp.Body)
+		}
+	}
+}
+
+func TestProblemDocument(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+	accept := http.Header{"Accept": {models.ProblemContentType}}
+
+	resp := h.DoWithHeaders(http.MethodGet, "/api/products/99999?fields=id", client, nil, accept).
+		Expect(t, http.StatusNotFound)
+	if got := resp.Header.Get("Content-Type"); got != models.ProblemContentType {
+		t.Fatalf("expected Content-Type %s, got %q", models.ProblemContentType, got)
+	}
+
+	var problem map[string]any
+	if err := json.Unmarshal(resp.Body, &problem); err != nil {
+		t.Fatalf("invalid problem document %s: %v", resp.Body, err)
+	}
+	want := map[string]any{
+		"type":     models.ProblemTypePrefix + "not-found",
+		"title":    "Not Found",
+		"status":   float64(http.StatusNotFound),
+		"detail":   "Product not found",
+		"instance": "/api/products/99999?fields=id",
+		"code":     "NOT_FOUND",
+	}
+	for key, value := range want {
+		if problem[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, problem[key])
+		}
+	}
+	if _, ok := problem["success"]; ok {
+		t.Errorf("the problem document must not carry the envelope: %s", resp.Body)
+	}
+
+	// Validation failures list the fields
+	resp = h.DoWithHeaders(http.MethodPost, "/api/products", h.AdminToken(), map[string]any{"name": ""}, accept).
+		Expect(t, http.StatusBadRequest)
+	var invalid models.Problem
+	if err := json.Unmarshal(resp.Body, &invalid); err != nil {
+		t.Fatal(err)
+	}
+	if invalid.Code != "INVALID_INPUT" || len(invalid.Errors) == 0 {
+		t.Fatalf("expected field errors, got %s", resp.Body)
+	}
+}
+
+func TestProblemPreconditionFailed(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, `{"name":"Problem","price":1,"stock":1,"category_ids":[1]}`).
+		Expect(t, http.StatusCreated).Data(t, &created)
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 2}).Expect(t, http.StatusOK)
+
+	header := ifMatch(models.ETag(created.Version))
+	header.Set("Accept", models.ProblemContentType)
+	resp := h.DoWithHeaders(http.MethodPut, path, admin, map[string]any{"stock": 3}, header).
+		Expect(t, http.StatusPreconditionFailed)
+	if got := resp.Header.Get("Content-Type"); got != models.ProblemContentType {
+		t.Fatalf("expected Content-Type %s, got %q", models.ProblemContentType, got)
+	}
+
+	var problem struct {
+		models.Problem
+		Current models.Product `json:"current"`
+	}
+	if err := json.Unmarshal(resp.Body, &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusPreconditionFailed || problem.Code != "PRECONDITION_FAILED" {
+		t.Fatalf("unexpected problem: %s", resp.Body)
+	}
+	if problem.Current.ID != created.ID || problem.Current.Stock != 2 || problem.Current.Version != created.Version+1 {
+		t.Fatalf("expected the current representation, got %s", resp.Body)
+	}
+	if etag := resp.Header.Get("ETag"); etag != models.ETag(problem.Current.Version) {
+		t.Fatalf("expected the current ETag, got %q", etag)
+	}
+}
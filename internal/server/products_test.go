@@ -0,0 +1,236 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestProductLifecycle(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name":         "Test Widget",
+		"description":  "Created by the integration tests",
+		"price":        10.5,
+		"stock":        7,
+		"category_ids": []int{1, 2},
+	}).Expect(t, http.StatusCreated).Data(t, &created)
+
+	if created.ID == 0 || created.Name != "Test Widget" || len(created.Categories) != 2 {
+		t.Fatalf("unexpected created product: %+v", created)
+	}
+
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+
+	var fetched models.Product
+	h.Get(path, admin).Expect(t, http.StatusOK).Data(t, &fetched)
+	if fetched.Price != money.MustParse("10.5") || fetched.Stock != 7 {
+		t.Fatalf("unexpected fetched product: %+v", fetched)
+	}
+
+	var updated models.Product
+	h.Do(http.MethodPut, path, admin, map[string]any{"price": 12.25, "stock": 3}).
+		Expect(t, http.StatusOK).Data(t, &updated)
+	if updated.Price != money.MustParse("12.25") || updated.Stock != 3 || updated.Name != "Test Widget" {
+		t.Fatalf("unexpected updated product: %+v", updated)
+	}
+
+	// Updating price or stock is recorded by the history trigger
+	var history models.ProductHistoryResponse
+	h.Get(path+"/history", admin).Expect(t, http.StatusOK).Data(t, &history)
+	if history.Total != 1 || history.History[0].Price != money.MustParse("12.25") || history.History[0].Stock != 3 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	// Changing only the name does not add a history entry
+	h.Do(http.MethodPut, path, admin, map[string]any{"name": "Renamed Widget"}).Expect(t, http.StatusOK)
+	h.Get(path+"/history", admin).Expect(t, http.StatusOK).Data(t, &history)
+	if history.Total != 1 {
+		t.Fatalf("expected 1 history entry after rename, got %d", history.Total)
+	}
+
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusOK)
+	if code := h.Get(path, admin).Expect(t, http.StatusNotFound).Error(t).Code; code != "NOT_FOUND" {
+		t.Fatalf("expected NOT_FOUND, got %s", code)
+	}
+}
+
+func TestProductPriceIsExact(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	// Strings and numbers are both accepted; the response keeps two decimals
+	resp := h.Do(http.MethodPost, "/api/products", admin, `{"name":"Exact","price":"0.10","stock":1,"category_ids":[1]}`).
+		Expect(t, http.StatusCreated)
+	if !strings.Contains(string(resp.Body), `"price":0.10`) {
+		t.Fatalf("expected fixed precision price, got %s", resp.Body)
+	}
+
+	var created models.Product
+	resp.Data(t, &created)
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+
+	// 0.10 + 0.20 drifts as float64; stored amounts must not
+	h.Do(http.MethodPut, path, admin, `{"price":0.30}`).Expect(t, http.StatusOK).Data(t, &created)
+	if sum := created.Price.Cmp(money.MustParse("0.1").Add(money.MustParse("0.2"))); sum != 0 {
+		t.Fatalf("expected exactly 0.30, got %s", created.Price)
+	}
+}
+
+func TestProductPriceStringFormat(t *testing.T) {
+	// Opt-in string encoding for clients that parse prices as decimals
+	h := testharness.New(t, func(cfg *config.Config) {
+		cfg.Pricing.JSONFormat = money.FormatString
+	})
+	admin := h.AdminToken()
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, `{"name":"Exact","price":0.30,"stock":1,"category_ids":[1]}`).
+		Expect(t, http.StatusCreated).Data(t, &created)
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+	if body := h.Get(path, admin).Expect(t, http.StatusOK).Body; !strings.Contains(string(body), `"price":"0.30"`) {
+		t.Fatalf("expected string price, got %s", body)
+	}
+
+	// Sparse fieldsets encode the trimmed objects themselves
+	if body := h.Get("/api/products?fields=id,price&sort_by=price&sort_order=asc", admin).Expect(t, http.StatusOK).Body; !strings.Contains(string(body), `"price":"`) {
+		t.Fatalf("expected string prices in a sparse list, got %s", body)
+	}
+
+	ws := h.DialWS()
+	h.Do(http.MethodPut, path, admin, map[string]any{"price": "1.50"}).Expect(t, http.StatusOK)
+	if data := ws.Expect(service.EventProductUpdated, 2*time.Second).Data; !strings.Contains(string(data), `"price":"1.50"`) {
+		t.Fatalf("expected a string price in the event, got %s", data)
+	}
+
+	// Every response holding amounts follows the format, down to conversions
+	for _, tt := range []struct{ path, want string }{
+		{"/api/products?limit=3&currency=USD", `"original_price":"`},
+		{path + "/history", `"price":"1.50"`},
+		{"/api/exchange-rates", `"rate":"`},
+		{path + "/history/export?format=ndjson", `"price":"1.50"`},
+	} {
+		if body := h.Get(tt.path, admin).Expect(t, http.StatusOK).Body; !strings.Contains(string(body), tt.want) {
+			t.Fatalf("expected %s in GET %s, got %s", tt.want, tt.path, body)
+		}
+	}
+}
+
+func TestProductListPagination(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	var page models.ProductListResponse
+	h.Get("/api/products?page=2&limit=5&sort_by=price&sort_order=asc", client).
+		Expect(t, http.StatusOK).Data(t, &page)
+
+	if page.Total != 20 || page.Page != 2 || page.Limit != 5 || page.TotalPages != 4 || len(page.Products) != 5 {
+		t.Fatalf("unexpected page: total=%d page=%d limit=%d pages=%d len=%d",
+			page.Total, page.Page, page.Limit, page.TotalPages, len(page.Products))
+	}
+	for i := 1; i < len(page.Products); i++ {
+		if page.Products[i-1].Price.Cmp(page.Products[i].Price) > 0 {
+			t.Fatalf("products are not sorted by price: %v then %v", page.Products[i-1].Price, page.Products[i].Price)
+		}
+	}
+
+	var filtered models.ProductListResponse
+	h.Get("/api/search?category_id=3", client).Expect(t, http.StatusOK).Data(t, &filtered)
+	if filtered.Total != 2 {
+		t.Fatalf("expected 2 products in Books, got %d", filtered.Total)
+	}
+}
+
+func TestProductBatchGet(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	var batch models.ProductBatchResponse
+	h.Get("/api/products?ids=7,99999,3,7&ids=5", client).Expect(t, http.StatusOK).Data(t, &batch)
+	if len(batch.Products) != 3 || len(batch.NotFound) != 1 || batch.NotFound[0] != 99999 {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	for i, id := range []int{7, 3, 5} {
+		var product models.Product
+		h.Get(fmt.Sprintf("/api/products/%d", id), client).Expect(t, http.StatusOK).Data(t, &product)
+		got := batch.Products[i]
+		if got.ID != id || got.Price != product.Price || len(got.Categories) == 0 || len(got.Categories) != len(product.Categories) {
+			t.Fatalf("expected product %d with its categories at %d, got %+v", id, i, got)
+		}
+	}
+
+	// Deleted products are missing too
+	h.Do(http.MethodDelete, "/api/products/3", h.AdminToken(), nil).Expect(t, http.StatusOK)
+	h.Get("/api/products?ids=3", client).Expect(t, http.StatusOK).Data(t, &batch)
+	if len(batch.Products) != 0 || len(batch.NotFound) != 1 {
+		t.Fatalf("expected product 3 to be missing, got %+v", batch)
+	}
+
+	h.Get("/api/products?ids=1,abc", client).Expect(t, http.StatusBadRequest)
+	ids := make([]string, 101)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i + 1)
+	}
+	h.Get("/api/products?ids="+strings.Join(ids, ","), client).Expect(t, http.StatusBadRequest)
+}
+
+func TestProductAuthorization(t *testing.T) {
+	h := testharness.New(t)
+	body := map[string]any{"name": "Forbidden", "price": 1, "stock": 1, "category_ids": []int{1}}
+
+	h.Get("/api/products", "").Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodPost, "/api/products", h.ClientToken(), body).Expect(t, http.StatusForbidden)
+}
+
+func TestProductValidation(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	apiErr := h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name":         "X",
+		"price":        1.999,
+		"stock":        1,
+		"category_ids": []int{1, 1},
+	}).Expect(t, http.StatusBadRequest).Error(t)
+
+	rules := map[string]string{}
+	for _, f := range apiErr.Fields {
+		rules[f.Field] = f.Rule
+	}
+	want := map[string]string{"name": "product_name", "price": "price_precision", "category_ids": "unique_ids"}
+	for field, rule := range want {
+		if rules[field] != rule {
+			t.Errorf("field %s: expected rule %q, got %q (%+v)", field, rule, rules[field], apiErr.Fields)
+		}
+	}
+
+	// Prices are plain decimals: exponents and NaN are rejected, as numbers or strings
+	for _, price := range []any{json.Number("1.5e2"), "1.5e2", "NaN", "Infinity"} {
+		h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+			"name":         "Scientific",
+			"price":        price,
+			"stock":        1,
+			"category_ids": []int{1},
+		}).Expect(t, http.StatusBadRequest)
+	}
+
+	// Unknown categories are rejected by the foreign key
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name":         "Orphan",
+		"price":        1,
+		"stock":        1,
+		"category_ids": []int{9999},
+	}).Expect(t, http.StatusUnprocessableEntity)
+}
@@ -3,14 +3,18 @@ package server
 import (
 	"log"
 	"net/http"
+	"sync"
 
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
-	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/handlers"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/middleware"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
 	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/websocket"
 
 	// Swagger imports
@@ -19,23 +23,37 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
+// registerBindingValidators guards gin's validator, which every router shares
+var registerBindingValidators sync.Once
+
+func SetupRouter(cfg *config.Config, services *service.Services, jwtService *auth.JWTService, hub *websockets.Hub) *gin.Engine {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  cfg.WebSocket.ReadBufferSize,
+		WriteBufferSize: cfg.WebSocket.WriteBufferSize,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	// Use JSON field names and business rules in binding errors
+	registerBindingValidators.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			models.RegisterValidators(v)
+		}
+	})
 
-func SetupRouter(database *db.DB, jwtService *auth.JWTService, hub *websockets.Hub) *gin.Engine {
 	// Create router
 	r := gin.Default()
 
 	// global middleware
 	r.Use(middleware.Logger())
 	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.PriceFormat(cfg.Pricing.JSONFormat))
 
-	h := handlers.NewHandler(database, jwtService, hub)
+	h := handlers.NewHandler(services, cfg.Pagination, cfg.HTTPCache)
+
+	// If-Match is always honored on updates and deletes; this makes it mandatory
+	requireIfMatch := middleware.RequireIfMatch(cfg.Concurrency.RequireIfMatch)
 
 	r.GET("/health", func(c *gin.Context) {
 		models.RespondSuccess(c, http.StatusOK, gin.H{"status": "ok"})
@@ -60,12 +78,18 @@ func SetupRouter(database *db.DB, jwtService *auth.JWTService, hub *websockets.H
 		{
 
 			protected.GET("/products", h.ListProducts)
+			protected.GET("/products/export", h.ExportProducts)
 			protected.GET("/products/:id", h.GetProduct)
 			protected.GET("/products/:id/history", h.GetProductHistory)
+			protected.GET("/products/:id/history/export", h.ExportProductHistory)
+			protected.GET("/products/:id/stock-movements", h.ListStockMovements)
 
 			protected.GET("/categories", h.ListCategories)
+			protected.GET("/categories/export", h.ExportCategories)
 			protected.GET("/categories/:id", h.GetCategory)
 
+			protected.GET("/exchange-rates", h.ListExchangeRates)
+
 			protected.GET("/search", h.Search)
 
 			// Admin-only routes
@@ -73,12 +97,29 @@ func SetupRouter(database *db.DB, jwtService *auth.JWTService, hub *websockets.H
 			admin.Use(middleware.RequireRole("admin"))
 			{
 				admin.POST("/products", h.CreateProduct)
-				admin.PUT("/products/:id", h.UpdateProduct)
-				admin.DELETE("/products/:id", h.DeleteProduct)
+				admin.POST("/products/import", h.ImportProducts)
+				admin.PATCH("/products/bulk", h.BulkUpdateProducts)
+				admin.DELETE("/products/bulk", h.BulkDeleteProducts)
+				admin.PUT("/products/:id", requireIfMatch, h.UpdateProduct)
+				admin.PATCH("/products/:id", requireIfMatch, h.PatchProduct)
+				admin.DELETE("/products/:id", requireIfMatch, h.DeleteProduct)
+				admin.POST("/products/:id/stock-movements", h.CreateStockMovement)
 
 				admin.POST("/categories", h.CreateCategory)
-				admin.PUT("/categories/:id", h.UpdateCategory)
-				admin.DELETE("/categories/:id", h.DeleteCategory)
+				admin.PUT("/categories/:id", requireIfMatch, h.UpdateCategory)
+				admin.PATCH("/categories/:id", requireIfMatch, h.PatchCategory)
+				admin.DELETE("/categories/:id", requireIfMatch, h.DeleteCategory)
+
+				admin.POST("/exchange-rates", h.CreateExchangeRate)
+				admin.DELETE("/exchange-rates/:id", h.DeleteExchangeRate)
+
+				admin.GET("/trash/products", h.ListTrashedProducts)
+				admin.GET("/trash/categories", h.ListTrashedCategories)
+				admin.POST("/trash/products/:id/restore", h.RestoreProduct)
+				admin.POST("/trash/categories/:id/restore", h.RestoreCategory)
+				admin.POST("/trash/purge", h.PurgeTrash)
+
+				admin.GET("/cache/stats", h.GetCacheStats)
 			}
 		}
 	}
@@ -0,0 +1,188 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestSearchProducts(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	var result models.ProductListResponse
+	h.Get("/api/search?q=mouse", client).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Total != 1 || result.Products[0].Name != "Wireless Mouse" {
+		t.Fatalf("unexpected search result: %+v", result)
+	}
+
+	// Full-text search combined with the category filter
+	h.Get("/api/search?q=led&category_id=4", client).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Total != 1 || result.Products[0].Name != "LED Desk Lamp" {
+		t.Fatalf("unexpected filtered search result: %+v", result)
+	}
+
+	h.Get("/api/search?q=led&category_id=6", client).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Total != 0 {
+		t.Fatalf("expected no results, got %+v", result)
+	}
+}
+
+func TestSearchProductFilters(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	names := func(result models.ProductListResponse) []string {
+		names := make([]string, len(result.Products))
+		for i, p := range result.Products {
+			names[i] = p.Name
+		}
+		return names
+	}
+	search := func(query string) models.ProductListResponse {
+		t.Helper()
+		var result models.ProductListResponse
+		h.Get("/api/search?limit=100&"+query, admin).Expect(t, http.StatusOK).Data(t, &result)
+		return result
+	}
+
+	// Clothing (2) and Sports (5)
+	if got := search("category_ids=2,5"); got.Total != 5 {
+		t.Fatalf("expected 5 products in any category, got %v", names(got))
+	}
+	if got := search("category_ids=2&category_ids=5&category_match=all"); got.Total != 1 || got.Products[0].Name != "Running Shoes" {
+		t.Fatalf("expected only Running Shoes in both categories, got %v", names(got))
+	}
+	if got := search("category_id=1&category_ids=4&category_match=all"); got.Total != 1 || got.Products[0].Name != "LED Desk Lamp" {
+		t.Fatalf("expected only LED Desk Lamp, got %v", names(got))
+	}
+
+	// Ranges are inclusive and combine with the sort
+	got := search("category_ids=1&min_price=34.99&max_price=50&sort_by=price&sort_order=desc")
+	if fmt.Sprint(names(got)) != "[Bluetooth Speaker LED Desk Lamp]" {
+		t.Fatalf("unexpected price range result %v", names(got))
+	}
+	if got := search("min_stock=100&max_stock=150"); got.Total != 2 {
+		t.Fatalf("expected 2 products with 100..150 in stock, got %v", names(got))
+	}
+
+	var updated models.Product
+	h.Do(http.MethodPut, "/api/products/12", admin, map[string]any{"stock": 0}).Expect(t, http.StatusOK).Data(t, &updated)
+	if got := search("in_stock=false"); got.Total != 1 || got.Products[0].ID != 12 {
+		t.Fatalf("expected only product 12 out of stock, got %v", names(got))
+	}
+	if got := search("in_stock=true"); got.Total != 19 {
+		t.Fatalf("expected 19 products in stock, got %d", got.Total)
+	}
+
+	since := url.QueryEscape(updated.UpdatedAt.Format(time.RFC3339Nano))
+	if got := search("updated_after=" + since); got.Total != 1 || got.Products[0].ID != 12 {
+		t.Fatalf("expected only product 12 updated since %s, got %v", since, names(got))
+	}
+	if got := search("created_after=2999-01-01T00:00:00Z"); got.Total != 0 {
+		t.Fatalf("expected no products created in the future, got %v", names(got))
+	}
+
+	// The same filters apply to the product listing
+	var page models.ProductListResponse
+	h.Get("/api/products?category_ids=2,5&category_match=all", admin).Expect(t, http.StatusOK).Data(t, &page)
+	if page.Total != 1 {
+		t.Fatalf("expected 1 listed product, got %+v", page)
+	}
+
+	for _, query := range []string{"min_price=abc", "min_price=50&max_price=10", "min_stock=9&max_stock=1", "category_match=some", "category_ids=1,x", "updated_after=yesterday"} {
+		h.Get("/api/search?"+query, admin).Expect(t, http.StatusBadRequest)
+	}
+}
+
+func TestSearchCategories(t *testing.T) {
+	h := testharness.New(t)
+
+	var result models.CategoryListResponse
+	h.Get("/api/search?type=category&q=%26", h.ClientToken()).Expect(t, http.StatusOK).Data(t, &result)
+
+	// Case-insensitive substring match, sorted by name
+	want := []string{"Food & Beverage", "Health & Beauty", "Home & Garden"}
+	if result.Total != len(want) {
+		t.Fatalf("unexpected category search: %+v", result)
+	}
+	for i, name := range want {
+		if result.Categories[i].Name != name {
+			t.Fatalf("expected %q at position %d, got %q", name, i, result.Categories[i].Name)
+		}
+	}
+}
+
+func TestSearchRejectsUnknownType(t *testing.T) {
+	h := testharness.New(t)
+
+	apiErr := h.Get("/api/search?type=user", h.ClientToken()).Expect(t, http.StatusBadRequest).Error(t)
+	if apiErr.Code != "INVALID_TYPE" {
+		t.Fatalf("expected INVALID_TYPE, got %s", apiErr.Code)
+	}
+}
+
+func TestSearchFilterExpression(t *testing.T) {
+	h := testharness.New(t)
+	client := h.ClientToken()
+
+	search := func(filter string) models.ProductListResponse {
+		t.Helper()
+		var result models.ProductListResponse
+		h.Get("/api/search?limit=100&sort_by=price&filter="+url.QueryEscape(filter), client).Expect(t, http.StatusOK).Data(t, &result)
+		return result
+	}
+
+	cases := []struct {
+		filter string
+		want   int
+	}{
+		{"price >= 100", 3},
+		{"price >= 50 AND stock < 30 AND category IN (1,5)", 3},
+		{"category = 2 and not category = 5", 2},
+		{"category NOT IN (1, 2, 3, 4, 5, 6, 7)", 2},
+		{"(stock > 100 OR price < 15) AND currency = 'ARS'", 4},
+		{"name CONTAINS 'set' AND price <> 24.99", 2},
+		{"name = 'Men''s T-Shirt'", 1},
+		{"id IN (1, 2, 99)", 2},
+		{`created_at > "2000-01-01T00:00:00Z"`, 20},
+		{"name CONTAINS '%'", 0},
+	}
+	for _, tc := range cases {
+		if got := search(tc.filter); got.Total != tc.want {
+			t.Errorf("filter %q: expected %d products, got %d", tc.filter, tc.want, got.Total)
+		}
+	}
+
+	// Expressions combine with the other filters on the product listing
+	var page models.ProductListResponse
+	h.Get("/api/products?in_stock=true&filter="+url.QueryEscape("price < 20"), client).Expect(t, http.StatusOK).Data(t, &page)
+	if page.Total != 4 {
+		t.Fatalf("expected 4 cheap products, got %d", page.Total)
+	}
+
+	invalid := map[string]string{
+		"price >":                     "at position 8: price expects a number",
+		"cost > 5":                    `at position 1: unknown field "cost"`,
+		"stock = 1.5":                 "stock expects an integer",
+		"name > 'a'":                  "name only supports =, !=, CONTAINS and IN",
+		"price > 1 AND":               "expected a field name, got end of filter",
+		"(price > 1":                  `expected ")"`,
+		"price > 1 stock < 2":         "expected AND, OR or end of filter",
+		"created_at > 'yesterday'":    "created_at expects a quoted RFC3339 date",
+		"name = 'open":                "unterminated string",
+		"price > 1; DROP TABLE users": `unexpected character ';'`,
+	}
+	for filter, want := range invalid {
+		resp := h.Get("/api/search?filter="+url.QueryEscape(filter), client).Expect(t, http.StatusBadRequest)
+		if apiErr := resp.Error(t); apiErr.Code != "INVALID_FILTER" || !strings.Contains(apiErr.Message, want) {
+			t.Errorf("filter %q: expected INVALID_FILTER containing %q, got %s: %s", filter, want, apiErr.Code, apiErr.Message)
+		}
+	}
+}
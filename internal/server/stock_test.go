@@ -0,0 +1,176 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestStockMovements(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var before models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &before)
+
+	// A sale takes units out and is recorded with who made it
+	ws := h.DialWS()
+	var movement models.StockMovement
+	h.Do(http.MethodPost, "/api/products/1/stock-movements", admin, map[string]any{
+		"quantity": -2, "reason": "sale", "reference": "ORDER-1042",
+	}).Expect(t, http.StatusCreated).Data(t, &movement)
+	if movement.StockAfter != before.Stock-2 || movement.Reason != models.StockReasonSale || movement.UserID == nil ||
+		movement.Reference == nil || *movement.Reference != "ORDER-1042" {
+		t.Fatalf("unexpected movement: %+v", movement)
+	}
+
+	var moved models.StockMovement
+	if err := json.Unmarshal(ws.Expect(service.EventProductStockMoved, 2*time.Second).Data, &moved); err != nil || moved.ID != movement.ID {
+		t.Fatalf("expected the movement in the event, got %+v (%v)", moved, err)
+	}
+
+	var product models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &product)
+	if product.Stock != before.Stock-2 || product.Version != before.Version+1 {
+		t.Fatalf("expected stock %d at version %d, got %+v", before.Stock-2, before.Version+1, product)
+	}
+	var history models.ProductHistoryResponse
+	h.Get("/api/products/1/history", admin).Expect(t, http.StatusOK).Data(t, &history)
+	if history.Total == 0 || history.History[0].Stock != product.Stock {
+		t.Fatalf("expected a history row for the movement, got %+v", history)
+	}
+
+	// Going below zero is rejected and changes nothing
+	resp := h.Do(http.MethodPost, "/api/products/1/stock-movements", admin, map[string]any{
+		"quantity": -(product.Stock + 1), "reason": "damage",
+	}).Expect(t, http.StatusConflict)
+	if apiErr := resp.Error(t); apiErr.Code != "CONFLICT" {
+		t.Fatalf("expected CONFLICT, got %s", apiErr.Code)
+	}
+	var after models.Product
+	h.Get("/api/products/1", admin).Expect(t, http.StatusOK).Data(t, &after)
+	if after.Stock != product.Stock || after.Version != product.Version {
+		t.Fatalf("a rejected movement must not change the product: %+v", after)
+	}
+
+	// The sign must suit the reason
+	for _, tc := range []struct {
+		body map[string]any
+		code string
+	}{
+		{map[string]any{"quantity": 3, "reason": "sale"}, "VALIDATION_ERROR"},
+		{map[string]any{"quantity": -3, "reason": "restock"}, "VALIDATION_ERROR"},
+		{map[string]any{"quantity": 0, "reason": "adjustment"}, "INVALID_INPUT"},
+		{map[string]any{"quantity": 3, "reason": "gift"}, "INVALID_INPUT"},
+	} {
+		resp := h.Do(http.MethodPost, "/api/products/1/stock-movements", admin, tc.body).Expect(t, http.StatusBadRequest)
+		if apiErr := resp.Error(t); apiErr.Code != tc.code {
+			t.Errorf("%v: expected %s, got %s", tc.body, tc.code, apiErr.Code)
+		}
+	}
+
+	h.Do(http.MethodPost, "/api/products/99999/stock-movements", admin, map[string]any{"quantity": 1, "reason": "restock"}).
+		Expect(t, http.StatusNotFound)
+	h.Do(http.MethodPost, "/api/products/1/stock-movements", h.ClientToken(), map[string]any{"quantity": 1, "reason": "restock"}).
+		Expect(t, http.StatusForbidden)
+}
+
+func TestStockMovementHistory(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	for _, body := range []map[string]any{
+		{"quantity": 10, "reason": "restock"},
+		{"quantity": -1, "reason": "sale"},
+		{"quantity": 1, "reason": "return"},
+		{"quantity": -4, "reason": "adjustment"},
+	} {
+		h.Do(http.MethodPost, "/api/products/2/stock-movements", admin, body).Expect(t, http.StatusCreated)
+	}
+
+	// Newest first, readable by any authenticated user
+	var page models.StockMovementListResponse
+	h.Get("/api/products/2/stock-movements?limit=3", h.ClientToken()).Expect(t, http.StatusOK).Data(t, &page)
+	if page.Total != 4 || page.TotalPages != 2 || len(page.Movements) != 3 || page.Movements[0].Reason != models.StockReasonAdjustment {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	h.Get("/api/products/2/stock-movements?limit=3&page=2", admin).Expect(t, http.StatusOK).Data(t, &page)
+	if len(page.Movements) != 1 || page.Movements[0].Reason != models.StockReasonRestock {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	var cursor models.StockMovementCursorResponse
+	h.Get("/api/products/2/stock-movements?cursor=&limit=2", admin).Expect(t, http.StatusOK).Data(t, &cursor)
+	if len(cursor.Movements) != 2 || cursor.NextCursor == nil || cursor.Movements[0].Reason != models.StockReasonAdjustment {
+		t.Fatalf("unexpected cursor page: %+v", cursor)
+	}
+	h.Get("/api/products/2/stock-movements?limit=2&cursor="+url.QueryEscape(*cursor.NextCursor), admin).
+		Expect(t, http.StatusOK).Data(t, &cursor)
+	if len(cursor.Movements) != 2 || cursor.Movements[1].Reason != models.StockReasonRestock {
+		t.Fatalf("unexpected second cursor page: %+v", cursor)
+	}
+
+	var sales models.StockMovementListResponse
+	h.Get("/api/products/2/stock-movements?reason=sale", admin).Expect(t, http.StatusOK).Data(t, &sales)
+	if sales.Total != 1 || sales.Movements[0].Quantity != -1 {
+		t.Fatalf("expected only the sale, got %+v", sales)
+	}
+
+	h.Get("/api/products/2/stock-movements?reason=gift", admin).Expect(t, http.StatusBadRequest)
+	h.Get("/api/products/99999/stock-movements", admin).Expect(t, http.StatusNotFound)
+}
+
+// Stock set by product writes lands in the ledger as adjustments
+func TestStockWritesAreLedgered(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var product models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"sku": "LEDGER-1", "name": "Counted", "price": 10, "stock": 5, "category_ids": []int{1},
+	}).Expect(t, http.StatusCreated).Data(t, &product)
+	path := fmt.Sprintf("/api/products/%d", product.ID)
+
+	ledger := func() []models.StockMovement {
+		t.Helper()
+		var page models.StockMovementListResponse
+		h.Get(path+"/stock-movements?limit=100", admin).Expect(t, http.StatusOK).Data(t, &page)
+		return page.Movements
+	}
+
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 8}).Expect(t, http.StatusOK)
+	h.Do(http.MethodPut, path, admin, map[string]any{"name": "Recounted", "stock": 8}).Expect(t, http.StatusOK)
+	h.DoWithHeaders(http.MethodPatch, path, admin, `{"stock": 6}`, patchHeaders("application/merge-patch+json", nil)).
+		Expect(t, http.StatusOK)
+	h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{"ids": []int{product.ID}, "adjust_stock": 4}).
+		Expect(t, http.StatusOK)
+	importFile(h, admin, "", "text/csv", "sku,name,price,stock,categories\nLEDGER-1,Recounted,10,3,1\n").
+		Expect(t, http.StatusOK)
+
+	// Newest first; unchanged stock records nothing
+	movements := ledger()
+	want := []struct{ quantity, after int }{{-7, 3}, {4, 10}, {-2, 6}, {3, 8}}
+	if len(movements) != len(want) {
+		t.Fatalf("expected %d adjustments, got %+v", len(want), movements)
+	}
+	for i, w := range want {
+		m := movements[i]
+		if m.Reason != models.StockReasonAdjustment || m.Quantity != w.quantity || m.StockAfter != w.after || m.UserID == nil {
+			t.Fatalf("unexpected movement %d: %+v", i, m)
+		}
+	}
+
+	// A rejected bulk write leaves the ledger alone
+	h.Do(http.MethodPatch, "/api/products/bulk", admin, map[string]any{"ids": []int{product.ID}, "adjust_stock": -100}).
+		Expect(t, http.StatusBadRequest)
+	if got := ledger(); len(got) != len(want) {
+		t.Fatalf("expected no adjustment for a rejected write, got %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestProductTrashAndRestore(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name": "Trashable Widget", "price": 10, "stock": 1, "category_ids": []int{1, 2},
+	}).Expect(t, http.StatusCreated).Data(t, &created)
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 5}).Expect(t, http.StatusOK)
+
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusOK)
+
+	// Deleted products disappear from reads, writes and listings
+	h.Get(path, admin).Expect(t, http.StatusNotFound)
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 1}).Expect(t, http.StatusNotFound)
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusNotFound)
+	h.Get(path+"/history", admin).Expect(t, http.StatusNotFound)
+	h.Get(path+"/history/export", admin).Expect(t, http.StatusNotFound)
+	var found models.ProductListResponse
+	h.Get("/api/products?search=Trashable", admin).Expect(t, http.StatusOK).Data(t, &found)
+	if found.Total != 0 {
+		t.Fatalf("deleted product is still listed: %+v", found)
+	}
+
+	var trash models.ProductListResponse
+	h.Get("/api/trash/products", h.ClientToken()).Expect(t, http.StatusForbidden)
+	h.Get("/api/trash/products", admin).Expect(t, http.StatusOK).Data(t, &trash)
+	if trash.Total != 1 || trash.Products[0].ID != created.ID || trash.Products[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash: %+v", trash)
+	}
+
+	var restored models.Product
+	h.Do(http.MethodPost, fmt.Sprintf("/api/trash/products/%d/restore", created.ID), admin, nil).
+		Expect(t, http.StatusOK).Data(t, &restored)
+	if restored.DeletedAt != nil || len(restored.Categories) != 2 {
+		t.Fatalf("unexpected restored product: %+v", restored)
+	}
+	h.Do(http.MethodPost, fmt.Sprintf("/api/trash/products/%d/restore", created.ID), admin, nil).Expect(t, http.StatusNotFound)
+
+	var history models.ProductHistoryResponse
+	h.Get(path+"/history", admin).Expect(t, http.StatusOK).Data(t, &history)
+	if history.Total != 1 {
+		t.Fatalf("expected history to survive the trash, got %+v", history)
+	}
+}
+
+func TestCategoryTrashAndRestore(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	// Running Shoes belongs to Clothing (2) and Sports (5)
+	var shoes models.ProductListResponse
+	h.Get("/api/search?q=running", admin).Expect(t, http.StatusOK).Data(t, &shoes)
+	productPath := fmt.Sprintf("/api/products/%d", shoes.Products[0].ID)
+
+	h.Do(http.MethodDelete, "/api/categories/5", admin, nil).Expect(t, http.StatusOK)
+	h.Get("/api/categories/5", admin).Expect(t, http.StatusNotFound)
+
+	// Trashed categories cannot be linked and free their name
+	h.Do(http.MethodPut, productPath, admin, map[string]any{"category_ids": []int{5}}).Expect(t, http.StatusUnprocessableEntity)
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Sports"}).Expect(t, http.StatusCreated)
+
+	var trash models.CategoryListResponse
+	h.Get("/api/trash/categories", admin).Expect(t, http.StatusOK).Data(t, &trash)
+	if trash.Total != 1 || trash.Categories[0].ID != 5 {
+		t.Fatalf("unexpected trash: %+v", trash)
+	}
+
+	// The new Sports category must go before the old one can come back
+	if code := h.Do(http.MethodPost, "/api/trash/categories/5/restore", admin, nil).
+		Expect(t, http.StatusConflict).Error(t).Code; code != "CONFLICT" {
+		t.Fatalf("expected CONFLICT, got %s", code)
+	}
+	h.Do(http.MethodPut, "/api/categories/9", admin, map[string]any{"name": "Sports (new)"}).Expect(t, http.StatusOK)
+	h.Do(http.MethodPost, "/api/trash/categories/5/restore", admin, nil).Expect(t, http.StatusOK)
+
+	var product models.Product
+	h.Get(productPath, admin).Expect(t, http.StatusOK).Data(t, &product)
+	if len(product.Categories) != 2 {
+		t.Fatalf("expected the Sports link to be restored, got %+v", product.Categories)
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	h := testharness.New(t, func(cfg *config.Config) { cfg.Trash.Retention = 0 })
+	admin := h.AdminToken()
+
+	h.Do(http.MethodDelete, "/api/products/1", admin, nil).Expect(t, http.StatusOK)
+	h.Do(http.MethodDelete, "/api/categories/8", admin, nil).Expect(t, http.StatusOK)
+
+	var result models.TrashPurgeResponse
+	h.Do(http.MethodPost, "/api/trash/purge", h.ClientToken(), nil).Expect(t, http.StatusForbidden)
+	h.Do(http.MethodPost, "/api/trash/purge", admin, nil).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Products != 1 || result.Categories != 1 {
+		t.Fatalf("unexpected purge result: %+v", result)
+	}
+
+	h.Do(http.MethodPost, "/api/trash/products/1/restore", admin, nil).Expect(t, http.StatusNotFound)
+	h.Do(http.MethodPost, "/api/trash/categories/8/restore", admin, nil).Expect(t, http.StatusNotFound)
+}
+
+func TestTrashPurgeKeepsItemsWithinRetention(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+
+	h.Do(http.MethodDelete, "/api/products/1", admin, nil).Expect(t, http.StatusOK)
+
+	var result models.TrashPurgeResponse
+	h.Do(http.MethodPost, "/api/trash/purge", admin, nil).Expect(t, http.StatusOK).Data(t, &result)
+	if result.Products != 0 {
+		t.Fatalf("expected nothing to be purged, got %+v", result)
+	}
+	h.Do(http.MethodPost, "/api/trash/products/1/restore", admin, nil).Expect(t, http.StatusOK)
+}
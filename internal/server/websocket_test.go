@@ -0,0 +1,65 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/testharness"
+)
+
+func TestWebSocketEvents(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	ws := h.DialWS()
+
+	var created models.Product
+	h.Do(http.MethodPost, "/api/products", admin, map[string]any{
+		"name":         "Live Product",
+		"price":        5,
+		"stock":        1,
+		"category_ids": []int{1},
+	}).Expect(t, http.StatusCreated).Data(t, &created)
+
+	var payload models.Product
+	event := ws.Expect(service.EventProductCreated, 2*time.Second)
+	if err := json.Unmarshal(event.Data, &payload); err != nil || payload.ID != created.ID {
+		t.Fatalf("unexpected product:created payload: %s", event.Data)
+	}
+
+	path := fmt.Sprintf("/api/products/%d", created.ID)
+	h.Do(http.MethodPut, path, admin, map[string]any{"stock": 2}).Expect(t, http.StatusOK)
+	ws.Expect(service.EventProductUpdated, 2*time.Second)
+
+	h.Do(http.MethodDelete, path, admin, nil).Expect(t, http.StatusOK)
+	event = ws.Expect(service.EventProductDeleted, 2*time.Second)
+	var deleted struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(event.Data, &deleted); err != nil || deleted.ID != created.ID {
+		t.Fatalf("unexpected product:deleted payload: %s", event.Data)
+	}
+
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Live Category"}).Expect(t, http.StatusCreated)
+	ws.Expect(service.EventCategoryCreated, 2*time.Second)
+}
+
+func TestWebSocketNoEventOnFailure(t *testing.T) {
+	h := testharness.New(t)
+	admin := h.AdminToken()
+	ws := h.DialWS()
+
+	// A rejected write must not be broadcast; the next event seen is the successful one
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "Electronics"}).Expect(t, http.StatusConflict)
+	h.Do(http.MethodPost, "/api/categories", admin, map[string]any{"name": "After Failure"}).Expect(t, http.StatusCreated)
+
+	var category models.Category
+	event := ws.Expect(service.EventCategoryCreated, 2*time.Second)
+	if err := json.Unmarshal(event.Data, &category); err != nil || category.Name != "After Failure" {
+		t.Fatalf("unexpected category:created payload: %s", event.Data)
+	}
+}
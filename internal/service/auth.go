@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// AuthService registers users and issues tokens
+type AuthService struct {
+	users      db.UserStore
+	tx         db.Transactor
+	jwt        *auth.JWTService
+	bcryptCost int
+}
+
+func NewAuthService(users db.UserStore, tx db.Transactor, jwtService *auth.JWTService, bcryptCost int) *AuthService {
+	return &AuthService{users: users, tx: tx, jwt: jwtService, bcryptCost: bcryptCost}
+}
+
+// Register creates a user with the default "client" role and returns a token
+func (s *AuthService) Register(ctx context.Context, req *models.UserRegisterRequest) (*models.UserLoginResponse, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	// Hash outside the transaction: bcrypt is slow on purpose
+	passwordHash, err := auth.HashPassword(req.Password, s.bcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.users.EmailExists(ctx, req.Email)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return db.ErrEmailExists
+		}
+
+		role, err := s.users.GetRoleByName(ctx, RoleClient)
+		if err != nil {
+			return err
+		}
+
+		// A concurrent registration surfaces as ErrConflict
+		created, err := s.users.CreateUser(ctx, req.Email, passwordHash, &role.ID)
+		if err != nil {
+			return err
+		}
+
+		// Load user with role
+		user, err = s.users.GetUserByID(ctx, created.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueToken(user)
+}
+
+// Login checks the credentials and returns a token
+func (s *AuthService) Login(ctx context.Context, req *models.UserLoginRequest) (*models.UserLoginResponse, error) {
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := auth.CheckPassword(req.Password, user.PasswordHash); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issueToken(user)
+}
+
+func (s *AuthService) issueToken(user *models.User) (*models.UserLoginResponse, error) {
+	roleName := ""
+	if user.Role != nil {
+		roleName = user.Role.Name
+	}
+
+	token, err := s.jwt.GenerateToken(user.ID, user.Email, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserLoginResponse{
+		User:  user.ToUserResponse(),
+		Token: token,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/filterexpr"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// MaxBulkProducts bounds the products a single bulk operation may change
+const MaxBulkProducts = 10000
+
+// BulkUpdate applies the same changes to every selected product in one
+// transaction: either all of them change or none does. One
+// product:bulk_updated event covers the batch.
+func (s *ProductService) BulkUpdate(ctx context.Context, actor *Actor, req *models.ProductBulkUpdateRequest) (*models.ProductBulkResult, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.SetPrice != nil && req.AdjustPricePercent != nil:
+		return nil, fmt.Errorf("%w: set_price and adjust_price_percent cannot be combined", db.ErrValidation)
+	case req.SetStock != nil && req.AdjustStock != nil:
+		return nil, fmt.Errorf("%w: set_stock and adjust_stock cannot be combined", db.ErrValidation)
+	case req.SetPrice == nil && req.AdjustPricePercent == nil && req.SetStock == nil && req.AdjustStock == nil &&
+		len(req.AddCategoryIDs) == 0 && len(req.RemoveCategoryIDs) == 0:
+		return nil, fmt.Errorf("%w: no changes to apply", db.ErrValidation)
+	}
+	for _, id := range req.AddCategoryIDs {
+		if slices.Contains(req.RemoveCategoryIDs, id) {
+			return nil, fmt.Errorf("%w: category %d is both added and removed", db.ErrValidation, id)
+		}
+	}
+
+	result, err := s.bulk(ctx, req.IDs, req.Filter, func(ctx context.Context, ids []int) error {
+		return s.products.BulkUpdateProducts(ctx, ids, actorUserID(actor), req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Affected > 0 {
+		publish(s.events, EventProductBulkUpdated, models.ProductBulkEvent{ProductIDs: result.ProductIDs})
+	}
+	return result, nil
+}
+
+// BulkDelete moves every selected product to the trash in one transaction
+// and publishes a single product:bulk_deleted event
+func (s *ProductService) BulkDelete(ctx context.Context, actor *Actor, req *models.ProductBulkDeleteRequest) (*models.ProductBulkResult, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	result, err := s.bulk(ctx, req.IDs, req.Filter, s.products.BulkDeleteProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Affected > 0 {
+		publish(s.events, EventProductBulkDeleted, models.ProductBulkEvent{ProductIDs: result.ProductIDs})
+	}
+	return result, nil
+}
+
+// bulk locks the live products picked by ids or filter and passes them to
+// apply within a transaction. Requested IDs that match no live product are
+// reported rather than failing the operation.
+func (s *ProductService) bulk(ctx context.Context, ids []int, filter string, apply func(ctx context.Context, ids []int) error) (*models.ProductBulkResult, error) {
+	selection, err := bulkSelection(ids, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ProductBulkResult{ProductIDs: []int{}}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// One row past the cap tells an oversized selection apart without
+		// locking the rest of the catalog
+		matched, err := s.products.LockProducts(ctx, selection, MaxBulkProducts+1)
+		if err != nil {
+			return err
+		}
+		if len(matched) > MaxBulkProducts {
+			return fmt.Errorf("%w: the filter matches more than %d products, the most a bulk operation may change", db.ErrValidation, MaxBulkProducts)
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+		if err := apply(ctx, matched); err != nil {
+			return err
+		}
+		result.ProductIDs = matched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Affected = len(result.ProductIDs)
+	for _, id := range ids {
+		if _, found := slices.BinarySearch(result.ProductIDs, id); !found {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	return result, nil
+}
+
+// bulkSelection turns the ids or filter of a bulk request into product filters
+func bulkSelection(ids []int, filter string) (*db.FilterParams, error) {
+	filter = strings.TrimSpace(filter)
+	switch {
+	case len(ids) == 0 && filter == "":
+		return nil, fmt.Errorf("%w: ids or filter is required", db.ErrValidation)
+	case len(ids) > 0 && filter != "":
+		return nil, fmt.Errorf("%w: ids and filter cannot be combined", db.ErrValidation)
+	case len(ids) > MaxBulkProducts:
+		return nil, fmt.Errorf("%w: ids may list at most %d products", db.ErrValidation, MaxBulkProducts)
+	case len(ids) > 0:
+		return &db.FilterParams{IDs: ids}, nil
+	}
+
+	expr, err := filterexpr.Parse(filter, db.ProductFilterFields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid filter: %v", db.ErrValidation, err)
+	}
+	return &db.FilterParams{Expr: expr}, nil
+}
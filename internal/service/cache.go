@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// CacheStatsReporter is implemented by stores wrapped in a read cache
+type CacheStatsReporter interface {
+	Stats() models.CacheStats
+}
+
+// CacheService exposes the read cache statistics to admins
+type CacheService struct {
+	reporter CacheStatsReporter
+}
+
+// NewCacheService reports the stats of store when it is cached
+func NewCacheService(store db.Store) *CacheService {
+	reporter, _ := store.(CacheStatsReporter)
+	return &CacheService{reporter: reporter}
+}
+
+// Stats returns the cache counters, or Enabled=false when reads are not cached
+func (s *CacheService) Stats(ctx context.Context, actor *Actor) (*models.CacheStats, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	if s.reporter == nil {
+		return &models.CacheStats{Backend: "none"}, nil
+	}
+	stats := s.reporter.Stats()
+	return &stats, nil
+}
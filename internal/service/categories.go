@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// CategoryService manages categories. Reads require an authenticated actor,
+// writes require an admin and emit category:* events after committing.
+type CategoryService struct {
+	categories db.CategoryStore
+	tx         db.Transactor
+	events     Publisher
+}
+
+func NewCategoryService(categories db.CategoryStore, tx db.Transactor, events Publisher) *CategoryService {
+	return &CategoryService{categories: categories, tx: tx, events: events}
+}
+
+// List returns the categories matching search; all of them when pagination is nil
+func (s *CategoryService) List(ctx context.Context, actor *Actor, search string, pagination *db.PaginationParams, fields db.Fieldset) ([]models.Category, int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, 0, err
+	}
+	return s.categories.ListCategories(ctx, search, pagination, fields)
+}
+
+// ListVersion works as ProductService.ListVersion for category listings
+func (s *CategoryService) ListVersion(ctx context.Context, actor *Actor) (string, error) {
+	if err := requireActor(actor); err != nil {
+		return "", err
+	}
+	return s.categories.CategoryListVersion(ctx)
+}
+
+func (s *CategoryService) Search(ctx context.Context, actor *Actor, term string, pagination *db.PaginationParams, filter *db.FilterParams) ([]models.Category, int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, 0, err
+	}
+	return s.categories.SearchCategories(ctx, term, pagination, filter)
+}
+
+// Export calls fn with every category matching search
+func (s *CategoryService) Export(ctx context.Context, actor *Actor, search string, fn func(*models.Category) error) error {
+	if err := requireActor(actor); err != nil {
+		return err
+	}
+	return s.categories.ExportCategories(ctx, search, fn)
+}
+
+func (s *CategoryService) Get(ctx context.Context, actor *Actor, id int) (*models.Category, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, err
+	}
+	return s.categories.GetCategoryByID(ctx, id)
+}
+
+func (s *CategoryService) Create(ctx context.Context, actor *Actor, req *models.CategoryCreateRequest) (*models.Category, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		category, err = s.categories.CreateCategory(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventCategoryCreated, category)
+
+	return category, nil
+}
+
+// Update applies a partial update; ifVersions works as in ProductService.Update
+func (s *CategoryService) Update(ctx context.Context, actor *Actor, id int, req *models.CategoryUpdateRequest, ifVersions []int) (*models.Category, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		category, err = s.categories.UpdateCategory(ctx, id, req, ifVersions)
+		return err
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return nil, s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventCategoryUpdated, category)
+
+	return category, nil
+}
+
+// Delete moves a category to the trash; ifVersions works as in Update
+func (s *CategoryService) Delete(ctx context.Context, actor *Actor, id int, ifVersions []int) error {
+	if err := requireAdmin(actor); err != nil {
+		return err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.categories.DeleteCategory(ctx, id, ifVersions)
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	publish(s.events, EventCategoryDeleted, map[string]int{"id": id})
+
+	return nil
+}
+
+// preconditionFailed loads the current category for a failed If-Match
+func (s *CategoryService) preconditionFailed(ctx context.Context, id int) error {
+	current, err := s.categories.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &PreconditionFailedError{Current: current, Version: current.Version}
+}
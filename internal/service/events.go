@@ -0,0 +1,23 @@
+package service
+
+// Event types published to the Publisher; the WebSocket hub forwards them
+// to clients as is
+const (
+	EventProductCreated     = "product:created"
+	EventProductUpdated     = "product:updated"
+	EventProductDeleted     = "product:deleted"
+	EventProductImported    = "product:imported"     // One event per bulk import
+	EventProductBulkUpdated = "product:bulk_updated" // One event per bulk update
+	EventProductBulkDeleted = "product:bulk_deleted" // One event per bulk delete
+	EventProductStockMoved  = "product:stock_moved"
+	EventCategoryCreated    = "category:created"
+	EventCategoryUpdated    = "category:updated"
+	EventCategoryDeleted    = "category:deleted"
+
+	EventProductRestored  = "product:restored"
+	EventCategoryRestored = "category:restored"
+	EventTrashPurged      = "trash:purged"
+
+	EventExchangeRateCreated = "exchange_rate:created"
+	EventExchangeRateDeleted = "exchange_rate:deleted"
+)
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// ExchangeRateService manages exchange rates and converts prices with them.
+// Anyone authenticated can read rates; only admins can change them.
+type ExchangeRateService struct {
+	rates  db.ExchangeRateStore
+	tx     db.Transactor
+	events Publisher
+	now    func() time.Time
+}
+
+func NewExchangeRateService(rates db.ExchangeRateStore, tx db.Transactor, events Publisher) *ExchangeRateService {
+	return &ExchangeRateService{rates: rates, tx: tx, events: events, now: time.Now}
+}
+
+func (s *ExchangeRateService) List(ctx context.Context, actor *Actor, base, quote string) ([]models.ExchangeRate, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, err
+	}
+
+	rates, err := s.rates.ListExchangeRates(ctx, base, quote)
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []models.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+func (s *ExchangeRateService) Create(ctx context.Context, actor *Actor, req *models.ExchangeRateCreateRequest) (*models.ExchangeRate, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	if req.BaseCurrency == req.QuoteCurrency {
+		return nil, fmt.Errorf("%w: base_currency and quote_currency must differ", db.ErrValidation)
+	}
+
+	var rate *models.ExchangeRate
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		rate, err = s.rates.CreateExchangeRate(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventExchangeRateCreated, rate)
+
+	return rate, nil
+}
+
+func (s *ExchangeRateService) Delete(ctx context.Context, actor *Actor, id int) error {
+	if err := requireAdmin(actor); err != nil {
+		return err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.rates.DeleteExchangeRate(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	publish(s.events, EventExchangeRateDeleted, map[string]int{"id": id})
+
+	return nil
+}
+
+// quote is the rate used to convert from one currency into another
+type quote struct {
+	rate     *models.ExchangeRate
+	inverted bool
+}
+
+// converter converts prices into a target currency, looking each currency
+// pair up once so a whole page uses the same rates
+type converter struct {
+	service  *ExchangeRateService
+	currency string
+	at       time.Time
+	quotes   map[string]quote
+}
+
+// newConverter returns nil when no conversion was requested
+func (s *ExchangeRateService) newConverter(currency string) *converter {
+	if currency == "" {
+		return nil
+	}
+	return &converter{service: s, currency: currency, at: s.now(), quotes: make(map[string]quote)}
+}
+
+// convert rewrites the product price into the target currency and records the rate used
+func (c *converter) convert(ctx context.Context, product *models.Product) error {
+	if c == nil || product.Currency == c.currency {
+		return nil
+	}
+
+	q, err := c.quote(ctx, product.Currency)
+	if err != nil {
+		return err
+	}
+
+	conversion := &models.PriceConversion{
+		OriginalPrice:    product.Price,
+		OriginalCurrency: product.Currency,
+		RateID:           q.rate.ID,
+		Inverted:         q.inverted,
+		EffectiveAt:      q.rate.EffectiveAt,
+	}
+	if q.inverted {
+		product.Price = product.Price.Quo(q.rate.Rate, models.PriceMaxDecimals)
+		conversion.Rate = money.New(1, 0).Quo(q.rate.Rate, models.RateMaxDecimals)
+	} else {
+		product.Price = product.Price.Mul(q.rate.Rate, models.PriceMaxDecimals)
+		conversion.Rate = q.rate.Rate
+	}
+	product.Currency = c.currency
+	product.Conversion = conversion
+
+	return nil
+}
+
+// quote finds from→target, falling back to the inverse of target→from
+func (c *converter) quote(ctx context.Context, from string) (quote, error) {
+	if q, ok := c.quotes[from]; ok {
+		return q, nil
+	}
+
+	rate, err := c.service.rates.GetEffectiveRate(ctx, from, c.currency, c.at)
+	q := quote{rate: rate}
+	if errors.Is(err, db.ErrNotFound) {
+		rate, err = c.service.rates.GetEffectiveRate(ctx, c.currency, from, c.at)
+		q = quote{rate: rate, inverted: true}
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		return quote{}, fmt.Errorf("%w: no exchange rate from %s to %s", db.ErrInvalidReference, from, c.currency)
+	}
+	if err != nil {
+		return quote{}, err
+	}
+
+	c.quotes[from] = q
+	return q, nil
+}
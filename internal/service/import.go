@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// MaxImportRows bounds the rows of a single import
+const MaxImportRows = 10000
+
+// Sentinels that roll back the import transaction without failing the request
+var (
+	errDryRun         = errors.New("dry run")
+	errImportRejected = errors.New("import rejected")
+)
+
+// ImportService creates and updates products in bulk
+type ImportService struct {
+	products   db.ProductStore
+	categories db.CategoryStore
+	tx         db.Transactor
+	events     Publisher
+}
+
+func NewImportService(products db.ProductStore, categories db.CategoryStore, tx db.Transactor, events Publisher) *ImportService {
+	return &ImportService{products: products, categories: categories, tx: tx, events: events}
+}
+
+// ImportProducts writes rows in one transaction: rows whose SKU belongs to a
+// live product update it and the rest create products. In atomic mode a
+// single invalid row rejects the whole import; in best effort mode each row
+// is written in its own savepoint and failures are only reported. A dry run
+// does everything and rolls back, so its report also shows the conflicts
+// only the database detects. One product:imported event covers the import.
+func (s *ImportService) ImportProducts(ctx context.Context, actor *Actor, rows []models.ProductImportRow, mode string, dryRun bool) (*models.ProductImportReport, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if mode != models.ImportModeAtomic && mode != models.ImportModeBestEffort {
+		return nil, fmt.Errorf("%w: mode must be %s or %s", db.ErrValidation, models.ImportModeAtomic, models.ImportModeBestEffort)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the import has no rows", db.ErrValidation)
+	}
+	if len(rows) > MaxImportRows {
+		return nil, fmt.Errorf("%w: the import has more than %d rows", db.ErrValidation, MaxImportRows)
+	}
+
+	report := &models.ProductImportReport{
+		Mode:   mode,
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]models.ProductImportResult, len(rows)),
+	}
+	requests, err := s.prepare(ctx, rows, report.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.matchSKUs(ctx, rows, report.Rows); err != nil {
+			return err
+		}
+		if mode == models.ImportModeAtomic && failed(report.Rows) > 0 {
+			return errImportRejected
+		}
+
+		for i, req := range requests {
+			result := &report.Rows[i]
+			if result.Action == models.ImportActionError {
+				continue
+			}
+
+			var err error
+			if mode == models.ImportModeBestEffort {
+				err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+					return s.write(ctx, actor, req, result)
+				})
+			} else {
+				err = s.write(ctx, actor, req, result)
+			}
+			if err == nil {
+				continue
+			}
+
+			rowErr, ok := importRowError(err)
+			if !ok {
+				return err
+			}
+			result.Action, result.ProductID, result.Errors = models.ImportActionError, 0, []models.FieldError{rowErr}
+			if mode == models.ImportModeAtomic {
+				return errImportRejected
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) && !errors.Is(err, errImportRejected) {
+		return nil, err
+	}
+
+	// The counters report what was written, or what would be on a dry run;
+	// a rejected import wrote nothing
+	counted := err == nil || errors.Is(err, errDryRun)
+	event := models.ProductImportEvent{ProductIDs: []int{}}
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		switch result.Action {
+		case models.ImportActionCreate:
+			if counted {
+				report.Created++
+			}
+			if err != nil {
+				// Rolled back: the ID was never committed
+				result.ProductID = 0
+			}
+		case models.ImportActionUpdate:
+			if counted {
+				report.Updated++
+			}
+		default:
+			report.Failed++
+			continue
+		}
+		event.ProductIDs = append(event.ProductIDs, result.ProductID)
+	}
+
+	report.Applied = err == nil && report.Created+report.Updated > 0
+	if report.Applied {
+		event.Created, event.Updated = report.Created, report.Updated
+		publish(s.events, EventProductImported, event)
+	}
+
+	return report, nil
+}
+
+// prepare validates every row with the rules of POST /products, resolving
+// category names and IDs against the live categories; a name match wins, so
+// a category named "2024" is never mistaken for the ID 2024. It returns the create
+// request of each row and records the errors of invalid rows in results.
+func (s *ImportService) prepare(ctx context.Context, rows []models.ProductImportRow, results []models.ProductImportResult) ([]*models.ProductCreateRequest, error) {
+	categories, _, err := s.categories.ListCategories(ctx, "", nil, db.Fieldset{"id", "name"})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int]bool, len(categories))
+	byName := make(map[string]int, len(categories))
+	for _, c := range categories {
+		ids[c.ID] = true
+		byName[strings.ToLower(c.Name)] = c.ID
+	}
+
+	requests := make([]*models.ProductCreateRequest, len(rows))
+	skuLines := make(map[string]int)
+	for i, row := range rows {
+		result := &results[i]
+		result.Line = row.Line
+		result.Errors = append([]models.FieldError(nil), row.Errors...)
+
+		req := &models.ProductCreateRequest{
+			SKU:         row.SKU,
+			Name:        strings.TrimSpace(row.Name),
+			Description: row.Description,
+			Price:       row.Price,
+			Currency:    strings.ToUpper(row.Currency),
+			Stock:       row.Stock,
+		}
+
+		if row.SKU != nil {
+			result.SKU = *row.SKU
+			if line, ok := skuLines[*row.SKU]; ok {
+				result.Errors = append(result.Errors, models.FieldError{
+					Field: "sku", Rule: "unique", Message: fmt.Sprintf("is already used on line %d", line),
+				})
+			} else {
+				skuLines[*row.SKU] = row.Line
+			}
+		}
+
+		for _, ref := range row.Categories {
+			id, ok := byName[strings.ToLower(ref)]
+			if !ok {
+				if n, err := strconv.Atoi(ref); err == nil && ids[n] {
+					id, ok = n, true
+				}
+			}
+			if !ok {
+				result.Errors = append(result.Errors, models.FieldError{
+					Field: "categories", Rule: "exists", Param: ref, Message: fmt.Sprintf("category %q does not exist", ref),
+				})
+				continue
+			}
+			if !slices.Contains(req.CategoryIDs, id) {
+				req.CategoryIDs = append(req.CategoryIDs, id)
+			}
+		}
+
+		if len(row.Errors) == 0 {
+			if err := validate(req); err != nil {
+				for _, fe := range models.FieldErrorsFromBinding(err) {
+					// Unresolved categories were already reported
+					if fe.Field == "category_ids" && len(row.Categories) > 0 {
+						continue
+					}
+					if fe.Field == "category_ids" {
+						fe.Field = "categories"
+					}
+					result.Errors = append(result.Errors, fe)
+				}
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			result.Action = models.ImportActionError
+			continue
+		}
+		result.Action = models.ImportActionCreate
+		requests[i] = req
+	}
+	return requests, nil
+}
+
+// matchSKUs turns the valid rows whose SKU belongs to a live product into updates
+func (s *ImportService) matchSKUs(ctx context.Context, rows []models.ProductImportRow, results []models.ProductImportResult) error {
+	var skus []string
+	for i, row := range rows {
+		if row.SKU != nil && results[i].Action != models.ImportActionError {
+			skus = append(skus, *row.SKU)
+		}
+	}
+	if len(skus) == 0 {
+		return nil
+	}
+
+	ids, err := s.products.ProductIDsBySKU(ctx, skus)
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if row.SKU == nil || results[i].Action == models.ImportActionError {
+			continue
+		}
+		if id, ok := ids[*row.SKU]; ok {
+			results[i].Action, results[i].ProductID = models.ImportActionUpdate, id
+		}
+	}
+	return nil
+}
+
+// write creates or updates the product of a row on behalf of actor
+func (s *ImportService) write(ctx context.Context, actor *Actor, req *models.ProductCreateRequest, result *models.ProductImportResult) error {
+	if result.Action == models.ImportActionCreate {
+		product, err := s.products.CreateProduct(ctx, req)
+		if err != nil {
+			return err
+		}
+		result.ProductID = product.ID
+		return nil
+	}
+
+	update := &models.ProductUpdateRequest{
+		Name:        &req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Stock:       &req.Stock,
+		CategoryIDs: req.CategoryIDs,
+	}
+	if req.Currency != "" {
+		update.Currency = &req.Currency
+	}
+	_, err := s.products.UpdateProduct(ctx, result.ProductID, actorUserID(actor), update, nil)
+	return err
+}
+
+// importRowError describes an error the store returned for a row. It
+// reports false for errors that are not the row's fault.
+func importRowError(err error) (models.FieldError, bool) {
+	for _, kind := range []struct {
+		err  error
+		rule string
+	}{
+		{db.ErrConflict, "conflict"},
+		{db.ErrInvalidReference, "reference"},
+		{db.ErrValidation, "invalid"},
+		{db.ErrNotFound, "not_found"},
+	} {
+		if errors.Is(err, kind.err) {
+			message := strings.TrimPrefix(err.Error(), kind.err.Error()+": ")
+			return models.FieldError{Field: "row", Rule: kind.rule, Message: message}, true
+		}
+	}
+	return models.FieldError{}, false
+}
+
+func failed(results []models.ProductImportResult) int {
+	n := 0
+	for _, result := range results {
+		if result.Action == models.ImportActionError {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+)
+
+// Formats accepted by ReadProductImport
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// Columns of a CSV import; the header row names them in any order
+var (
+	importColumns         = []string{"sku", "name", "description", "price", "currency", "stock", "categories"}
+	importRequiredColumns = []string{"name", "price", "stock", "categories"}
+)
+
+// maxImportLine bounds a single NDJSON line
+const maxImportLine = 1 << 20
+
+// ReadProductImport reads the rows of an import file. Values that cannot be
+// read are recorded on their row so the report lists them with the other
+// errors; only problems with the file as a whole fail with ErrValidation.
+//
+// CSV files start with a header row; several categories are separated by
+// "|". NDJSON files hold one product object per line, with categories as an
+// array of IDs or names. Blank lines are skipped.
+func ReadProductImport(r io.Reader, format string) ([]models.ProductImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return readImportCSV(r)
+	case ImportFormatNDJSON:
+		return readImportNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported import format %q", db.ErrValidation, format)
+	}
+}
+
+func readImportCSV(r io.Reader) ([]models.ProductImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the import has no rows", db.ErrValidation)
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %q (allowed: %s)", db.ErrValidation, name, strings.Join(importColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: column %q appears more than once", db.ErrValidation, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", db.ErrValidation, name)
+		}
+	}
+
+	var rows []models.ProductImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, csvError(err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("%w: the import has more than %d rows", db.ErrValidation, MaxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := models.ProductImportRow{Line: line}
+		if err != nil {
+			row.Errors = []models.FieldError{{
+				Field: "row", Rule: "columns", Param: strconv.Itoa(len(header)),
+				Message: fmt.Sprintf("has %d values instead of %d", len(record), len(header)),
+			}}
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row.Name = value("name")
+		row.Currency = value("currency")
+		if sku := value("sku"); sku != "" {
+			row.SKU = &sku
+		}
+		if description := value("description"); description != "" {
+			row.Description = &description
+		}
+		if raw := value("price"); raw != "" {
+			if price, err := money.Parse(raw); err == nil {
+				row.Price = &price
+			} else {
+				row.Errors = append(row.Errors, typeError("price", "number"))
+			}
+		}
+		if raw := value("stock"); raw != "" {
+			if stock, err := strconv.Atoi(raw); err == nil {
+				row.Stock = stock
+			} else {
+				row.Errors = append(row.Errors, typeError("stock", "integer"))
+			}
+		}
+		for _, ref := range strings.Split(value("categories"), "|") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				row.Categories = append(row.Categories, ref)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// csvError reports a CSV syntax error, which leaves the rest of the file unreadable
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: invalid CSV on line %d: %v", db.ErrValidation, parseErr.Line, parseErr.Err)
+	}
+	return fmt.Errorf("failed to read import: %w", err)
+}
+
+// importObject is a product as written on an NDJSON line
+type importObject struct {
+	SKU         *string       `json:"sku"`
+	Name        string        `json:"name"`
+	Description *string       `json:"description"`
+	Price       *money.Amount `json:"price"`
+	Currency    string        `json:"currency"`
+	Stock       int           `json:"stock"`
+	Categories  []interface{} `json:"categories"` // IDs or names
+}
+
+func readImportNDJSON(r io.Reader) ([]models.ProductImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+
+	var rows []models.ProductImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("%w: the import has more than %d rows", db.ErrValidation, MaxImportRows)
+		}
+
+		row := models.ProductImportRow{Line: line}
+		var object importObject
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&object); err != nil {
+			if fields := models.FieldErrorsFromBinding(err); len(fields) > 0 {
+				row.Errors = fields
+			} else {
+				row.Errors = []models.FieldError{{Field: "row", Rule: "json", Message: err.Error()}}
+			}
+			rows = append(rows, row)
+			continue
+		}
+
+		row.SKU, row.Name, row.Description = object.SKU, object.Name, object.Description
+		row.Price, row.Currency, row.Stock = object.Price, object.Currency, object.Stock
+		for _, ref := range object.Categories {
+			switch v := ref.(type) {
+			case float64:
+				row.Categories = append(row.Categories, strconv.FormatFloat(v, 'f', -1, 64))
+			case string:
+				row.Categories = append(row.Categories, strings.TrimSpace(v))
+			default:
+				row.Errors = append(row.Errors, typeError("categories", "array of IDs or names"))
+			}
+		}
+
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: a line is longer than %d bytes", db.ErrValidation, maxImportLine)
+		}
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the import has no rows", db.ErrValidation)
+	}
+	return rows, nil
+}
+
+func typeError(field, typ string) models.FieldError {
+	return models.FieldError{Field: field, Rule: "type", Param: typ, Message: "must be of type " + typ}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// PatchFunc applies a merge patch or JSON Patch to the JSON document of a
+// resource and returns the patched document
+type PatchFunc func(doc []byte) ([]byte, error)
+
+// patchAttempts bounds the retries of a patch that lost a race with another
+// write and was sent without If-Match
+const patchAttempts = 3
+
+// Patch applies patch to the writable fields of a product and saves the
+// result like Update, in one transaction. ifVersions works as in Update.
+func (s *ProductService) Patch(ctx context.Context, actor *Actor, id int, patch PatchFunc, ifVersions []int) (*models.Product, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	var product *models.Product
+	var changed bool
+	err := patchWithRetry(ctx, s.tx, ifVersions, func(ctx context.Context) error {
+		changed = false
+		current, err := s.products.GetProductByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ifVersions != nil && !slices.Contains(ifVersions, current.Version) {
+			return fmt.Errorf("product %w", db.ErrPreconditionFailed)
+		}
+
+		doc := models.ProductPatchDocument{
+			SKU:         current.SKU,
+			Name:        current.Name,
+			Description: current.Description,
+			Price:       &current.Price,
+			Currency:    current.Currency,
+			Stock:       &current.Stock,
+			CategoryIDs: []int{},
+		}
+		for _, category := range current.Categories {
+			doc.CategoryIDs = append(doc.CategoryIDs, category.ID)
+		}
+		var patched models.ProductPatchDocument
+		if err := applyPatch(patch, doc, &patched); err != nil {
+			return err
+		}
+
+		req, ok := productChanges(&doc, &patched)
+		if !ok {
+			product = current
+			return nil
+		}
+		// The version read is the one patched, so a concurrent write fails the update
+		product, err = s.products.UpdateProduct(ctx, id, actorUserID(actor), req, []int{current.Version})
+		changed = err == nil
+		return err
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return nil, s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		publish(s.events, EventProductUpdated, product)
+	}
+
+	return product, nil
+}
+
+// productChanges turns the difference between two product documents into
+// an update request, reporting false when nothing changed
+func productChanges(before, after *models.ProductPatchDocument) (*models.ProductUpdateRequest, bool) {
+	req := &models.ProductUpdateRequest{}
+	changed := false
+
+	switch {
+	case after.SKU == nil && before.SKU != nil:
+		req.ClearSKU, changed = true, true
+	case after.SKU != nil && (before.SKU == nil || *after.SKU != *before.SKU):
+		req.SKU, changed = after.SKU, true
+	}
+	if after.Name != before.Name {
+		req.Name, changed = &after.Name, true
+	}
+	switch {
+	case after.Description == nil && before.Description != nil:
+		req.ClearDescription, changed = true, true
+	case after.Description != nil && (before.Description == nil || *after.Description != *before.Description):
+		req.Description, changed = after.Description, true
+	}
+	if after.Price.Cmp(*before.Price) != 0 {
+		req.Price, changed = after.Price, true
+	}
+	if after.Currency != before.Currency {
+		req.Currency, changed = &after.Currency, true
+	}
+	if *after.Stock != *before.Stock {
+		req.Stock, changed = after.Stock, true
+	}
+	if !sameIDs(after.CategoryIDs, before.CategoryIDs) {
+		req.CategoryIDs, changed = after.CategoryIDs, true
+	}
+
+	return req, changed
+}
+
+// Patch applies patch to a category and saves the result like Update
+func (s *CategoryService) Patch(ctx context.Context, actor *Actor, id int, patch PatchFunc, ifVersions []int) (*models.Category, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	var changed bool
+	err := patchWithRetry(ctx, s.tx, ifVersions, func(ctx context.Context) error {
+		changed = false
+		current, err := s.categories.GetCategoryByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ifVersions != nil && !slices.Contains(ifVersions, current.Version) {
+			return fmt.Errorf("category %w", db.ErrPreconditionFailed)
+		}
+
+		doc := models.CategoryPatchDocument{Name: current.Name, Description: current.Description}
+		var patched models.CategoryPatchDocument
+		if err := applyPatch(patch, doc, &patched); err != nil {
+			return err
+		}
+
+		req := &models.CategoryUpdateRequest{}
+		if patched.Name != doc.Name {
+			req.Name, changed = &patched.Name, true
+		}
+		switch {
+		case patched.Description == nil && doc.Description != nil:
+			req.ClearDescription, changed = true, true
+		case patched.Description != nil && (doc.Description == nil || *patched.Description != *doc.Description):
+			req.Description, changed = patched.Description, true
+		}
+		if !changed {
+			category = current
+			return nil
+		}
+
+		category, err = s.categories.UpdateCategory(ctx, id, req, []int{current.Version})
+		changed = err == nil
+		return err
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return nil, s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		publish(s.events, EventCategoryUpdated, category)
+	}
+
+	return category, nil
+}
+
+// patchWithRetry runs fn, which reads, patches and writes a resource, in a
+// transaction. The write only succeeds on the version read; when another
+// write got there first and the client sent no If-Match, and so does not
+// mind which version it patches, fn runs again on the new version.
+func patchWithRetry(ctx context.Context, tx db.Transactor, ifVersions []int, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < patchAttempts; attempt++ {
+		err = tx.WithinTx(ctx, fn)
+		if ifVersions != nil || !errors.Is(err, db.ErrPreconditionFailed) {
+			return err
+		}
+	}
+	return err
+}
+
+// applyPatch runs patch on the JSON of doc and decodes the result into
+// patched, which must still be a valid document
+func applyPatch(patch PatchFunc, doc interface{}, patched interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	data, err = patch(data)
+	if err != nil {
+		return err
+	}
+
+	// Members outside the document (such as id or version) are read-only
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		return fmt.Errorf("%w: the patched document is invalid: %v", db.ErrValidation, err)
+	}
+	return validate(patched)
+}
+
+// sameIDs reports whether two ID lists hold the same IDs in any order
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA, sortedB := slices.Clone(a), slices.Clone(b)
+	slices.Sort(sortedA)
+	slices.Sort(sortedB)
+	return slices.Equal(sortedA, sortedB)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// ProductService manages products. Reads require an authenticated actor,
+// writes require an admin and emit product:* events after committing.
+type ProductService struct {
+	products db.ProductStore
+	rates    *ExchangeRateService
+	tx       db.Transactor
+	events   Publisher
+}
+
+func NewProductService(products db.ProductStore, rates *ExchangeRateService, tx db.Transactor, events Publisher) *ProductService {
+	return &ProductService{products: products, rates: rates, tx: tx, events: events}
+}
+
+// List returns a page of products. When currency is set, prices are
+// converted with the rates effective now.
+func (s *ProductService) List(ctx context.Context, actor *Actor, pagination *db.PaginationParams, filter *db.FilterParams, currency string) ([]models.Product, int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, 0, err
+	}
+
+	// Converting a price needs its currency; without the price there is nothing to convert
+	if !filter.Fields.Has("price") {
+		currency = ""
+	} else if currency != "" {
+		filter.Fields = filter.Fields.With("currency")
+	}
+
+	products, total, err := s.products.ListProducts(ctx, pagination, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conv := s.rates.newConverter(currency)
+	for i := range products {
+		if err := conv.convert(ctx, &products[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return products, total, nil
+}
+
+// ListVersion returns a value that changes whenever a listing of products
+// might, so a conditional request can be answered without listing them.
+// Converted prices also depend on exchange rates, which it does not track.
+func (s *ProductService) ListVersion(ctx context.Context, actor *Actor) (string, error) {
+	if err := requireActor(actor); err != nil {
+		return "", err
+	}
+	return s.products.ProductListVersion(ctx)
+}
+
+// Get returns a product, converting its price when currency is set
+func (s *ProductService) Get(ctx context.Context, actor *Actor, id int, currency string) (*models.Product, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, err
+	}
+
+	product, err := s.products.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rates.newConverter(currency).convert(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// MaxBatchProducts bounds the IDs a single batch get may ask for
+const MaxBatchProducts = 100
+
+// GetMany returns the products among ids in the order they were asked for,
+// plus the ids that match no product
+func (s *ProductService) GetMany(ctx context.Context, actor *Actor, ids []int, fields db.Fieldset, currency string) ([]models.Product, []int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, nil, err
+	}
+
+	// Repeated ids are answered once
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	ids = unique
+	if len(ids) > MaxBatchProducts {
+		return nil, nil, fmt.Errorf("%w: ids may list at most %d products", db.ErrValidation, MaxBatchProducts)
+	}
+
+	if !fields.Has("price") {
+		currency = ""
+	} else if currency != "" {
+		fields = fields.With("currency")
+	}
+
+	found, err := s.products.GetProductsByIDs(ctx, ids, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]models.Product, len(found))
+	for _, product := range found {
+		byID[product.ID] = product
+	}
+
+	conv := s.rates.newConverter(currency)
+	products := make([]models.Product, 0, len(found))
+	notFound := []int{}
+	for _, id := range ids {
+		product, ok := byID[id]
+		if !ok {
+			notFound = append(notFound, id)
+			continue
+		}
+		if err := conv.convert(ctx, &product); err != nil {
+			return nil, nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, notFound, nil
+}
+
+// History returns the price and stock changes of a product; all of them when
+// pagination is nil. Like the product, it is gone while the product is in the trash.
+func (s *ProductService) History(ctx context.Context, actor *Actor, id int, start, end *time.Time, pagination *db.PaginationParams, fields db.Fieldset) ([]models.ProductHistory, int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.products.GetProductByID(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.products.GetProductHistory(ctx, id, start, end, pagination, fields)
+}
+
+// Export calls fn with every product matching filter, in prices of their own currency
+func (s *ProductService) Export(ctx context.Context, actor *Actor, filter *db.FilterParams, fn func(*models.Product) error) error {
+	if err := requireActor(actor); err != nil {
+		return err
+	}
+	return s.products.ExportProducts(ctx, filter, fn)
+}
+
+// ExportHistory calls fn with every change of a product between start and end
+func (s *ProductService) ExportHistory(ctx context.Context, actor *Actor, id int, start, end *time.Time, fn func(*models.ProductHistory) error) error {
+	if err := requireActor(actor); err != nil {
+		return err
+	}
+	if _, err := s.products.GetProductByID(ctx, id); err != nil {
+		return err
+	}
+	return s.products.ExportProductHistory(ctx, id, start, end, fn)
+}
+
+func (s *ProductService) Create(ctx context.Context, actor *Actor, req *models.ProductCreateRequest) (*models.Product, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	var product *models.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = s.products.CreateProduct(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventProductCreated, product)
+
+	return product, nil
+}
+
+// Update applies a partial update. ifVersions carries the If-Match versions
+// (nil for an unconditional write); on a mismatch it returns a
+// *PreconditionFailedError holding the current product.
+func (s *ProductService) Update(ctx context.Context, actor *Actor, id int, req *models.ProductUpdateRequest, ifVersions []int) (*models.Product, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	var product *models.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = s.products.UpdateProduct(ctx, id, actorUserID(actor), req, ifVersions)
+		return err
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return nil, s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventProductUpdated, product)
+
+	return product, nil
+}
+
+// Delete moves a product to the trash; ifVersions works as in Update
+func (s *ProductService) Delete(ctx context.Context, actor *Actor, id int, ifVersions []int) error {
+	if err := requireAdmin(actor); err != nil {
+		return err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.products.DeleteProduct(ctx, id, ifVersions)
+	})
+	if errors.Is(err, db.ErrPreconditionFailed) {
+		return s.preconditionFailed(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	publish(s.events, EventProductDeleted, map[string]int{"id": id})
+
+	return nil
+}
+
+// preconditionFailed loads the current product for a failed If-Match
+func (s *ProductService) preconditionFailed(ctx context.Context, id int) error {
+	current, err := s.products.GetProductByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &PreconditionFailedError{Current: current, Version: current.Version}
+}
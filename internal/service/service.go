@@ -0,0 +1,132 @@
+// Package service holds the business rules shared by every transport
+// (HTTP handlers today, gRPC or CLI tools tomorrow): validation,
+// transactions, authorization and event emission.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// Authorization errors returned by the services
+var (
+	ErrUnauthenticated    = errors.New("authentication required")
+	ErrForbidden          = errors.New("you don't have permission to perform this action")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// PreconditionFailedError is returned when an If-Match version no longer
+// matches. Current is the latest representation so the client can merge and retry.
+type PreconditionFailedError struct {
+	Current interface{}
+	Version int
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("resource %s (current version %d)", db.ErrPreconditionFailed, e.Version)
+}
+
+func (e *PreconditionFailedError) Unwrap() error {
+	return db.ErrPreconditionFailed
+}
+
+// Role names stored in the roles table
+const (
+	RoleAdmin  = "admin"
+	RoleClient = "client"
+)
+
+// Actor is the authenticated user on whose behalf an operation runs
+type Actor struct {
+	UserID int
+	Email  string
+	Role   string
+}
+
+// IsAdmin reports whether the actor has the admin role
+func (a *Actor) IsAdmin() bool {
+	return a != nil && a.Role == RoleAdmin
+}
+
+// SystemActor is used by trusted internal callers such as seeders and CLI tools
+var SystemActor = &Actor{Role: RoleAdmin, Email: "system"}
+
+// Publisher emits domain events (e.g. to WebSocket clients)
+type Publisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// Services bundles every service so transports can be wired in one place
+type Services struct {
+	Auth          *AuthService
+	Products      *ProductService
+	Categories    *CategoryService
+	ExchangeRates *ExchangeRateService
+	Trash         *TrashService
+	Cache         *CacheService
+	Import        *ImportService
+	Stock         *StockService
+}
+
+func New(store db.Store, jwtService *auth.JWTService, events Publisher, cfg *config.Config) *Services {
+	rates := NewExchangeRateService(store, store, events)
+
+	return &Services{
+		Auth:          NewAuthService(store, store, jwtService, cfg.Auth.BcryptCost),
+		Products:      NewProductService(store, rates, store, events),
+		Categories:    NewCategoryService(store, store, events),
+		ExchangeRates: rates,
+		Trash:         NewTrashService(store, store, store, events, time.Duration(cfg.Trash.Retention)),
+		Cache:         NewCacheService(store),
+		Import:        NewImportService(store, store, store, events),
+		Stock:         NewStockService(store, store, store, events),
+	}
+}
+
+func requireActor(actor *Actor) error {
+	if actor == nil {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+func requireAdmin(actor *Actor) error {
+	if err := requireActor(actor); err != nil {
+		return err
+	}
+	if !actor.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// requestValidator reads the same binding tags and custom rules the HTTP
+// layer uses, so callers that don't go through gin get identical validation
+var requestValidator = sync.OnceValue(func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	models.RegisterValidators(v)
+	return v
+})
+
+// validate checks a request struct (or a pointer to one)
+func validate(req interface{}) error {
+	if err := requestValidator().Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", db.ErrValidation, err)
+	}
+	return nil
+}
+
+func publish(events Publisher, eventType string, data interface{}) {
+	if events != nil {
+		events.Publish(eventType, data)
+	}
+}
@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db/memory"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/money"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+)
+
+// The services must validate with the custom rules without the HTTP router,
+// as a CLI or gRPC transport would use them
+func TestServicesValidateWithoutRouter(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	categories := service.NewCategoryService(store, store, nil)
+	products := service.NewProductService(store, service.NewExchangeRateService(store, store, nil), store, nil)
+
+	category, err := categories.Create(ctx, service.SystemActor, &models.CategoryCreateRequest{Name: "Tools"})
+	if err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+
+	sku, price := "ACME-1", money.MustParse("10.50")
+	product, err := products.Create(ctx, service.SystemActor, &models.ProductCreateRequest{
+		SKU: &sku, Name: "Hammer", Price: &price, Stock: 3, CategoryIDs: []int{category.ID},
+	})
+	if err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	if product.SKU == nil || *product.SKU != sku {
+		t.Fatalf("unexpected product: %+v", product)
+	}
+
+	for name, req := range map[string]*models.ProductCreateRequest{
+		"sku":             {SKU: ptr("no spaces"), Name: "Hammer", Price: &price, Stock: 1, CategoryIDs: []int{category.ID}},
+		"price_precision": {Name: "Hammer", Price: ptr(money.MustParse("1.005")), Stock: 1, CategoryIDs: []int{category.ID}},
+		"unique_ids":      {Name: "Hammer", Price: &price, Stock: 1, CategoryIDs: []int{category.ID, category.ID}},
+	} {
+		if _, err := products.Create(ctx, service.SystemActor, req); !errors.Is(err, db.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
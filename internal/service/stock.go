@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// StockService records stock movements, the way to change stock that keeps
+// track of why it changed; stock set by product writes is recorded as an
+// adjustment by the store. Anyone authenticated can read the ledger; only
+// admins can add to it.
+type StockService struct {
+	stock    db.StockMovementStore
+	products db.ProductStore
+	tx       db.Transactor
+	events   Publisher
+}
+
+func NewStockService(stock db.StockMovementStore, products db.ProductStore, tx db.Transactor, events Publisher) *StockService {
+	return &StockService{stock: stock, products: products, tx: tx, events: events}
+}
+
+// Move applies a signed quantity to the stock of a product and records it
+// in the ledger on behalf of actor. It fails with a conflict, changing
+// nothing, when the stock would go below zero.
+func (s *StockService) Move(ctx context.Context, actor *Actor, productID int, req *models.StockMovementCreateRequest) (*models.StockMovement, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+	if err := checkStockSign(req.Reason, req.Quantity); err != nil {
+		return nil, err
+	}
+
+	var movement *models.StockMovement
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		movement, err = s.stock.CreateStockMovement(ctx, productID, actorUserID(actor), req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventProductStockMoved, movement)
+
+	return movement, nil
+}
+
+// List returns a page of the ledger of a product, newest first, optionally
+// only the movements with reason
+func (s *StockService) List(ctx context.Context, actor *Actor, productID int, reason string, pagination *db.PaginationParams) ([]models.StockMovement, int, error) {
+	if err := requireActor(actor); err != nil {
+		return nil, 0, err
+	}
+	if reason != "" && !validStockReason(reason) {
+		return nil, 0, fmt.Errorf("%w: unknown reason %q", db.ErrValidation, reason)
+	}
+	if _, err := s.products.GetProductByID(ctx, productID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.stock.ListStockMovements(ctx, productID, reason, pagination)
+}
+
+// actorUserID is the user recorded in the stock ledger for actor's writes;
+// system callers have none
+func actorUserID(actor *Actor) *int {
+	if actor.UserID == 0 {
+		return nil
+	}
+	userID := actor.UserID
+	return &userID
+}
+
+// checkStockSign rejects quantities whose sign contradicts the reason, such
+// as a sale that adds units
+func checkStockSign(reason string, quantity int) error {
+	switch reason {
+	case models.StockReasonSale, models.StockReasonDamage:
+		if quantity > 0 {
+			return fmt.Errorf("%w: a %s must take units out, so quantity must be negative", db.ErrValidation, reason)
+		}
+	case models.StockReasonRestock, models.StockReasonReturn:
+		if quantity < 0 {
+			return fmt.Errorf("%w: a %s must add units, so quantity must be positive", db.ErrValidation, reason)
+		}
+	}
+	return nil
+}
+
+func validStockReason(reason string) bool {
+	switch reason {
+	case models.StockReasonSale, models.StockReasonRestock, models.StockReasonAdjustment, models.StockReasonReturn, models.StockReasonDamage:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+)
+
+// TrashService manages soft-deleted products and categories. Everything in
+// it is admin-only: listing the trash, restoring items and purging the ones
+// older than the retention period.
+type TrashService struct {
+	products   db.ProductStore
+	categories db.CategoryStore
+	tx         db.Transactor
+	events     Publisher
+	retention  time.Duration
+	now        func() time.Time
+}
+
+func NewTrashService(products db.ProductStore, categories db.CategoryStore, tx db.Transactor, events Publisher, retention time.Duration) *TrashService {
+	return &TrashService{products: products, categories: categories, tx: tx, events: events, retention: retention, now: time.Now}
+}
+
+func (s *TrashService) Products(ctx context.Context, actor *Actor, pagination *db.PaginationParams) ([]models.Product, int, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, 0, err
+	}
+	return s.products.ListDeletedProducts(ctx, pagination)
+}
+
+func (s *TrashService) Categories(ctx context.Context, actor *Actor) ([]models.Category, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+	return s.categories.ListDeletedCategories(ctx)
+}
+
+func (s *TrashService) RestoreProduct(ctx context.Context, actor *Actor, id int) (*models.Product, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	var product *models.Product
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = s.products.RestoreProduct(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventProductRestored, product)
+
+	return product, nil
+}
+
+func (s *TrashService) RestoreCategory(ctx context.Context, actor *Actor, id int) (*models.Category, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	var category *models.Category
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		category, err = s.categories.RestoreCategory(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publish(s.events, EventCategoryRestored, category)
+
+	return category, nil
+}
+
+// Purge permanently removes the items deleted longer than the retention period ago
+func (s *TrashService) Purge(ctx context.Context, actor *Actor) (*models.TrashPurgeResponse, error) {
+	if err := requireAdmin(actor); err != nil {
+		return nil, err
+	}
+
+	result := &models.TrashPurgeResponse{DeletedBefore: s.now().Add(-s.retention)}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if result.Products, err = s.products.PurgeProducts(ctx, result.DeletedBefore); err != nil {
+			return err
+		}
+		result.Categories, err = s.categories.PurgeCategories(ctx, result.DeletedBefore)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Products > 0 || result.Categories > 0 {
+		publish(s.events, EventTrashPurged, result)
+	}
+
+	return result, nil
+}
+
+// RunPurger purges expired items every interval until ctx is cancelled
+func (s *TrashService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.Purge(ctx, SystemActor)
+			if err != nil {
+				log.Printf("Failed to purge trash: %v", err)
+				continue
+			}
+			if result.Products > 0 || result.Categories > 0 {
+				log.Printf("Purged %d products and %d categories from the trash", result.Products, result.Categories)
+			}
+		}
+	}
+}
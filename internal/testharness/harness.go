@@ -0,0 +1,390 @@
+// Package testharness boots the full HTTP router against a throwaway database
+// for end-to-end tests. Postgres is used (TEST_DATABASE_URL or local
+// initdb/pg_ctl binaries) unless TEST_BACKEND=memory asks for the in-memory
+// store; without either, the tests are skipped rather than silently run on
+// memory, and TEST_BACKEND=postgres turns that skip into a failure.
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/auth"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/cache"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db/cached"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/db/memory"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/migrations"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/seed"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/server"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/service"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/websockets"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Seeded credentials (see seed.SeedUsers)
+const (
+	AdminEmail     = "admin@bsmart.com"
+	AdminPassword  = "admin123"
+	ClientEmail    = "client@bsmart.com"
+	ClientPassword = "client123"
+)
+
+// Backends
+const (
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// BackendEnv selects the backend. Unset, Postgres is used when available;
+// BackendPostgres requires it and BackendMemory uses the in-memory store.
+const BackendEnv = "TEST_BACKEND"
+
+// announceOnce prints the backend once per test binary, even without -v
+var announceOnce sync.Once
+
+// skipped records that a test was skipped for lack of Postgres, for Main to report
+var skipped atomic.Bool
+
+// Harness is a running server with a seeded, isolated database
+type Harness struct {
+	T       testing.TB
+	Backend string
+	Config  *config.Config
+	Store   db.Store
+	Pool    *pgxpool.Pool // nil for the memory backend
+	Hub     *websockets.Hub
+	Server  *httptest.Server
+}
+
+// Main runs the package tests and stops the shared Postgres server afterwards.
+// Call it from TestMain.
+func Main(m *testing.M) {
+	code := m.Run()
+	stopCluster()
+	if skipped.Load() {
+		fmt.Fprintf(os.Stderr, "testharness: WARNING: tests that need PostgreSQL were SKIPPED: %v\n"+
+			"testharness: set %s=%s to run them on the in-memory store\n", sharedErr, BackendEnv, BackendMemory)
+	}
+	os.Exit(code)
+}
+
+// New starts a harness on Postgres, or on the in-memory store when
+// TEST_BACKEND=memory. When Postgres is wanted but not available the test
+// is skipped. configure can adjust the default config.
+func New(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+
+	switch backend := os.Getenv(BackendEnv); backend {
+	case BackendMemory:
+		return start(t, BackendMemory, memory.New(), nil, configure)
+	case "", BackendPostgres:
+	default:
+		t.Fatalf("unknown %s %q: use %s or %s", BackendEnv, backend, BackendPostgres, BackendMemory)
+	}
+	return NewPostgres(t, configure...)
+}
+
+// NewPostgres starts a harness on Postgres and skips the test when none is
+// available, unless TEST_BACKEND=postgres requires it
+func NewPostgres(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+
+	store, pool, err := postgresStore(t)
+	if errors.Is(err, errNoPostgres) {
+		if os.Getenv(BackendEnv) == BackendPostgres {
+			t.Fatalf("%s=%s but %v", BackendEnv, BackendPostgres, err)
+		}
+		skipped.Store(true)
+		t.Skipf("SKIPPED, this test needs PostgreSQL: %v (set %s=%s to run on the in-memory store)", err, BackendEnv, BackendMemory)
+	}
+	if err != nil {
+		t.Fatalf("failed to prepare test database: %v", err)
+	}
+	return start(t, BackendPostgres, store, pool, configure)
+}
+
+// postgresStore creates a fresh database and runs the embedded migrations on it
+func postgresStore(t testing.TB) (db.Store, *pgxpool.Pool, error) {
+	c, err := getCluster()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dsn, drop, err := c.createDatabase(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.Cleanup(drop)
+
+	pool, err := openPool(ctx, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.Cleanup(pool.Close)
+
+	if err := migrations.Up(ctx, pool); err != nil {
+		return nil, nil, err
+	}
+
+	return db.NewDB(pool), pool, nil
+}
+
+func start(t testing.TB, backend string, store db.Store, pool *pgxpool.Pool, configure []func(*config.Config)) *Harness {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	announceOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "testharness: running against the %s backend\n", backend)
+	})
+	t.Logf("backend: %s", backend)
+
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.Auth.BcryptCost = bcrypt.MinCost
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	// Seeding hashes several passwords; cfg keeps the cost low
+	if err := seed.SeedAll(store, cfg.Auth.BcryptCost); err != nil {
+		t.Fatalf("failed to seed %s store: %v", backend, err)
+	}
+
+	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.TokenTTL))
+	hub := websockets.NewHub(cfg.WebSocket, cfg.Pricing.JSONFormat)
+	go hub.Run()
+
+	// Same wiring as cmd/app: the harness Store stays the raw store so tests
+	// can inspect what was persisted
+	var (
+		reads  db.Store          = store
+		events service.Publisher = hub
+	)
+	if readCache := cache.New(cfg.Cache); readCache != nil {
+		cachedStore := cached.New(store, readCache, hub, cfg.Cache)
+		reads, events = cachedStore, cachedStore
+	}
+	services := service.New(reads, jwtService, events, cfg)
+	srv := httptest.NewServer(server.SetupRouter(cfg, services, jwtService, hub))
+	t.Cleanup(srv.Close)
+
+	return &Harness{
+		T:       t,
+		Backend: backend,
+		Config:  cfg,
+		Store:   store,
+		Pool:    pool,
+		Hub:     hub,
+		Server:  srv,
+	}
+}
+
+// Response is a fully read HTTP response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Do sends a request to path (e.g. "/api/products"). body may be nil, a
+// string or []byte sent as-is, or any value encoded as JSON.
+func (h *Harness) Do(method, path, token string, body any) *Response {
+	h.T.Helper()
+	return h.DoWithHeaders(method, path, token, body, nil)
+}
+
+// DoWithHeaders is Do with extra request headers (e.g. If-Match)
+func (h *Harness) DoWithHeaders(method, path, token string, body any, header http.Header) *Response {
+	h.T.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			h.T.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, h.Server.URL+path, reader)
+	if err != nil {
+		h.T.Fatalf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		h.T.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.T.Fatalf("failed to read response of %s %s: %v", method, path, err)
+	}
+
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Get is shorthand for Do(http.MethodGet, ...)
+func (h *Harness) Get(path, token string) *Response {
+	h.T.Helper()
+	return h.Do(http.MethodGet, path, token, nil)
+}
+
+// Login returns a JWT for the given credentials, failing the test otherwise
+func (h *Harness) Login(email, password string) string {
+	h.T.Helper()
+
+	resp := h.Do(http.MethodPost, "/api/auth/login", "", map[string]string{"email": email, "password": password})
+	resp.Expect(h.T, http.StatusOK)
+
+	var login models.UserLoginResponse
+	resp.Data(h.T, &login)
+	return login.Token
+}
+
+// AdminToken logs in as the seeded admin
+func (h *Harness) AdminToken() string {
+	h.T.Helper()
+	return h.Login(AdminEmail, AdminPassword)
+}
+
+// ClientToken logs in as the seeded client
+func (h *Harness) ClientToken() string {
+	h.T.Helper()
+	return h.Login(ClientEmail, ClientPassword)
+}
+
+// Expect fails the test when the status code differs, printing the body
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.StatusCode != status {
+		t.Fatalf("expected status %d, got %d: %s", status, r.StatusCode, r.Body)
+	}
+	return r
+}
+
+// Data decodes the "data" field of the response envelope into v
+func (r *Response) Data(t testing.TB, v any) {
+	t.Helper()
+
+	envelope := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		t.Fatalf("invalid response body: %v: %s", err, r.Body)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("failed to decode response data: %v: %s", err, envelope.Data)
+	}
+}
+
+// Error decodes the "error" field of the response envelope
+func (r *Response) Error(t testing.TB) models.ApiError {
+	t.Helper()
+
+	var envelope models.ApiResponse
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		t.Fatalf("invalid response body: %v: %s", err, r.Body)
+	}
+	if envelope.Error == nil {
+		t.Fatalf("response has no error: %s", r.Body)
+	}
+	return *envelope.Error
+}
+
+// Event is a WebSocket event as received by clients
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// WSClient is a WebSocket connection to the harness server
+type WSClient struct {
+	t    testing.TB
+	conn *websocket.Conn
+}
+
+// DialWS connects to /ws and waits until the hub has registered the client
+func (h *Harness) DialWS() *WSClient {
+	h.T.Helper()
+
+	before := h.Hub.ClientCount()
+	wsURL := "ws" + strings.TrimPrefix(h.Server.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		h.T.Fatalf("failed to connect to websocket: %v", err)
+	}
+	h.T.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for h.Hub.ClientCount() <= before {
+		if time.Now().After(deadline) {
+			h.T.Fatalf("websocket client was not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return &WSClient{t: h.T, conn: conn}
+}
+
+// Expect reads events until one of the given type arrives, failing after timeout
+func (w *WSClient) Expect(eventType string, timeout time.Duration) Event {
+	w.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		w.conn.SetReadDeadline(deadline)
+		_, message, err := w.conn.ReadMessage()
+		if err != nil {
+			w.t.Fatalf("did not receive %q event: %v", eventType, err)
+		}
+
+		// The write pump may batch several events in one frame, one per line
+		for _, line := range bytes.Split(message, []byte{'\n'}) {
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				w.t.Fatalf("invalid websocket event: %v: %s", err, line)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package testharness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseURLEnv points the harness at an existing server (any database on it);
+// each test still gets its own throwaway database
+const DatabaseURLEnv = "TEST_DATABASE_URL"
+
+// errNoPostgres is returned when neither TEST_DATABASE_URL nor local binaries are available
+var errNoPostgres = errors.New("no PostgreSQL available: set " + DatabaseURLEnv + " or install initdb/pg_ctl")
+
+// cluster is a PostgreSQL server shared by every test in the package
+type cluster struct {
+	adminURL string
+	stop     func()
+}
+
+var (
+	clusterOnce sync.Once
+	shared      *cluster
+	sharedErr   error
+	databaseSeq atomic.Int64
+)
+
+// getCluster starts (or connects to) the shared server on first use
+func getCluster() (*cluster, error) {
+	clusterOnce.Do(func() {
+		if dsn := os.Getenv(DatabaseURLEnv); dsn != "" {
+			shared = &cluster{adminURL: dsn, stop: func() {}}
+			return
+		}
+		shared, sharedErr = startLocalCluster()
+	})
+	return shared, sharedErr
+}
+
+// stopCluster shuts down a server started by the harness, if any
+func stopCluster() {
+	if shared != nil {
+		shared.stop()
+	}
+}
+
+// startLocalCluster runs initdb + pg_ctl in a temporary directory,
+// listening only on a unix socket so it never clashes with a dev server
+func startLocalCluster() (*cluster, error) {
+	initdb, err := findBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := findBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+	// initdb refuses to run as root
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("%w (initdb cannot run as root)", errNoPostgres)
+	}
+
+	// Short path: unix socket paths are limited to ~100 bytes
+	dir, err := os.MkdirTemp("", "bsmart-pg-")
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	cmd := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync", "-E", "UTF8")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb failed: %w\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -c fsync=off -c synchronous_commit=off -c full_page_writes=off", port, dir)
+	cmd = exec.Command(pgCtl, "-D", dataDir, "-o", opts, "-l", filepath.Join(dir, "postgres.log"), "-w", "start")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start failed: %w\n%s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	adminURL := fmt.Sprintf("postgres://postgres@/postgres?host=%s&port=%d&sslmode=disable", url.QueryEscape(dir), port)
+	return &cluster{adminURL: adminURL, stop: stop}, nil
+}
+
+// findBinary looks in PATH and in the usual Debian/Homebrew install locations
+func findBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	patterns := []string{
+		"/usr/lib/postgresql/*/bin/" + name,
+		"/usr/local/opt/postgresql*/bin/" + name,
+		"/opt/homebrew/opt/postgresql*/bin/" + name,
+	}
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		if len(matches) > 0 {
+			// Highest version sorts last
+			return matches[len(matches)-1], nil
+		}
+	}
+
+	return "", errNoPostgres
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// createDatabase creates an empty database on the cluster and returns its
+// URL together with a function that drops it
+func (c *cluster) createDatabase(ctx context.Context) (string, func(), error) {
+	admin, err := pgx.Connect(ctx, c.adminURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to connect to test server: %w", err)
+	}
+	defer admin.Close(ctx)
+
+	name := fmt.Sprintf("bsmart_test_%d_%d", os.Getpid(), databaseSeq.Add(1))
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+		return "", nil, fmt.Errorf("failed to create test database: %w", err)
+	}
+
+	u, err := url.Parse(c.adminURL)
+	if err != nil {
+		return "", nil, err
+	}
+	u.Path = "/" + name
+
+	drop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		admin, err := pgx.Connect(ctx, c.adminURL)
+		if err != nil {
+			return
+		}
+		defer admin.Close(ctx)
+		admin.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)")
+	}
+
+	return u.String(), drop, nil
+}
+
+// openPool connects to a test database
+func openPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
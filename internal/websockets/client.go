@@ -12,8 +12,6 @@ const (
 	pongWait  = 60 * time.Second
 
 	pingPeriod = (pongWait * 9) / 10
-
-	maxMessageSize = 512
 )
 
 // represents a single WebSocket connection
@@ -31,7 +29,7 @@ func NewClient(hub *Hub, conn *websocket.Conn) *Client {
 	return &Client{
 		hub:  hub,
 		conn: conn,
-		send: make(chan []byte, 256),
+		send: make(chan []byte, hub.config.SendBufferSize),
 	}
 }
 
@@ -42,7 +40,7 @@ func (c *Client) ReadPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(c.hub.config.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
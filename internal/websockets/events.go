@@ -3,16 +3,8 @@ package websockets
 import (
 	"encoding/json"
 	"log"
-)
 
-// Event types
-const (
-	EventProductCreated  = "product:created"
-	EventProductUpdated  = "product:updated"
-	EventProductDeleted  = "product:deleted"
-	EventCategoryCreated = "category:created"
-	EventCategoryUpdated = "category:updated"
-	EventCategoryDeleted = "category:deleted"
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/models"
 )
 
 type Event struct {
@@ -25,6 +17,9 @@ func BroadcastEvent(hub *Hub, eventType string, data interface{}) {
 		return
 	}
 
+	if formatter, ok := data.(models.PriceFormatter); ok {
+		data = formatter.InPriceFormat(hub.priceFormat)
+	}
 	event := Event{
 		Type: eventType,
 		Data: data,
@@ -41,3 +36,8 @@ func BroadcastEvent(hub *Hub, eventType string, data interface{}) {
 
 	log.Printf("Broadcasted event '%s' to %d clients", eventType, hub.ClientCount())
 }
+
+// Publish broadcasts an event to all clients; it lets the hub act as a service.Publisher
+func (h *Hub) Publish(eventType string, data interface{}) {
+	BroadcastEvent(h, eventType, data)
+}
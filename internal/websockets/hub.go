@@ -3,6 +3,8 @@ package websockets
 import (
 	"log"
 	"sync"
+
+	"github.com/BrunoMalagoli/bsmart-challenge/internal/config"
 )
 
 // Hub maintains the set of active clients and broadcasts messages to them
@@ -15,16 +17,24 @@ type Hub struct {
 
 	unregister chan *Client
 
+	// Buffer sizes and limits for the hub and its clients
+	config config.WebSocketConfig
+
+	// Money JSON format of the broadcast events (pricing.json_format)
+	priceFormat string
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
 }
 
-func NewHub() *Hub {
+func NewHub(cfg config.WebSocketConfig, priceFormat string) *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
+		config:      cfg,
+		priceFormat: priceFormat,
+		broadcast:   make(chan []byte, cfg.BroadcastBufferSize),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		clients:     make(map[*Client]bool),
 	}
 }
 